@@ -0,0 +1,37 @@
+// Command clib is getswaps' swap parser as a C shared library, for calling
+// from Python, Node.js, Rust and other languages over FFI. Build it with
+// make clib, or
+//
+//	go build -buildmode=c-shared -o libgetswaps.so ./cmd/clib
+//
+// which also writes libgetswaps.h declaring:
+//
+//	char* parseSwapJSON(char* txJSON);
+//	void freeString(char* s);
+//
+// parseSwapJSON takes a getTransaction result as JSON and returns the
+// combined swap and transaction record as JSON, or {"error": "..."}. Each
+// string it returns must be passed to freeString once the caller is done
+// with it.
+package main
+
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/clib"
+)
+
+//export parseSwapJSON
+func parseSwapJSON(txJSON *C.char) *C.char {
+	return (*C.char)(clib.ParseSwapJSON(unsafe.Pointer(txJSON)))
+}
+
+//export freeString
+func freeString(s *C.char) {
+	clib.FreeString(unsafe.Pointer(s))
+}
+
+// main is required by -buildmode=c-shared but never runs
+func main() {}
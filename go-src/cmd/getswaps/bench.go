@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/reports"
+)
+
+// benchReport is what bench prints. Latencies are in milliseconds.
+type benchReport struct {
+	Signature         solana.Signature `json:"signature"`
+	Requests          int              `json:"requests"`
+	Concurrency       int              `json:"concurrency"`
+	Errors            int              `json:"errors"`
+	ErrorRate         float64          `json:"error_rate"`
+	DurationMs        float64          `json:"duration_ms"`
+	RequestsPerSecond float64          `json:"requests_per_second"`
+	Latency           benchLatency     `json:"latency_ms"`
+}
+
+type benchLatency struct {
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+}
+
+// runBench fetches the same transaction repeatedly to measure how the RPC
+// endpoint holds up under concurrent load
+func runBench(args []string) {
+	fs := newFlagSet("bench")
+	sig := fs.String("sig", "", "signature of a representative transaction to fetch (required)")
+	requests := fs.Int("requests", 1000, "total getTransaction requests to send")
+	concurrency := fs.Int("concurrency", 20, "requests in flight at once")
+	fs.Parse(args)
+
+	if *sig == "" {
+		log.Fatal("bench: --sig is required")
+	}
+	txSig, err := solana.SignatureFromBase58(*sig)
+	if err != nil {
+		log.Fatalf("bench: invalid --sig: %s", err)
+	}
+	if *requests < 1 || *concurrency < 1 {
+		log.Fatal("bench: --requests and --concurrency must be at least 1")
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		failures  int
+	)
+	jobs := make(chan struct{})
+	var wg sync.WaitGroup
+	for range min(*concurrency, *requests) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				start := time.Now()
+				_, err := fetchTransaction(ctx, rpcClient, txSig)
+				latency := time.Since(start)
+				// Requests cut short by cancellation say nothing about the endpoint
+				if ctx.Err() != nil {
+					continue
+				}
+				mu.Lock()
+				if err != nil {
+					failures++
+				} else {
+					latencies = append(latencies, latency)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	start := time.Now()
+feed:
+	for range *requests {
+		select {
+		case jobs <- struct{}{}:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	stats := reports.LatencyStats(latencies)
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	report := benchReport{
+		Signature:   txSig,
+		Requests:    len(latencies) + failures,
+		Concurrency: *concurrency,
+		Errors:      failures,
+		DurationMs:  ms(elapsed),
+		Latency: benchLatency{
+			Min:  ms(stats.Min),
+			Max:  ms(stats.Max),
+			Mean: ms(stats.Mean),
+			P50:  ms(stats.P50),
+			P95:  ms(stats.P95),
+			P99:  ms(stats.P99),
+		},
+	}
+	if report.Requests > 0 {
+		report.ErrorRate = float64(failures) / float64(report.Requests)
+		report.RequestsPerSecond = float64(report.Requests) / elapsed.Seconds()
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Fatalf("Error writing output: %s", err)
+	}
+}
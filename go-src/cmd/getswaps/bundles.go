@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/mev"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/price"
+)
+
+// runBundles scans recent blocks for Jito bundles and prints whether each
+// one's swaps made back its tip
+func runBundles(args []string) {
+	fs := newFlagSet("bundles")
+	blocks := fs.Uint64("blocks", 50, "how many of the most recent slots to scan")
+	unprofitable := fs.Bool("unprofitable", false, "only print bundles whose profit didn't cover the tip")
+	fs.Parse(args)
+
+	if *blocks == 0 {
+		log.Fatal("bundles: --blocks must be at least 1")
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+
+	produced, err := recentBlocks(ctx, rpcClient, *blocks)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// One analysis per line, like NDJSON output
+	enc := json.NewEncoder(os.Stdout)
+	found, flagged := 0, 0
+	for _, slot := range produced {
+		if ctx.Err() != nil {
+			break
+		}
+		results, err := fetchBlockResults(ctx, rpcClient, slot)
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+		var swaps []*model.SwapData
+		for _, result := range results {
+			swaps = append(swaps, result.AllSwaps()...)
+		}
+		if err := (price.StablecoinEnricher{}).Enrich(ctx, swaps); err != nil {
+			log.Fatalf("Error pricing swaps: %s", err)
+		}
+
+		for _, analysis := range mev.AnalyzeBundleProfitability(swaps) {
+			found++
+			if analysis.Unprofitable {
+				flagged++
+			} else if *unprofitable {
+				continue
+			}
+			if err := enc.Encode(analysis); err != nil {
+				log.Fatalf("Error writing output: %s", err)
+			}
+		}
+	}
+	printInfo("Found %d bundles in %d blocks, %d unprofitable\n", found, len(produced), flagged)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+// ansiEscape matches the SGR sequences used to color terminal output
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func init() {
+	// Color is on by default only when stdout is a terminal and NO_COLOR
+	// (https://no-color.org) is unset or empty
+	tty := isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd())
+	color.NoColor = os.Getenv("NO_COLOR") != "" || !tty
+}
+
+// colorFlag returns the handler for --color (on) or --no-color (off), so
+// --color=false behaves like --no-color
+func colorFlag(on bool) func(string) error {
+	return func(value string) error {
+		set, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		setColor(set == on)
+		return nil
+	}
+}
+
+// setColor forces color output on or off, overriding the detected default.
+// Turning it off also strips color codes from anything logged.
+func setColor(on bool) {
+	color.NoColor = !on
+	if on {
+		log.SetOutput(os.Stderr)
+	} else {
+		log.SetOutput(stripANSI{os.Stderr})
+	}
+}
+
+// stripANSI removes color codes from everything written through it
+type stripANSI struct {
+	w io.Writer
+}
+
+func (s stripANSI) Write(p []byte) (int, error) {
+	if _, err := s.w.Write(ansiEscape.ReplaceAll(p, nil)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
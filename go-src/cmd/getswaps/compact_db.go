@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage/duckdb"
+)
+
+// runCompactDB shrinks a DuckDB file after rows have been deleted from it
+func runCompactDB(args []string) {
+	fs := newFlagSet("compact-db")
+	db := fs.String("db-path", duckdb.DefaultPath, "DuckDB file to compact")
+	fs.Parse(args)
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	if err := compactDB(ctx, *db); err != nil {
+		log.Fatalf("compact-db: %s", err)
+	}
+}
+
+// compactDB compacts the file and prints its size before and after
+func compactDB(ctx context.Context, path string) error {
+	before, after, err := duckdb.Compact(ctx, path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Compacted %s: %s -> %s\n", path, formatBytes(before), formatBytes(after))
+	return nil
+}
+
+// formatBytes renders a file size in binary units, e.g. 1.5 MiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
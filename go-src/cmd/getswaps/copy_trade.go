@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/backoff"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/copytrade"
+)
+
+// runCopyTrade follows a wallet over a WebSocket subscription and prints an
+// alert for each swap it signs, with the trade that would mirror it
+func runCopyTrade(args []string) {
+	fs := newFlagSet("copy-trade")
+	wallet := fs.String("watch-wallet", "", "wallet to follow (required)")
+	alias := fs.String("alias", "", "name for the wallet in alerts (default its .sol domain or truncated address)")
+	scale := fs.Float64("scale", 1, "size of the recommended trade as a multiple of the wallet's")
+	alertOnly := fs.Bool("alert-only", true, "only print alerts; building transactions isn't supported")
+	fs.Parse(args)
+
+	if *wallet == "" {
+		log.Fatal("copy-trade: --watch-wallet is required")
+	}
+	walletKey, err := solana.PublicKeyFromBase58(*wallet)
+	if err != nil {
+		log.Fatalf("copy-trade: invalid --watch-wallet: %s", err)
+	}
+	if !*alertOnly {
+		log.Fatal("copy-trade: only --alert-only is supported; getswaps doesn't build or send transactions")
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+	name := *alias
+	if name == "" {
+		name = walletNamer(ctx, rpcClient)(walletKey)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	followWallet(ctx, walletKey, func(sig solana.Signature) {
+		result, err := fetchAndParse(ctx, rpcClient, sig)
+		if err != nil {
+			if !errors.Is(err, errFilteredOut) && ctx.Err() == nil {
+				logInfo("Skipping %s: %s", sig, err)
+			}
+			return
+		}
+		// Transactions that only mention the wallet, e.g. a transfer to
+		// it, aren't its trades
+		if !slices.Contains(result.TransactionData.AllSigners, walletKey) {
+			return
+		}
+		for _, swap := range result.AllSwaps() {
+			if err := enc.Encode(copytrade.NewAlert(name, swap, *scale)); err != nil {
+				log.Fatalf("Error writing output: %s", err)
+			}
+		}
+	})
+}
+
+// followWallet calls fn with each successful transaction mentioning wallet
+// as it is confirmed, reconnecting whenever the subscription drops, until
+// ctx is done
+func followWallet(ctx context.Context, wallet solana.PublicKey, fn func(sig solana.Signature)) {
+	url := wsURL()
+	strategy := backoff.ExponentialBackoff{Base: time.Second, Max: backoff.DefaultMax}
+	attempt := 0
+	for {
+		received := false
+		err := subscribeLogs(ctx, url, wallet, func(sig solana.Signature) {
+			received = true
+			fn(sig)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		// A subscription that worked for a while starts the delays over
+		if received {
+			attempt = 0
+		}
+		attempt++
+		delay := strategy.NextDelay(attempt)
+		logInfo("Subscription to %s dropped (%s), reconnecting in %s", wallet, err, delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// subscribeLogs receives transactions mentioning wallet until the
+// subscription fails
+func subscribeLogs(ctx context.Context, url string, wallet solana.PublicKey, fn func(sig solana.Signature)) error {
+	client, err := ws.Connect(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	sub, err := client.LogsSubscribeMentions(wallet, rpc.CommitmentConfirmed)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+	for {
+		notification, err := sub.Recv(ctx)
+		if err != nil {
+			return err
+		}
+		// Failed transactions never moved any tokens
+		if notification.Value.Err != nil {
+			continue
+		}
+		fn(notification.Value.Signature)
+	}
+}
+
+// wsURL is the RPC endpoint's WebSocket API: SOLANA_WS_URL if set, else
+// SOLANA_RPC_URL on the matching ws or wss scheme
+func wsURL() string {
+	if url := os.Getenv("SOLANA_WS_URL"); url != "" {
+		return url
+	}
+	url := os.Getenv("SOLANA_RPC_URL")
+	if url == "" {
+		log.Fatal("SOLANA_RPC_URL not set in environment or .env file")
+	}
+	if rest, ok := strings.CutPrefix(url, "https://"); ok {
+		return "wss://" + rest
+	}
+	if rest, ok := strings.CutPrefix(url, "http://"); ok {
+		return "ws://" + rest
+	}
+	return url
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/reports"
+)
+
+// runCount prints quick statistics about a file of getswaps results
+func runCount(args []string) {
+	fs := newFlagSet("count")
+	input := fs.String("input", "-", "json or NDJSON results to read (- for stdin)")
+	format := fs.String("output", "json", "output format: json, table")
+	fs.Parse(args)
+
+	in := os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			log.Fatalf("count: %s", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	report, err := reports.Count(in)
+	if err != nil {
+		log.Fatalf("count: %s", err)
+	}
+
+	timestamp := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Format(time.RFC3339)
+	}
+	rows := [][]string{
+		{"Records", strconv.Itoa(report.Records)},
+		{"FirstBlockTime", timestamp(report.FirstBlockTime)},
+		{"LastBlockTime", timestamp(report.LastBlockTime)},
+		{"UniqueWallets", strconv.Itoa(report.UniqueWallets)},
+		{"UniquePrograms", strconv.Itoa(report.UniquePrograms)},
+	}
+	for _, d := range report.ByDex {
+		name := d.Dex
+		if name == "" {
+			name = "unknown"
+		}
+		rows = append(rows, []string{"DEX " + name, strconv.Itoa(d.Records)})
+	}
+	for _, t := range report.ByToken {
+		name := t.Symbol
+		if name == "" {
+			name = t.Mint.String()
+		}
+		rows = append(rows, []string{"Token " + name, strconv.Itoa(t.Records)})
+	}
+	writeReport(*format, report, []string{"Metric", "Value"}, rows, 1)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/instructions"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/registry"
+)
+
+// runtimePrograms names the native programs most transactions call, which
+// aren't in the DEX registry
+var runtimePrograms = map[solana.PublicKey]string{
+	solana.SystemProgramID:                    "System Program",
+	solana.TokenProgramID:                     "Token Program",
+	solana.Token2022ProgramID:                 "Token-2022",
+	solana.SPLAssociatedTokenAccountProgramID: "Associated Token Account",
+	solana.ComputeBudget:                      "Compute Budget",
+	solana.MemoProgramID:                      "Memo",
+}
+
+// runCPIGraph draws a transaction's cross-program invocations
+func runCPIGraph(args []string) {
+	fs := newFlagSet("cpi-graph")
+	sigStr := fs.String("sig", "", "transaction signature to graph (required)")
+	format := fs.String("output", "dot", "output format: dot (Graphviz) or json (the call tree)")
+	fs.Parse(args)
+
+	if *sigStr == "" {
+		log.Fatal("cpi-graph: --sig is required")
+	}
+	txSig, err := solana.SignatureFromBase58(*sigStr)
+	if err != nil {
+		log.Fatalf("cpi-graph: invalid --sig: %s", err)
+	}
+	if *format != "dot" && *format != "json" {
+		log.Fatalf("unknown output format %q (want dot or json)", *format)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+
+	tx, err := fetchTransaction(ctx, rpcClient, txSig)
+	if err != nil {
+		log.Fatalf("Error fetching transaction: %s", err)
+	}
+	tree := instructions.BuildCPITree(tx)
+	if tree == nil {
+		log.Fatalf("Error decoding %s", txSig)
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(tree); err != nil {
+			log.Fatalf("Error writing output: %s", err)
+		}
+		return
+	}
+	if err := instructions.WriteDOT(os.Stdout, tree, programName); err != nil {
+		log.Fatalf("Error writing output: %s", err)
+	}
+}
+
+// programName labels a program by its registry entry or runtime name, or
+// "" if it isn't known
+func programName(program solana.PublicKey) string {
+	if info, ok := registry.Default().Lookup(program); ok {
+		return strings.TrimSpace(info.Name + " " + info.Version)
+	}
+	return runtimePrograms[program]
+}
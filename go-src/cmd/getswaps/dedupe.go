@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/dedup"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/output"
+)
+
+// runDedupe removes repeated signatures from a signature list or NDJSON file,
+// keeping the first occurrence of each, or with --resubmissions tags swaps
+// that repeat an earlier one under a new signature
+func runDedupe(args []string) {
+	fs := newFlagSet("dedupe")
+	input := fs.String("input", "-", "signature list or NDJSON file to read (- for stdin)")
+	outPath := fs.String("output", "-", "file to write (- for stdout)")
+	resubmissions := fs.Bool("resubmissions", false, "instead tag getswaps results that resubmit an earlier swap with is_resubmission, keeping every record")
+	fs.Parse(args)
+
+	in := os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			log.Fatalf("dedupe: %s", err)
+		}
+		defer f.Close()
+		in = f
+	}
+	var out io.Writer = os.Stdout
+	var file *output.AtomicWriter
+	if *outPath != "-" {
+		f, err := output.NewAtomicWriter(*outPath)
+		if err != nil {
+			log.Fatalf("dedupe: %s", err)
+		}
+		file, out = f, f
+	}
+
+	w := bufio.NewWriter(out)
+	if *resubmissions {
+		tagged, err := tagResubmissions(in, w)
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			log.Fatalf("dedupe: %s", err)
+		}
+		commitOutput("dedupe", file)
+		printInfo("Tagged %d resubmissions\n", tagged)
+		return
+	}
+	removed, err := dedupe(in, w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		log.Fatalf("dedupe: %s", err)
+	}
+	commitOutput("dedupe", file)
+	printInfo("Removed %d duplicates\n", removed)
+}
+
+// tagResubmissions copies getswaps results from r to w as NDJSON, marking
+// each swap dedup.DeduplicateResubmissions finds to be a resubmission
+func tagResubmissions(r io.Reader, w io.Writer) (tagged int, err error) {
+	var results []*model.Result
+	var swaps []*model.SwapData
+	dec := json.NewDecoder(r)
+	for {
+		result := new(model.Result)
+		err := dec.Decode(result)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("record %d: %w", len(results)+1, err)
+		}
+		results = append(results, result)
+		swaps = append(swaps, result.AllSwaps()...)
+	}
+
+	tagged = len(swaps) - len(dedup.DeduplicateResubmissions(swaps))
+	enc := json.NewEncoder(w)
+	for _, result := range results {
+		if err := enc.Encode(result); err != nil {
+			return tagged, err
+		}
+	}
+	return tagged, nil
+}
+
+// dedupe copies r to w, dropping lines whose signature was already seen. A
+// line starting with { is an NDJSON record keyed by its "signature" field,
+// or swap_data.signature for getswaps output; any other line is a raw
+// signature. Blank lines and # comments are copied as-is.
+func dedupe(r io.Reader, w io.Writer) (removed int, err error) {
+	seen := make(map[string]struct{})
+	br := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, readErr := br.ReadBytes('\n')
+		if len(line) > 0 {
+			key, err := signatureKey(bytes.TrimSpace(line))
+			if err != nil {
+				return removed, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			if key != "" {
+				if _, dup := seen[key]; dup {
+					removed++
+					continue
+				}
+				seen[key] = struct{}{}
+			}
+			if _, err := w.Write(line); err != nil {
+				return removed, err
+			}
+		}
+		if readErr == io.EOF {
+			return removed, nil
+		}
+		if readErr != nil {
+			return removed, readErr
+		}
+	}
+}
+
+// signatureKey returns the signature a line is deduplicated by, or "" for
+// lines that aren't deduplicated
+func signatureKey(line []byte) (string, error) {
+	if len(line) == 0 || line[0] == '#' {
+		return "", nil
+	}
+	if line[0] != '{' {
+		return string(line), nil
+	}
+
+	var record struct {
+		Signature string `json:"signature"`
+		SwapData  *struct {
+			Signature string `json:"signature"`
+		} `json:"swap_data"`
+	}
+	if err := json.Unmarshal(line, &record); err != nil {
+		return "", err
+	}
+	switch {
+	case record.Signature != "":
+		return record.Signature, nil
+	case record.SwapData != nil && record.SwapData.Signature != "":
+		return record.SwapData.Signature, nil
+	default:
+		return "", errors.New(`record has no "signature" field`)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/blockcompare"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/parser"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// runDiffBlocks reports how the swaps in two slots differ
+func runDiffBlocks(args []string) {
+	fs := newFlagSet("diff-blocks")
+	slotA := fs.Uint64("slot-a", 0, "first slot to compare (required)")
+	slotB := fs.Uint64("slot-b", 0, "second slot to compare (required)")
+	fs.Parse(args)
+
+	if *slotA == 0 || *slotB == 0 {
+		log.Fatal("diff-blocks: --slot-a and --slot-b are required")
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+
+	swapsA, err := fetchBlockSwaps(ctx, rpcClient, *slotA)
+	if err != nil {
+		log.Fatal(err)
+	}
+	swapsB, err := fetchBlockSwaps(ctx, rpcClient, *slotB)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(blockcompare.Diff(*slotA, swapsA, *slotB, swapsB)); err != nil {
+		log.Fatalf("Error writing output: %s", err)
+	}
+}
+
+// recentBlocks returns the produced slots among the n most recent, oldest
+// first. Skipped slots have no block to fetch.
+func recentBlocks(ctx context.Context, rpcClient *rpc.Client, n uint64) (rpc.BlocksResult, error) {
+	tip, err := rpcClient.GetSlot(ctx, rpc.CommitmentConfirmed)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting current slot: %s", err)
+	}
+	start := tip - min(tip, n-1)
+	produced, err := rpcClient.GetBlocks(ctx, start, &tip, rpc.CommitmentConfirmed)
+	if err != nil {
+		return nil, fmt.Errorf("Error listing blocks %d-%d: %s", start, tip, err)
+	}
+	return produced, nil
+}
+
+// fetchBlockSwaps parses every successful swap in a slot, in block order,
+// from a single getBlock call
+func fetchBlockSwaps(ctx context.Context, rpcClient *rpc.Client, slot uint64) ([]*model.SwapData, error) {
+	results, err := fetchBlockResults(ctx, rpcClient, slot)
+	if err != nil {
+		return nil, err
+	}
+	var swaps []*model.SwapData
+	for _, result := range results {
+		swaps = append(swaps, result.AllSwaps()...)
+	}
+	return swaps, nil
+}
+
+// fetchBlockResults is fetchBlockSwaps with each swap's whole result
+func fetchBlockResults(ctx context.Context, rpcClient *rpc.Client, slot uint64) ([]*model.Result, error) {
+	var results []*model.Result
+	err := eachBlockTransaction(ctx, rpcClient, slot, func(i int, tx *rpc.GetTransactionResult) {
+		// Most of a block isn't swaps, so parse failures aren't worth logging
+		result, err := parser.ParseSwap(tx)
+		if err != nil {
+			return
+		}
+		for _, swap := range result.AllSwaps() {
+			swap.BlockIndex = &i
+		}
+		results = append(results, result)
+	})
+	return results, err
+}
+
+// eachBlockTransaction fetches a slot's block with a single getBlock call
+// and calls fn with each successful transaction and its position in the
+// block, in block order, leaving out those skipTransaction rules out
+func eachBlockTransaction(ctx context.Context, rpcClient *rpc.Client, slot uint64, fn func(i int, tx *rpc.GetTransactionResult)) error {
+	var maxTxVersion uint64 = 0
+	rewards := false
+	block, err := rpcClient.GetBlockWithOpts(ctx, slot, &rpc.GetBlockOpts{
+		Encoding:                       solana.EncodingBase64,
+		TransactionDetails:             rpc.TransactionDetailsFull,
+		Rewards:                        &rewards,
+		Commitment:                     rpc.CommitmentConfirmed,
+		MaxSupportedTransactionVersion: &maxTxVersion,
+	})
+	if err != nil {
+		return fmt.Errorf("Error fetching block %d: %s", slot, err)
+	}
+
+	for i, blockTx := range block.Transactions {
+		if blockTx.Meta == nil || blockTx.Meta.Err != nil {
+			continue
+		}
+		// Votes and --skip-programs transactions can't be swaps, so skip
+		// them before the costlier conversion
+		if decoded, err := blockTx.GetTransaction(); err == nil && skipTransaction(decoded) {
+			continue
+		}
+		tx, err := txutil.FromBlock(slot, block.BlockTime, blockTx)
+		if err != nil {
+			continue
+		}
+		fn(i, tx)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/output"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage/duckdb"
+)
+
+// runExport writes the swaps stored in a DuckDB file in any output format,
+// without refetching or re-parsing them
+func runExport(args []string) {
+	fs := newFlagSet("export")
+	db := fs.String("db-path", duckdb.DefaultPath, "DuckDB file written by --output duckdb")
+	table := fs.String("table", storage.DefaultTable, "table to export")
+	where := fs.String("where", "", `SQL condition on the table's columns, e.g. "block_time > '2024-01-01'"`)
+	format := fs.String("output", "ndjson", "output format: "+strings.Join(output.Formats, ", "))
+	outPath := fs.String("out", "", "file to write (default stdout)")
+	fs.Parse(args)
+
+	if _, err := os.Stat(*db); err != nil {
+		log.Fatalf("export: %s", err)
+	}
+	var dest io.Writer = os.Stdout
+	var file *output.AtomicWriter
+	if *outPath != "" {
+		f, err := output.NewAtomicWriter(*outPath)
+		if err != nil {
+			log.Fatalf("export: %s", err)
+		}
+		file, dest = f, f
+	}
+	w, err := output.New(*format, dest, output.Options{})
+	if err != nil {
+		log.Fatalf("export: %s", err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	exporter, err := duckdb.NewExporter(duckdb.Config{Path: *db, Table: *table})
+	if err != nil {
+		log.Fatalf("export: %s", err)
+	}
+	defer exporter.Close()
+
+	err = exporter.Export(ctx, *where, func(s *model.SwapData) error {
+		return w.Write(&model.Result{SwapData: s})
+	})
+	if err != nil {
+		log.Fatalf("Error exporting %s: %s", *table, err)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatalf("Error writing output: %s", err)
+	}
+	commitOutput("export", file)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/registry"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/reports"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+)
+
+// runFeeComparison compares the effective fees DEXes charged on recent
+// swaps of a token pair
+func runFeeComparison(args []string) {
+	fs := newFlagSet("fee-comparison")
+	tokenA := fs.String("token-a", "", "token symbol (e.g. SOL) or mint address (required)")
+	tokenB := fs.String("token-b", "", "token symbol (e.g. USDC) or mint address (required)")
+	amount := fs.Float64("amount", 100, "amount of token A to estimate the fee on")
+	hours := fs.Float64("hours", 1, "how far back to scan")
+	limit := fs.Int("limit", 500, "stop after this many signatures per program (0 = no limit)")
+	workers := fs.Int("workers", 8, "concurrent transaction fetches")
+	format := fs.String("output", "table", "output format: json, table")
+	fs.Parse(args)
+
+	mintA, err := parseToken(*tokenA)
+	if err != nil {
+		log.Fatalf("fee-comparison: invalid --token-a: %s", err)
+	}
+	mintB, err := parseToken(*tokenB)
+	if err != nil {
+		log.Fatalf("fee-comparison: invalid --token-b: %s", err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+	// Every name starts with "", so this is every registered program
+	programs := registry.Default().Find("")
+	since := time.Now().Add(-time.Duration(*hours * float64(time.Hour)))
+	swaps := collectProgramSwaps(ctx, rpcClient, programs, since, *limit, *workers)
+	rows := reports.FeeComparison(swaps, mintA, mintB, *amount)
+
+	cells := make([][]string, len(rows))
+	for i, r := range rows {
+		cells[i] = []string{
+			strconv.Itoa(r.Rank),
+			r.Dex,
+			strconv.Itoa(r.SwapCount),
+			fmt.Sprintf("%.1f", r.AvgFeeBps),
+			fmt.Sprintf("%.6g", r.EstimatedFee),
+		}
+	}
+	writeReport(*format, rows, []string{"Rank", "Dex", "SwapCount", "AvgFeeBps", "EstimatedFee"}, cells, 0, 2, 3, 4)
+}
+
+// parseToken reads a token flag: a symbol from the built-in token list or
+// a mint address
+func parseToken(value string) (solana.PublicKey, error) {
+	if value == "" {
+		return solana.PublicKey{}, errors.New("a symbol or mint address is required")
+	}
+	if mint, ok := tokenmetadata.FromSymbol(value); ok {
+		return mint, nil
+	}
+	return solana.PublicKeyFromBase58(value)
+}
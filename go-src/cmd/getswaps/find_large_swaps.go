@@ -0,0 +1,84 @@
+package main
+
+import (
+	"log"
+	"slices"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/price"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/registry"
+)
+
+// runFindLargeSwaps scans recent blocks for swaps worth at least a USD
+// threshold, printing each block's matches as soon as it is parsed
+func runFindLargeSwaps(args []string) {
+	fs := newFlagSet("find-large-swaps")
+	program := fs.String("program", "", "only swaps on programs whose registry name starts with this, e.g. raydium (default any DEX)")
+	minUSD := fs.Float64("min-usd", 100000, "smallest swap volume to report, in USD")
+	blocks := fs.Uint64("blocks", 50, "how many of the most recent slots to scan")
+	var outOpts outputOptions
+	outOpts.register(fs, "ndjson")
+	fs.Parse(args)
+
+	if *blocks == 0 {
+		log.Fatal("find-large-swaps: --blocks must be at least 1")
+	}
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+
+	// Resolved once the RPC client has picked the cluster's registry
+	var programs []solana.PublicKey
+	if *program != "" {
+		if programs = registry.Default().Find(*program); len(programs) == 0 {
+			log.Fatalf("No program in the registry matches %q", *program)
+		}
+	}
+
+	produced, err := recentBlocks(ctx, rpcClient, *blocks)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	out := outOpts.writer(ctx, rpcClient)
+	defer out.Close()
+	found := 0
+	for _, slot := range produced {
+		if ctx.Err() != nil {
+			break
+		}
+		results, err := fetchBlockResults(ctx, rpcClient, slot)
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		// Price against the whole block, which has far more stablecoin
+		// pairs than the program's swaps alone
+		var swaps []*model.SwapData
+		bySwap := make(map[*model.SwapData]*model.Result, len(results))
+		for _, result := range results {
+			for _, swap := range result.AllSwaps() {
+				swaps = append(swaps, swap)
+				bySwap[swap] = result
+			}
+		}
+		if err := (price.StablecoinEnricher{}).Enrich(ctx, swaps); err != nil {
+			log.Fatalf("Error pricing swaps: %s", err)
+		}
+
+		// Largest first within each block; blocks are printed oldest first
+		for _, s := range price.FilterByMinVolume(swaps, *minUSD) {
+			if programs != nil && (s.ProgramID == nil || !slices.Contains(programs, *s.ProgramID)) {
+				continue
+			}
+			if err := out.Write(bySwap[s].ForSwap(s)); err != nil {
+				log.Fatalf("Error writing output: %s", err)
+			}
+			found++
+		}
+	}
+	printInfo("Found %d swaps of at least $%.0f in %d blocks\n", found, *minUSD, len(produced))
+}
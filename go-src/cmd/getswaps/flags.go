@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/output"
+)
+
+// outputOptions are the flags shared by every command that prints swaps
+type outputOptions struct {
+	format       string
+	largeSwapSOL float64
+	resolveNames bool
+	compactJSON  bool
+	filter       *output.Filter
+
+	// Write to this file instead of stdout, split at maxOutputSize bytes
+	outputFile    string
+	maxOutputSize int64
+
+	storage storageOptions
+}
+
+func (o *outputOptions) register(fs *flag.FlagSet, defaultFormat string) {
+	fs.StringVar(&o.format, "output", defaultFormat, "output format: "+strings.Join(slices.Concat(output.Formats, storageFormats), ", "))
+	fs.BoolFunc("human-readable", "shorthand for --output table, which shows amounts at token precision with M/B/T for large ones", func(string) error {
+		o.format = "table"
+		return nil
+	})
+	fs.Float64Var(&o.largeSwapSOL, "large-swap-sol", 100, "highlight swaps moving at least this much SOL in table output")
+	fs.BoolVar(&o.resolveNames, "resolve-names", false, "show wallets by their .sol domain in table output")
+	fs.BoolVar(&o.compactJSON, "compact-json", false, "leave zero numbers and empty strings out of json and ndjson output")
+	fs.Func("filter-expr", "only output results matching this JMESPath expression, e.g. \"swap_data.dex == 'Raydium'\"", func(expression string) error {
+		filter, err := output.NewFilter(expression)
+		o.filter = filter
+		return err
+	})
+	fs.StringVar(&o.outputFile, "output-file", "", "write output to this file instead of stdout, gzipped if it ends in .gz")
+	fs.Func("max-output-size", "with --output-file, start a new numbered file (swaps_001.ndjson, ...) once one reaches this size before compression, e.g. 100MB", func(value string) error {
+		size, err := output.ParseSize(value)
+		o.maxOutputSize = size
+		return err
+	})
+	o.storage.register(fs)
+}
+
+// commitOutput renames a command's finished output file into place. file
+// is nil when the command wrote to stdout.
+func commitOutput(command string, file *output.AtomicWriter) {
+	if file == nil {
+		return
+	}
+	if err := file.Commit(); err != nil {
+		log.Fatalf("%s: %s", command, err)
+	}
+}
+
+// writer builds the configured output writer, on stdout unless the format
+// is a database
+func (o *outputOptions) writer(ctx context.Context, rpcClient *rpc.Client) output.Writer {
+	w := o.formatWriter(ctx, rpcClient)
+	if o.filter != nil {
+		w = output.Filtered(w, o.filter)
+	}
+	return networkWriter{w}
+}
+
+// formatWriter builds the writer for --output without any filtering
+func (o *outputOptions) formatWriter(ctx context.Context, rpcClient *rpc.Client) output.Writer {
+	if o.maxOutputSize > 0 && o.outputFile == "" {
+		log.Fatal("--max-output-size needs --output-file")
+	}
+	if isStorageFormat(o.format) {
+		if o.outputFile != "" {
+			log.Fatalf("--output-file can't be used with --output %s", o.format)
+		}
+		w, err := o.storage.writer(ctx, o.format)
+		if err != nil {
+			log.Fatalf("Error opening %s output: %s", o.format, err)
+		}
+		return w
+	}
+	opts := output.Options{LargeSwapSOL: o.largeSwapSOL, CompactJSON: o.compactJSON}
+	if o.resolveNames {
+		opts.WalletName = walletNamer(ctx, rpcClient)
+	}
+	if o.outputFile != "" {
+		// Check the format now rather than when the first result arrives
+		if !slices.Contains(output.Formats, o.format) {
+			log.Fatalf("unknown output format %q (want one of %v)", o.format, output.Formats)
+		}
+		return output.NewRollingWriter(o.outputFile, o.maxOutputSize, func(w io.Writer) (output.Writer, error) {
+			return output.New(o.format, w, opts)
+		})
+	}
+	w, err := output.New(o.format, os.Stdout, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return w
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/parser"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/price"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/reports"
+)
+
+// runGasAnalysis reports fee statistics over a wallet's recent swaps and
+// failed transactions
+func runGasAnalysis(args []string) {
+	fs := newFlagSet("gas-analysis")
+	wallet := fs.String("wallet", "", "wallet address to analyse (required)")
+	limit := fs.Int("limit", 500, "number of recent signatures to analyse")
+	workers := fs.Int("workers", 8, "concurrent transaction fetches")
+	format := fs.String("output", "json", "output format: json, table")
+	fs.Parse(args)
+
+	if *wallet == "" {
+		log.Fatal("gas-analysis: --wallet is required")
+	}
+	walletKey, err := solana.PublicKeyFromBase58(*wallet)
+	if err != nil {
+		log.Fatalf("gas-analysis: invalid --wallet: %s", err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+
+	var sigs []solana.Signature
+	err = signatureHistory(ctx, rpcClient, walletKey, func(sig *rpc.TransactionSignature) bool {
+		if *limit > 0 && len(sigs) >= *limit {
+			return false
+		}
+		sigs = append(sigs, sig.Signature)
+		return true
+	})
+	if err != nil {
+		log.Fatalf("Error scanning %s: %s", walletKey, err)
+	}
+
+	// Failed transactions are kept (without swap legs) for the landing rate;
+	// successful ones that aren't swaps are skipped by parser.ParseSwap
+	var swaps []*model.SwapData
+	work := func(txSig solana.Signature) (*model.Result, error) {
+		tx, err := fetchTransaction(ctx, rpcClient, txSig)
+		if err != nil {
+			return nil, fmt.Errorf("Error fetching transaction: %s", err)
+		}
+		if tx.Meta != nil && tx.Meta.Err != nil {
+			return parser.FailedResult(tx)
+		}
+		return parser.ParseSwap(tx)
+	}
+	processSignaturesWith(ctx, sigs, *workers, work, func(result *model.Result) {
+		swaps = append(swaps, result.AllSwaps()...)
+	})
+
+	if err := (price.StablecoinEnricher{}).Enrich(ctx, swaps); err != nil {
+		log.Fatalf("Error pricing swaps: %s", err)
+	}
+	report := reports.FeeAnalysis(swaps)
+
+	u := func(v uint64) string { return strconv.FormatUint(v, 10) }
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	rows := [][]string{
+		{"Transactions", strconv.Itoa(report.Transactions)},
+		{"Landed", strconv.Itoa(report.Landed)},
+		{"Failed", strconv.Itoa(report.Failed)},
+		{"LandingRate", strconv.FormatFloat(report.LandingRate, 'f', 4, 64)},
+		{"MinFeeLamports", u(report.MinFeeLamports)},
+		{"MaxFeeLamports", u(report.MaxFeeLamports)},
+		{"MeanFeeLamports", f(report.MeanFeeLamports)},
+		{"P50FeeLamports", u(report.P50FeeLamports)},
+		{"P95FeeLamports", u(report.P95FeeLamports)},
+		{"TotalFeeLamports", u(report.TotalFeeLamports)},
+		{"TotalFeesUSD", f(report.TotalFeesUSD)},
+		{"TotalTokenAccountRentLamports", u(report.TotalTokenAccountRentLamports)},
+		{"TotalCostLamports", u(report.TotalCostLamports)},
+		{"MeanPriorityFeeLanded", f(report.MeanPriorityFeeLandedLamports)},
+		{"MeanPriorityFeeFailed", f(report.MeanPriorityFeeFailedLamports)},
+		{"PriorityFeeLandingCorrelation", strconv.FormatFloat(report.PriorityFeeLandingCorrelation, 'f', 4, 64)},
+	}
+	writeReport(*format, report, []string{"Metric", "Value"}, rows, 1)
+}
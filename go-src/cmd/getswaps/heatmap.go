@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/price"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/reports"
+)
+
+// runHeatmap draws swap activity in a file of getswaps results by weekday
+// and time of day
+func runHeatmap(args []string) {
+	fs := newFlagSet("heatmap")
+	input := fs.String("input", "-", "json or NDJSON results to read (- for stdin)")
+	granularity := fs.String("granularity", "hour", "width of each column: hour, or a duration that divides a day, e.g. 30m")
+	metric := fs.String("metric", reports.HeatmapCount, "what each cell totals: count, or volume in USD")
+	format := fs.String("output", "csv", "output format: csv, svg, json")
+	fs.Parse(args)
+
+	bucket := time.Hour
+	if *granularity != "hour" {
+		var err error
+		if bucket, err = time.ParseDuration(*granularity); err != nil {
+			log.Fatalf("heatmap: invalid --granularity: %s", err)
+		}
+	}
+
+	in := os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			log.Fatalf("heatmap: %s", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var swaps []*model.SwapData
+	dec := json.NewDecoder(in)
+	for n := 1; ; n++ {
+		var result model.Result
+		err := dec.Decode(&result)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Fatalf("heatmap: record %d: %s", n, err)
+		}
+		swaps = append(swaps, result.AllSwaps()...)
+	}
+
+	if *metric == reports.HeatmapVolume {
+		ctx, cancel := commandContext()
+		defer cancel()
+		// Only stablecoin legs can be priced without fetching anything
+		if err := (price.StablecoinEnricher{}).Enrich(ctx, swaps); err != nil {
+			log.Fatalf("Error pricing swaps: %s", err)
+		}
+	}
+
+	report, err := reports.Heatmap(swaps, bucket, *metric)
+	if err != nil {
+		log.Fatalf("heatmap: %s", err)
+	}
+	if report.Skipped > 0 {
+		logInfo("Skipped %d swaps without a block time", report.Skipped)
+	}
+
+	switch *format {
+	case "csv":
+		err = report.WriteCSV(os.Stdout)
+	case "svg":
+		err = report.WriteSVG(os.Stdout)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	default:
+		log.Fatalf("heatmap: unknown --output %q (want csv, svg or json)", *format)
+	}
+	if err != nil {
+		log.Fatalf("Error writing output: %s", err)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/price/oracle"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+)
+
+// oracleRow is one token's latest Pyth price
+type oracleRow struct {
+	Token string           `json:"token"`
+	Mint  solana.PublicKey `json:"mint"`
+	oracle.Price
+	Age string `json:"age"`
+}
+
+// runHistoricalPrice prints the Pyth oracle price of tokens alongside the
+// feed's EMA price, a time-weighted average of its recent history
+func runHistoricalPrice(args []string) {
+	fs := newFlagSet("historical-price")
+	tokens := fs.String("token", "SOL", "comma-separated token symbols or mint addresses")
+	format := fs.String("output", "table", "output format: json, table")
+	fs.Parse(args)
+
+	var mints []solana.PublicKey
+	for _, value := range strings.Split(*tokens, ",") {
+		mint, err := parseToken(strings.TrimSpace(value))
+		if err != nil {
+			log.Fatalf("historical-price: invalid --token %q: %s", value, err)
+		}
+		if _, err := oracle.FeedAccount(mint); err != nil {
+			log.Fatalf("historical-price: %s: %s", value, err)
+		}
+		mints = append(mints, mint)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+	prices, err := oracle.NewFetcher().Prices(ctx, rpcClient, mints...)
+	if err != nil {
+		log.Fatalf("Error fetching oracle prices: %s", err)
+	}
+
+	var rows []oracleRow
+	var cells [][]string
+	for _, mint := range mints {
+		p, ok := prices[mint]
+		if !ok {
+			log.Printf("%s: price feed account not found", mint)
+			continue
+		}
+		row := oracleRow{
+			Token: tokenmetadata.Symbol(mint),
+			Mint:  mint,
+			Price: p,
+			Age:   time.Since(p.PublishTime).Round(time.Second).String(),
+		}
+		rows = append(rows, row)
+		cells = append(cells, []string{
+			row.Token,
+			fmt.Sprintf("%.6g", p.Price),
+			fmt.Sprintf("%.2g", p.Conf),
+			fmt.Sprintf("%.6g", p.EMAPrice),
+			p.PublishTime.Format(time.RFC3339),
+			row.Age,
+		})
+	}
+	writeReport(*format, rows, []string{"Token", "Price", "Conf", "EMAPrice", "PublishTime", "Age"}, cells, 1, 2, 3)
+}
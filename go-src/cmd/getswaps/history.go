@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/pagination"
+)
+
+// signaturesPageSize is the largest page getSignaturesForAddress will return
+const signaturesPageSize = 1000
+
+// signatureHistory pages through the signatures involving an address,
+// newest first, calling visit for each until it returns false, the history
+// runs out, or ctx is cancelled (which is not an error, so callers go on to
+// use what they visited)
+func signatureHistory(ctx context.Context, rpcClient *rpc.Client, address solana.PublicKey, visit func(sig *rpc.TransactionSignature) bool) error {
+	return signatureHistoryFrom(ctx, rpcClient, address, pagination.Cursor{}, visit, nil)
+}
+
+// signatureHistoryFrom is signatureHistory starting at cursor. If
+// checkpoint is set it is called with the cursor after the last signature
+// visit accepted, at the end of each page and when the walk stops early.
+func signatureHistoryFrom(ctx context.Context, rpcClient *rpc.Client, address solana.PublicKey, cursor pagination.Cursor, visit func(sig *rpc.TransactionSignature) bool, checkpoint func(next pagination.Cursor)) error {
+	pageSize := signaturesPageSize
+	var before solana.Signature
+	if cursor.Before != nil {
+		before = *cursor.Before
+	}
+	// Only checkpoint when visit has accepted something since the last one
+	var last pagination.Cursor
+	pending := false
+	save := func() {
+		if checkpoint != nil && pending {
+			checkpoint(last)
+			pending = false
+		}
+	}
+
+	for {
+		page, err := rpcClient.GetSignaturesForAddressWithOpts(ctx, address, &rpc.GetSignaturesForAddressOpts{
+			Limit:      &pageSize,
+			Before:     before,
+			Commitment: rpc.CommitmentConfirmed,
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("fetching signatures for %s: %w", address, err)
+		}
+		if len(page) == 0 {
+			return nil
+		}
+
+		for _, sig := range page {
+			if ctx.Err() != nil || !visit(sig) {
+				save()
+				return nil
+			}
+			last, pending = pagination.After(sig.Signature), true
+		}
+		save()
+
+		before = page[len(page)-1].Signature
+	}
+}
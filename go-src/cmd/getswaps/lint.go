@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/lint"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// runLint reports logically impossible values in a file of getswaps results
+func runLint(args []string) {
+	fs := newFlagSet("lint")
+	input := fs.String("input", "-", "json or NDJSON results to check (- for stdin)")
+	strict := fs.Bool("strict", false, "exit 1 if there are any violations")
+	fs.Parse(args)
+
+	in := os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			log.Fatalf("lint: %s", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var swaps []*model.SwapData
+	dec := json.NewDecoder(in)
+	for n := 1; ; n++ {
+		var result model.Result
+		err := dec.Decode(&result)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Fatalf("lint: record %d: %s", n, err)
+		}
+		swaps = append(swaps, result.AllSwaps()...)
+	}
+
+	violations := lint.Validate(swaps)
+	// One violation per line, like NDJSON output
+	enc := json.NewEncoder(os.Stdout)
+	for _, v := range violations {
+		if err := enc.Encode(v); err != nil {
+			log.Fatalf("Error writing output: %s", err)
+		}
+	}
+	printInfo("%d violations in %d records\n", len(violations), len(swaps))
+	if *strict && len(violations) > 0 {
+		os.Exit(1)
+	}
+}
@@ -1,76 +1,146 @@
 package main
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"strconv"
+	"time"
 
-	solanaswapgo "github.com/MaybeItsAdam/solanaswap-go/solanaswap-go"
-	solana "github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
 	"github.com/joho/godotenv"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/backoff"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/rpcutil"
 )
 
+// commands maps subcommand names to their entrypoints. Anything else on the
+// command line is treated as a transaction signature to parse.
+var commands = map[string]func(args []string){
+	"bench":                runBench,
+	"bundles":              runBundles,
+	"compact-db":           runCompactDB,
+	"count":                runCount,
+	"copy-trade":           runCopyTrade,
+	"cpi-graph":            runCPIGraph,
+	"dedupe":               runDedupe,
+	"diff-blocks":          runDiffBlocks,
+	"export":               runExport,
+	"fee-comparison":       runFeeComparison,
+	"find-large-swaps":     runFindLargeSwaps,
+	"gas-analysis":         runGasAnalysis,
+	"heatmap":              runHeatmap,
+	"historical-price":     runHistoricalPrice,
+	"lint":                 runLint,
+	"merge":                runMerge,
+	"migrate":              runMigrate,
+	"parse":                runParse,
+	"pnl":                  runPnL,
+	"portfolio":            runPortfolio,
+	"price-history":        runPriceHistory,
+	"protocol-usage-stats": runProtocolUsageStats,
+	"purge":                runPurge,
+	"query":                runQuery,
+	"reindex":              runReindex,
+	"replay":               runReplay,
+	"risk-score":           runRiskScore,
+	"route-optimizer":      runRouteOptimizer,
+	"scan-wallet":          runScanWallet,
+	"schema":               runSchema,
+	"simulate":             runSimulate,
+	"stress-test":          runStressTest,
+	"tax-report":           runTaxReport,
+	"top-pools":            runTopPools,
+	"top-tokens":           runTopTokens,
+	"top-wallets":          runTopWallets,
+	"transform":            runTransform,
+	"validate-sig":         runValidateSig,
+	"verify-rpc":           runVerifyRPC,
+	"version":              runVersion,
+	"watch":                runWatch,
+}
+
 func main() {
 	// Load .env from config directory at project root (two directories up from this file)
 	_ = godotenv.Load("../config/.env")
 
-	// Get QuickNode URL from environment variable
-	solanaRPCURL := os.Getenv("SOLANA_RPC_URL")
-	if solanaRPCURL == "" {
-		log.Fatal("QUICKNODE_URL not set in environment or .env file")
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: getswaps <signature> | getswaps <command> [flags]")
+		os.Exit(2)
 	}
 
-	// Set up RPC client with QuickNode endpoint
-	rpcClient := rpc.New(solanaRPCURL)
-
-	sig := os.Args[1]
-
-	// Replace with your actual transaction signature
-	txSig := solana.MustSignatureFromBase58(sig)
+	if run, ok := commands[os.Args[1]]; ok {
+		run(os.Args[2:])
+		closeMetadataCache()
+		return
+	}
 
-	// Specify the maximum transaction version supported
-	var maxTxVersion uint64 = 0
+	// A bare signature is shorthand for parse --sig
+	runParse([]string{"--sig", os.Args[1]})
+	closeMetadataCache()
+}
 
-	// Fetch the transaction data using the RPC client
-	tx, err := rpcClient.GetTransaction(
-		context.Background(),
-		txSig,
-		&rpc.GetTransactionOpts{
-			Commitment:                     rpc.CommitmentConfirmed,
-			MaxSupportedTransactionVersion: &maxTxVersion,
-		},
-	)
-	if err != nil {
-		log.Fatalf("Error fetching transaction: %s", err)
+// newRPCClient builds an RPC client for the endpoint configured in the environment
+func newRPCClient() *rpc.Client {
+	// Get QuickNode URL from environment variable
+	solanaRPCURL := os.Getenv("SOLANA_RPC_URL")
+	if solanaRPCURL == "" {
+		log.Fatal("SOLANA_RPC_URL not set in environment or .env file")
 	}
+	return newRPCClientFor(solanaRPCURL)
+}
 
-	// Initialize the transaction parser using solanaswapgo
-	parser, err := solanaswapgo.NewTransactionParser(tx)
+// newRPCClientFor builds an RPC client for an endpoint, configured by the
+// global flags
+func newRPCClientFor(solanaRPCURL string) *rpc.Client {
+	strategy, err := backoff.New(global.backoffStrategy)
 	if err != nil {
-		log.Fatalf("Error initializing transaction parser: %s", err)
+		log.Fatalf("invalid --backoff-strategy: %s", err)
 	}
 
-	// Parse the transaction to extract basic data
-	transactionData, err := parser.ParseTransaction()
-	if err != nil {
-		log.Fatalf("Error parsing transaction: %s", err)
+	// Set up RPC client with QuickNode endpoint, retrying transient failures.
+	// The default transport keeps only two idle connections per host, so
+	// with more workers most requests would open a new connection.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = httpPoolSize()
+	transport.MaxIdleConns = max(transport.MaxIdleConns, transport.MaxIdleConnsPerHost)
+	transport.IdleConnTimeout = 90 * time.Second
+	transport.DisableCompression = false
+	if global.tlsCert != "" || global.tlsKey != "" || global.tlsCA != "" {
+		if transport, err = rpcutil.NewTLSClient(transport, global.tlsCert, global.tlsKey, global.tlsCA); err != nil {
+			log.Fatalf("invalid TLS configuration: %s", err)
+		}
 	}
-
-	// Process and extract swap-specific data from the parsed transaction
-	swapData, err := parser.ProcessSwapData(transactionData)
-	if err != nil {
-		log.Fatalf("Error processing swap data: %s", err)
+	if transport, err = rpcutil.NewProxyClient(transport, global.httpProxy, global.socks5Proxy); err != nil {
+		log.Fatal(err)
 	}
-
-	// Combine both outputs into a single JSON object
-	combined := map[string]any{
-		"swap_data":        swapData,
-		"transaction_data": transactionData,
+	httpClient := &http.Client{
+		Transport: &backoff.Transport{Base: transport, Strategy: strategy, MaxRetries: global.maxRetries},
 	}
+	rpcClient := rpc.NewWithCustomRPCClient(jsonrpc.NewClientWithOpts(solanaRPCURL, &jsonrpc.RPCClientOpts{HTTPClient: httpClient}))
+	useNetwork(rpcClient, solanaRPCURL)
+	openMetadataCache()
+	return rpcClient
+}
 
-	marshalledCombined, _ := json.MarshalIndent(combined, "", "  ")
-	fmt.Println(string(marshalledCombined))
+// httpPoolSize returns --http-pool-size, defaulting to two connections per
+// worker for commands with --workers (or bench's --concurrency)
+func httpPoolSize() int {
+	if global.httpPoolSize > 0 {
+		return global.httpPoolSize
+	}
+	workers := 1
+	if global.flags != nil {
+		for _, name := range []string{"workers", "concurrency"} {
+			if f := global.flags.Lookup(name); f != nil {
+				if n, err := strconv.Atoi(f.Value.String()); err == nil && n > 0 {
+					workers = n
+				}
+				break
+			}
+		}
+	}
+	return 2 * workers
 }
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/output"
+)
+
+// mergeRunBytes is how much input merge sorts in memory before spilling a
+// sorted run to disk
+const mergeRunBytes = 64 << 20
+
+// runMerge combines NDJSON swap files into one, sorted by block time with
+// repeated signatures dropped
+func runMerge(args []string) {
+	fs := newFlagSet("merge")
+	inputs := fs.String("inputs", "", "comma-separated NDJSON files to merge, optionally gzipped (required)")
+	outPath := fs.String("output", "-", "file to write, gzipped if it ends in .gz (- for stdout)")
+	fs.Parse(args)
+
+	if *inputs == "" {
+		log.Fatal("merge: --inputs is required")
+	}
+	paths := strings.Split(*inputs, ",")
+
+	var out io.Writer = os.Stdout
+	var file *output.AtomicWriter
+	if *outPath != "-" {
+		f, err := output.NewAtomicWriter(*outPath)
+		if err != nil {
+			log.Fatalf("merge: %s", err)
+		}
+		file, out = f, f
+	}
+	bw := bufio.NewWriter(out)
+	w := io.Writer(bw)
+	var zw *gzip.Writer
+	if strings.HasSuffix(*outPath, ".gz") {
+		zw = gzip.NewWriter(bw)
+		w = zw
+	}
+
+	stats, err := merge(paths, w)
+	if err == nil && zw != nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		log.Fatalf("merge: %s", err)
+	}
+	commitOutput("merge", file)
+	printInfo("Read %d records, removed %d duplicates, wrote %d\n", stats.total, stats.duplicates, stats.written)
+}
+
+type mergeStats struct {
+	total, duplicates, written int
+}
+
+// mergeRecord is an input line with the fields it is ordered by
+type mergeRecord struct {
+	blockTime time.Time
+	signature string
+	line      []byte
+}
+
+func compareRecords(a, b mergeRecord) int {
+	if c := a.blockTime.Compare(b.blockTime); c != 0 {
+		return c
+	}
+	return strings.Compare(a.signature, b.signature)
+}
+
+// merge is an external merge sort: inputs are read into sorted runs of at
+// most mergeRunBytes, spilled to temporary files when there is more than
+// one, then merged. Copies of a transaction share a block time, so they
+// end up adjacent and all but the first are dropped on the way out.
+func merge(paths []string, w io.Writer) (mergeStats, error) {
+	var stats mergeStats
+	tmpDir, err := os.MkdirTemp("", "getswaps-merge-")
+	if err != nil {
+		return stats, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var (
+		run      []mergeRecord
+		runBytes int
+		spilled  []string
+	)
+	spill := func() error {
+		slices.SortStableFunc(run, compareRecords)
+		path := filepath.Join(tmpDir, fmt.Sprintf("run-%d.ndjson", len(spilled)))
+		if err := writeRun(path, run); err != nil {
+			return err
+		}
+		spilled = append(spilled, path)
+		run, runBytes = nil, 0
+		return nil
+	}
+
+	for _, path := range paths {
+		err := readRecords(path, func(r mergeRecord) error {
+			stats.total++
+			run = append(run, r)
+			if runBytes += len(r.line); runBytes >= mergeRunBytes {
+				return spill()
+			}
+			return nil
+		})
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	var sources []recordSource
+	if len(spilled) == 0 {
+		slices.SortStableFunc(run, compareRecords)
+		sources = append(sources, &sliceSource{records: run})
+	} else {
+		if len(run) > 0 {
+			if err := spill(); err != nil {
+				return stats, err
+			}
+		}
+		for _, path := range spilled {
+			f, err := os.Open(path)
+			if err != nil {
+				return stats, err
+			}
+			defer f.Close()
+			sources = append(sources, &fileSource{path: path, r: bufio.NewReader(f)})
+		}
+	}
+
+	var last string
+	err = mergeSources(sources, func(r mergeRecord) error {
+		if stats.written > 0 && r.signature == last {
+			stats.duplicates++
+			return nil
+		}
+		last = r.signature
+		stats.written++
+		_, err := w.Write(r.line)
+		return err
+	})
+	return stats, err
+}
+
+// readRecords calls fn for each record in an NDJSON file, which may be gzipped
+func readRecords(path string, fn func(r mergeRecord) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	// Detect gzip by its magic number rather than trusting the extension
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	lines := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, readErr := lines.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			record, err := parseMergeRecord(trimmed)
+			if err != nil {
+				return fmt.Errorf("%s line %d: %w", path, lineNo, err)
+			}
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("%s: %w", path, readErr)
+		}
+	}
+}
+
+// parseMergeRecord reads the signature and block time of a getswaps result,
+// or of a flat record with top-level "signature" and "block_time" fields
+func parseMergeRecord(line []byte) (mergeRecord, error) {
+	type fields struct {
+		Signature string    `json:"signature"`
+		BlockTime time.Time `json:"block_time"`
+	}
+	var record struct {
+		fields
+		SwapData *fields `json:"swap_data"`
+	}
+	if err := json.Unmarshal(line, &record); err != nil {
+		return mergeRecord{}, err
+	}
+	f := record.fields
+	if f.Signature == "" && record.SwapData != nil {
+		f = *record.SwapData
+	}
+	if f.Signature == "" {
+		return mergeRecord{}, errors.New(`record has no "signature" field`)
+	}
+	// Keep the line newline-terminated so it can be written back as-is
+	return mergeRecord{blockTime: f.BlockTime, signature: f.Signature, line: append(line, '\n')}, nil
+}
+
+func writeRun(path string, run []mergeRecord) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	for _, r := range run {
+		if _, err := bw.Write(r.line); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// recordSource yields records in sorted order; next returns false once
+// the source is exhausted
+type recordSource interface {
+	next() (mergeRecord, bool, error)
+}
+
+type sliceSource struct {
+	records []mergeRecord
+}
+
+func (s *sliceSource) next() (mergeRecord, bool, error) {
+	if len(s.records) == 0 {
+		return mergeRecord{}, false, nil
+	}
+	r := s.records[0]
+	s.records = s.records[1:]
+	return r, true, nil
+}
+
+// fileSource reads back a run written by writeRun
+type fileSource struct {
+	path string
+	r    *bufio.Reader
+}
+
+func (s *fileSource) next() (mergeRecord, bool, error) {
+	line, err := s.r.ReadBytes('\n')
+	if err == io.EOF && len(line) == 0 {
+		return mergeRecord{}, false, nil
+	}
+	if err != nil && err != io.EOF {
+		return mergeRecord{}, false, fmt.Errorf("%s: %w", s.path, err)
+	}
+	r, err := parseMergeRecord(bytes.TrimSpace(line))
+	return r, err == nil, err
+}
+
+// mergeHeap holds the next record from each source, smallest first
+type mergeHeap []mergeHead
+
+type mergeHead struct {
+	record mergeRecord
+	source recordSource
+}
+
+func (h mergeHeap) Len() int           { return len(h) }
+func (h mergeHeap) Less(i, j int) bool { return compareRecords(h[i].record, h[j].record) < 0 }
+func (h mergeHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x any)        { *h = append(*h, x.(mergeHead)) }
+func (h *mergeHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// mergeSources calls emit with every record from sources in sorted order
+func mergeSources(sources []recordSource, emit func(r mergeRecord) error) error {
+	h := make(mergeHeap, 0, len(sources))
+	for _, s := range sources {
+		r, ok, err := s.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h = append(h, mergeHead{record: r, source: s})
+		}
+	}
+	heap.Init(&h)
+
+	for h.Len() > 0 {
+		head := h[0]
+		if err := emit(head.record); err != nil {
+			return err
+		}
+		r, ok, err := head.source.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h[0].record = r
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	return nil
+}
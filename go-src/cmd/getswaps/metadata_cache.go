@@ -0,0 +1,46 @@
+package main
+
+import (
+	"log"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+)
+
+// metadataCacheOpened is set once --token-metadata-cache-file has been
+// loaded, so it's only saved by commands that used it
+var metadataCacheOpened bool
+
+// openMetadataCache sizes the token metadata cache and loads
+// --token-metadata-cache-file into it. It's done with the RPC client since
+// only commands making RPC calls look mints up.
+func openMetadataCache() {
+	if metadataCacheOpened {
+		return
+	}
+	if err := tokenmetadata.SetCacheSize(global.metadataCacheSize); err != nil {
+		log.Fatalf("invalid --token-metadata-cache-size: %s", err)
+	}
+	if global.metadataCacheFile != "" {
+		if err := tokenmetadata.LoadCache(global.metadataCacheFile); err != nil {
+			// A corrupt cache only costs lookups, so start empty
+			log.Printf("Ignoring --token-metadata-cache-file: %s", err)
+		}
+	}
+	metadataCacheOpened = true
+}
+
+// closeMetadataCache saves the token metadata cache to
+// --token-metadata-cache-file and reports its hit rate
+func closeMetadataCache() {
+	if !metadataCacheOpened {
+		return
+	}
+	if global.metadataCacheFile != "" {
+		if err := tokenmetadata.SaveCache(global.metadataCacheFile); err != nil {
+			log.Printf("Error saving --token-metadata-cache-file: %s", err)
+		}
+	}
+	if hits, misses := tokenmetadata.CacheStats(); hits+misses > 0 {
+		printInfo("Token metadata cache: %d hits, %d misses (%.1f%% hit rate)\n", hits, misses, 100*float64(hits)/float64(hits+misses))
+	}
+}
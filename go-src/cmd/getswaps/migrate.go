@@ -0,0 +1,77 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage/duckdb"
+)
+
+// runMigrate applies, rolls back or lists schema migrations on a DuckDB file
+func runMigrate(args []string) {
+	fs := newFlagSet("migrate")
+	db := fs.String("db-path", duckdb.DefaultPath, "DuckDB file to migrate (created if missing)")
+	up := fs.Int("migrate-up", 0, "apply the next N pending migrations (0 = all)")
+	down := fs.Int("migrate-down", 0, "roll back the last N applied migrations")
+	status := fs.Bool("migrate-status", false, "list applied and pending migrations instead")
+	format := fs.String("output", "table", "output format for --migrate-status: json, table")
+	fs.Parse(args)
+
+	if *up < 0 || *down < 0 {
+		log.Fatal("migrate: --migrate-up and --migrate-down must be positive")
+	}
+	if *down > 0 && (*up > 0 || *status) {
+		log.Fatal("migrate: --migrate-down can't be combined with --migrate-up or --migrate-status")
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	migrator, err := duckdb.NewMigrator()
+	if err != nil {
+		log.Fatalf("migrate: %s", err)
+	}
+	conn, err := sql.Open("duckdb", *db)
+	if err != nil {
+		log.Fatalf("migrate: %s", err)
+	}
+	defer conn.Close()
+
+	switch {
+	case *status:
+		statuses, err := migrator.Status(ctx, conn)
+		if err != nil {
+			log.Fatalf("Error reading migrations: %s", err)
+		}
+		rows := make([][]string, len(statuses))
+		for i, s := range statuses {
+			state, at := "pending", ""
+			if s.Applied {
+				state, at = "applied", s.AppliedAt.Format(time.RFC3339)
+			}
+			rows[i] = []string{strconv.Itoa(s.Version), s.Name, state, at}
+		}
+		writeReport(*format, statuses, []string{"Version", "Name", "Status", "AppliedAt"}, rows, 0)
+	case *down > 0:
+		done, err := migrator.Down(ctx, conn, *down)
+		for _, m := range done {
+			fmt.Printf("Rolled back %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("migrate: %s", err)
+		}
+	default:
+		done, err := migrator.Up(ctx, conn, *up)
+		for _, m := range done {
+			fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("migrate: %s", err)
+		}
+		if len(done) == 0 {
+			fmt.Println("No pending migrations")
+		}
+	}
+}
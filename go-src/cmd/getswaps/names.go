@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/output"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/sns"
+)
+
+// walletNamer returns a display name function that shows a wallet's .sol
+// domain, falling back to the truncated pubkey if it has none or the
+// lookup fails
+func walletNamer(ctx context.Context, rpcClient *rpc.Client) func(solana.PublicKey) string {
+	resolver := sns.NewResolver()
+	return func(wallet solana.PublicKey) string {
+		name, err := resolver.Resolve(ctx, rpcClient, wallet)
+		if err != nil {
+			return output.Truncate(wallet.String())
+		}
+		return name
+	}
+}
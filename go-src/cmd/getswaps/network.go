@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/network"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/output"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/registry"
+)
+
+// genesisTimeout bounds the getGenesisHash call made at startup
+const genesisTimeout = 10 * time.Second
+
+// parseNetwork checks a --network value
+func parseNetwork(value string) error {
+	if !slices.Contains(network.Names, value) {
+		return fmt.Errorf("unknown network %q (want one of %s)", value, strings.Join(network.Names, ", "))
+	}
+	global.network = value
+	return nil
+}
+
+// useNetwork works out which cluster the endpoint serves, warns if that
+// isn't the one asked for with --network, and points the program registry
+// at it. The genesis hash is authoritative; the URL is the fallback when
+// the endpoint can't be asked.
+func useNetwork(rpcClient *rpc.Client, rpcURL string) {
+	fromURL := network.FromURL(rpcURL)
+	base, cancelBase := processContext()
+	defer cancelBase()
+	ctx, cancel := context.WithTimeout(base, genesisTimeout)
+	defer cancel()
+	detected, err := network.FromGenesis(ctx, rpcClient)
+	switch {
+	case err != nil:
+		detected = fromURL
+	// A localhost URL may well be a proxy, so only a named cluster is a mismatch
+	case fromURL != "" && fromURL != network.Localnet && fromURL != detected:
+		logInfo("Warning: SOLANA_RPC_URL looks like %s, but the endpoint is on %s", fromURL, detected)
+	}
+
+	switch {
+	case global.network == "":
+		global.network = detected
+	case detected != "" && detected != global.network:
+		logInfo("Warning: SOLANA_RPC_URL is on %s, not --network %s", detected, global.network)
+	}
+	if global.network != "" {
+		registry.SetDefaultNetwork(global.network)
+	}
+}
+
+// networkWriter stamps each result with the cluster it came from
+type networkWriter struct {
+	output.Writer
+}
+
+func (w networkWriter) Write(r *model.Result) error {
+	r.Network = global.network
+	return w.Writer.Write(r)
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/governance"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/launch"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/multisig"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/parser"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/pool"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/price/oracle"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/reports"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/sampling"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// runParse parses a single signature, or a file of them in batch mode
+func runParse(args []string) {
+	fs := newFlagSet("parse")
+	sig := fs.String("sig", "", "transaction signature to parse")
+	input := fs.String("input", "", "file of signatures to parse, one per line (- for stdin)")
+	workers := fs.Int("workers", 8, "concurrent fetches in batch mode")
+	sample := fs.Int("sample", 0, "parse a random sample of this many of the --input signatures (0 = all)")
+	accountsOnly := fs.Bool("accounts-only", false, "print the --sig transaction's account keys and their roles instead of parsing it")
+	logsOnly := fs.Bool("logs-only", false, "print the --sig transaction's program logs instead of parsing it, as text or with --output json")
+	innerOnly := fs.Bool("inner-instructions", false, "print the --sig transaction's inner instructions as JSON instead of parsing it")
+	feePayerReport := fs.Bool("fee-payer-report", false, "in batch mode, also count failed transactions and print per fee payer stats as JSON after the results")
+	var outOpts outputOptions
+	outOpts.register(fs, "")
+	fs.Parse(args)
+
+	if (*sig == "") == (*input == "") {
+		log.Fatal("parse: exactly one of --sig or --input is required")
+	}
+	if (*accountsOnly || *logsOnly || *innerOnly) && *sig == "" {
+		log.Fatal("parse: --accounts-only, --logs-only and --inner-instructions need --sig")
+	}
+	if *feePayerReport && *input == "" {
+		log.Fatal("parse: --fee-payer-report needs --input")
+	}
+	if *logsOnly && outOpts.format != "" && outOpts.format != "json" {
+		log.Fatal("parse: --logs-only prints text or --output json")
+	}
+	logsJSON := outOpts.format == "json"
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+
+	if *sig != "" {
+		if outOpts.format == "" {
+			outOpts.format = "json"
+		}
+		txSig, err := solana.SignatureFromBase58(*sig)
+		if err != nil {
+			log.Fatalf("parse: invalid --sig: %s", err)
+		}
+		if *accountsOnly {
+			printAccounts(ctx, rpcClient, txSig)
+			return
+		}
+		if *logsOnly {
+			printLogs(ctx, rpcClient, txSig, logsJSON)
+			return
+		}
+		if *innerOnly {
+			printInnerInstructions(ctx, rpcClient, txSig)
+			return
+		}
+		w := outOpts.writer(ctx, rpcClient)
+		defer w.Close()
+
+		result, err := fetchAndParse(ctx, rpcClient, txSig)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := w.Write(result); err != nil {
+			log.Fatalf("Error writing output: %s", err)
+		}
+		return
+	}
+
+	if outOpts.format == "" {
+		outOpts.format = "ndjson"
+	}
+	sigs, err := readSignatures(*input)
+	if err != nil {
+		log.Fatalf("Error reading signatures: %s", err)
+	}
+	if *sample > 0 {
+		sigs = sampling.Reservoir(sigs, *sample)
+	}
+	w := outOpts.writer(ctx, rpcClient)
+	var latencies []time.Duration
+	var reported []*model.Result
+	work := func(txSig solana.Signature) (*model.Result, error) {
+		return fetchAndParse(ctx, rpcClient, txSig)
+	}
+	if *feePayerReport {
+		// Failed transactions are kept for the report but not written
+		work = func(txSig solana.Signature) (*model.Result, error) {
+			tx, latency, err := fetchTimed(ctx, rpcClient, txSig)
+			if err != nil {
+				return nil, err
+			}
+			if tx.Meta != nil && tx.Meta.Err != nil {
+				return parser.FailedResult(tx)
+			}
+			return parseFetched(ctx, rpcClient, tx, latency)
+		}
+	}
+	processSignaturesWith(ctx, sigs, *workers, work, func(result *model.Result) {
+		if *feePayerReport {
+			reported = append(reported, result)
+			if result.SwapData.Failed {
+				return
+			}
+		}
+		latencies = append(latencies, time.Duration(result.TransactionData.FetchLatencyMs)*time.Millisecond)
+		if err := w.Write(result); err != nil {
+			log.Fatalf("Error writing output: %s", err)
+		}
+	})
+	if len(latencies) > 0 {
+		printInfo("%s\n", reports.LatencyStats(latencies))
+	}
+	// Closed before the report so it comes after the last result
+	if err := w.Close(); err != nil {
+		log.Fatalf("Error writing output: %s", err)
+	}
+	if *feePayerReport {
+		writeFeePayerReport(reports.FeePayerAnalysis(reported))
+	}
+}
+
+// writeFeePayerReport prints the fee payer stats to stdout as one JSON
+// object, {"fee_payer_report": {"<payer>": {...}, ...}}, so on NDJSON
+// output it's the last line
+func writeFeePayerReport(stats map[solana.PublicKey]*reports.FeePayerStats) {
+	if err := json.NewEncoder(os.Stdout).Encode(map[string]any{"fee_payer_report": stats}); err != nil {
+		log.Fatalf("Error writing output: %s", err)
+	}
+}
+
+// fetchAndParse fetches a transaction and parses it as a swap
+func fetchAndParse(ctx context.Context, rpcClient *rpc.Client, txSig solana.Signature) (*model.Result, error) {
+	tx, latency, err := fetchTimed(ctx, rpcClient, txSig)
+	if err != nil {
+		return nil, err
+	}
+	return parseFetched(ctx, rpcClient, tx, latency)
+}
+
+// fetchTimed fetches a transaction, warning if it took over --slow-threshold
+func fetchTimed(ctx context.Context, rpcClient *rpc.Client, txSig solana.Signature) (*rpc.GetTransactionResult, time.Duration, error) {
+	start := time.Now()
+	tx, err := fetchTransaction(ctx, rpcClient, txSig)
+	latency := time.Since(start)
+	if global.slowThreshold > 0 && latency > global.slowThreshold {
+		slog.Warn("slow getTransaction", "signature", txSig, "latency", latency)
+	}
+	if err != nil {
+		return nil, latency, fmt.Errorf("Error fetching transaction: %s", err)
+	}
+	return tx, latency, nil
+}
+
+// parseFetched parses a fetched transaction as a swap and fills in the
+// details that take more RPC calls
+func parseFetched(ctx context.Context, rpcClient *rpc.Client, tx *rpc.GetTransactionResult, latency time.Duration) (*model.Result, error) {
+	if len(global.skipPrograms) > 0 {
+		if decoded, err := txutil.Decode(tx); err == nil && txutil.InvokesOnly(decoded, global.skipPrograms...) {
+			return nil, errFilteredOut
+		}
+	}
+	result, err := parser.ParseSwap(tx)
+	if err != nil {
+		return nil, err
+	}
+	// Filter before the lookups below, which cost more RPC calls
+	swaps := result.AllSwaps()
+	if !slices.ContainsFunc(swaps, global.tokenFilter.allows) {
+		return nil, errFilteredOut
+	}
+	result.TransactionData.FetchLatencyMs = latency.Milliseconds()
+	for _, swap := range swaps {
+		fillDecimals(ctx, rpcClient, tx, swap)
+	}
+	if global.accountChanges {
+		if result.TransactionData.WritableAccountChanges, err = txutil.AccountChanges(tx); err != nil {
+			log.Printf("%s: %s", result.TransactionData.Signature, err)
+		}
+	}
+	// Vote weight lives in the vote record account, not the transaction
+	for _, vote := range result.TransactionData.GovernanceEvents {
+		if err := governance.FetchVoteWeight(ctx, rpcClient, vote); err != nil {
+			log.Printf("%s: %s", result.TransactionData.Signature, err)
+		}
+	}
+	// As is the Squads transaction index, in the vault transaction account
+	if exec, err := multisig.FindExecution(tx); err == nil {
+		if err := multisig.FetchTransactionIndex(ctx, rpcClient, exec); err != nil {
+			log.Printf("%s: %s", result.TransactionData.Signature, err)
+		}
+		result.TransactionData.SquadsTransactionIndex = exec.TransactionIndex
+	}
+	for _, swap := range swaps {
+		if global.fetchPoolState && swap.PoolAddress != nil {
+			fillPoolLiquidity(ctx, rpcClient, tx, swap)
+		}
+		if global.detectLaunches && swap.PoolAddress != nil {
+			first, err := launch.IsFirstSwap(ctx, rpcClient, *swap.PoolAddress, tx.Slot)
+			if err != nil {
+				log.Printf("%s: %s", result.TransactionData.Signature, err)
+			}
+			swap.IsFirstPoolSwap = first
+		}
+		if global.mintAge {
+			fillMintAges(ctx, rpcClient, swap)
+		}
+		if !global.noOracle {
+			fillOraclePrices(ctx, rpcClient, swap)
+		}
+	}
+	return result, nil
+}
+
+// fillDecimals reads decimals from the mint account for any leg that moved
+// tokens but was parsed without them, falling back to the transaction's
+// token balances if the fetch fails
+func fillDecimals(ctx context.Context, rpcClient *rpc.Client, tx *rpc.GetTransactionResult, swap *model.SwapData) {
+	fill := func(mint solana.PublicKey, amount uint64, decimals *uint8, ui *float64) {
+		if *decimals != 0 || amount == 0 {
+			return
+		}
+		d, err := tokenmetadata.FetchDecimals(ctx, rpcClient, mint)
+		if err != nil {
+			var ok bool
+			if d, ok = tokenmetadata.BalanceDecimals(tx.Meta, mint); !ok {
+				log.Printf("%s: %s", swap.Signature, err)
+				return
+			}
+		}
+		*decimals = d
+		*ui = model.UIAmount(amount, d)
+	}
+	fill(swap.TokenInMint, swap.TokenInAmount, &swap.TokenInDecimals, &swap.AmountInUI)
+	fill(swap.TokenOutMint, swap.TokenOutAmount, &swap.TokenOutDecimals, &swap.AmountOutUI)
+}
+
+// fillMintAges sets swap.TokenInMintCreatedAt and TokenOutMintCreatedAt,
+// leaving established mints unset without a warning
+func fillMintAges(ctx context.Context, rpcClient *rpc.Client, swap *model.SwapData) {
+	fill := func(mint solana.PublicKey, createdAt **time.Time) {
+		if mint.IsZero() {
+			return
+		}
+		created, err := tokenmetadata.FetchMintAge(ctx, rpcClient, mint)
+		if err != nil {
+			if !errors.Is(err, tokenmetadata.ErrEstablishedMint) {
+				log.Printf("%s: %s", swap.Signature, err)
+			}
+			return
+		}
+		*createdAt = &created
+	}
+	fill(swap.TokenInMint, &swap.TokenInMintCreatedAt)
+	fill(swap.TokenOutMint, &swap.TokenOutMintCreatedAt)
+}
+
+// oraclePrices caches Pyth feed reads across a run
+var oraclePrices = oracle.NewFetcher()
+
+// fillOraclePrices sets swap.OraclePriceInAtSwap and OraclePriceOutAtSwap.
+// Feeds only hold their latest price, so swaps older than the staleness
+// window are skipped without a fetch.
+func fillOraclePrices(ctx context.Context, rpcClient *rpc.Client, swap *model.SwapData) {
+	if swap.Failed || time.Since(swap.BlockTime) > oracle.MaxStaleness {
+		return
+	}
+	prices, err := oraclePrices.Prices(ctx, rpcClient, swap.TokenInMint, swap.TokenOutMint)
+	if err != nil {
+		log.Printf("%s: %s", swap.Signature, err)
+		return
+	}
+	if p, ok := prices[swap.TokenInMint]; ok {
+		swap.OraclePriceInAtSwap, _ = p.At(swap.BlockTime)
+	}
+	if p, ok := prices[swap.TokenOutMint]; ok {
+		swap.OraclePriceOutAtSwap, _ = p.At(swap.BlockTime)
+	}
+}
+
+// poolStates caches pool accounts for --fetch-pool-state across a run
+var poolStates = pool.NewCache()
+
+// fillPoolLiquidity sets swap.PoolLiquidityAtSwapUSD and MarketImpactBps
+// from the pool's state
+func fillPoolLiquidity(ctx context.Context, rpcClient *rpc.Client, tx *rpc.GetTransactionResult, swap *model.SwapData) {
+	state, err := poolStates.State(ctx, rpcClient, *swap.PoolAddress)
+	if err != nil {
+		log.Printf("%s: %s", swap.Signature, err)
+		return
+	}
+	reserveA, reserveB := state.AtSwap(tx)
+	if liquidity, ok := pool.LiquidityUSD(state, swap, reserveA, reserveB); ok {
+		swap.PoolLiquidityAtSwapUSD = liquidity
+	}
+	if impact, ok := pool.MarketImpactBps(state, swap, reserveA, reserveB); ok {
+		swap.MarketImpactBps = impact
+	}
+}
+
+// processSignatures fetches and parses signatures on a pool of workers,
+// handing each result to emit as soon as it completes. emit is only ever
+// called from the calling goroutine. Failures are logged and skipped. Once
+// ctx is cancelled no new signatures are started.
+func processSignatures(ctx context.Context, rpcClient *rpc.Client, sigs []solana.Signature, workers int, emit func(result *model.Result)) {
+	work := func(txSig solana.Signature) (*model.Result, error) {
+		return fetchAndParse(ctx, rpcClient, txSig)
+	}
+	processSignaturesWith(ctx, sigs, workers, work, emit)
+}
+
+// processSignaturesWith is processSignatures with a custom per-signature step
+func processSignaturesWith(ctx context.Context, sigs []solana.Signature, workers int, work func(txSig solana.Signature) (*model.Result, error), emit func(result *model.Result)) {
+	jobs := make(chan solana.Signature)
+	results := make(chan *model.Result)
+
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for txSig := range jobs {
+				result, err := work(txSig)
+				if err != nil {
+					// Failures caused by cancellation aren't worth reporting
+					if ctx.Err() == nil && !errors.Is(err, errFilteredOut) {
+						log.Printf("Skipping %s: %s", txSig, err)
+					}
+					continue
+				}
+				results <- result
+			}
+		}()
+	}
+
+	go func() {
+	feed:
+		for _, txSig := range sigs {
+			select {
+			case jobs <- txSig:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	for result := range results {
+		emit(result)
+	}
+}
+
+// readSignatures reads one signature per line, skipping blank lines and # comments
+func readSignatures(path string) ([]solana.Signature, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var sigs []solana.Signature
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		txSig, err := solana.SignatureFromBase58(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		sigs = append(sigs, txSig)
+	}
+	return sigs, scanner.Err()
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/instructions"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// The parse modes below print one part of a raw transaction for debugging
+// parsers, without parsing the swap
+
+// printAccounts prints a transaction's account keys, for finding which
+// index an account sits at when writing a parser
+func printAccounts(ctx context.Context, rpcClient *rpc.Client, txSig solana.Signature) {
+	tx, err := fetchTransaction(ctx, rpcClient, txSig)
+	if err != nil {
+		log.Fatalf("Error fetching transaction: %s", err)
+	}
+	accounts, err := txutil.Accounts(tx)
+	if err != nil {
+		log.Fatalf("Error reading account keys: %s", err)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(accounts); err != nil {
+		log.Fatalf("Error writing output: %s", err)
+	}
+}
+
+// printLogs prints a transaction's program logs, each line prefixed with
+// the program that wrote it and indented by invocation depth
+func printLogs(ctx context.Context, rpcClient *rpc.Client, txSig solana.Signature, asJSON bool) {
+	tx, err := fetchTransaction(ctx, rpcClient, txSig)
+	if err != nil {
+		log.Fatalf("Error fetching transaction: %s", err)
+	}
+	if tx.Meta == nil {
+		log.Fatalf("%s has no transaction meta", txSig)
+	}
+	lines := instructions.AttributeLogs(tx.Meta.LogMessages)
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(lines); err != nil {
+			log.Fatalf("Error writing output: %s", err)
+		}
+		return
+	}
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	for _, l := range lines {
+		if l.Depth == 0 {
+			fmt.Fprintln(out, l.Message)
+			continue
+		}
+		fmt.Fprintf(out, "%s%s: %s\n", strings.Repeat("  ", l.Depth-1), l.ProgramID, l.Message)
+	}
+}
+
+// innerInstruction is an inner instruction as --inner-instructions prints it
+type innerInstruction struct {
+	// Top-level instruction that invoked it, and its position among that
+	// instruction's inner instructions
+	Index      int                `json:"index"`
+	InnerIndex int                `json:"innerIndex"`
+	ProgramID  solana.PublicKey   `json:"programId"`
+	Accounts   []solana.PublicKey `json:"accounts"`
+	Data       instructionData    `json:"data"`
+}
+
+type instructionData struct {
+	Base58 string `json:"base58"`
+	Hex    string `json:"hex"`
+}
+
+// printInnerInstructions prints a transaction's inner instructions with
+// their program and accounts resolved, lookup table keys included
+func printInnerInstructions(ctx context.Context, rpcClient *rpc.Client, txSig solana.Signature) {
+	tx, err := fetchTransaction(ctx, rpcClient, txSig)
+	if err != nil {
+		log.Fatalf("Error fetching transaction: %s", err)
+	}
+	all, err := txutil.Instructions(tx)
+	if err != nil {
+		log.Fatalf("Error resolving instructions: %s", err)
+	}
+	inner := []innerInstruction{}
+	for _, ix := range all {
+		if ix.InnerIndex < 0 {
+			continue
+		}
+		inner = append(inner, innerInstruction{
+			Index:      ix.Index,
+			InnerIndex: ix.InnerIndex,
+			ProgramID:  ix.ProgramID,
+			Accounts:   ix.Accounts,
+			Data:       instructionData{Base58: solana.Base58(ix.Data).String(), Hex: hex.EncodeToString(ix.Data)},
+		})
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(inner); err != nil {
+		log.Fatalf("Error writing output: %s", err)
+	}
+}
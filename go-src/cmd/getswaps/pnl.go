@@ -0,0 +1,110 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+
+	solana "github.com/gagliardetto/solana-go"
+	"golang.org/x/time/rate"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/pnl"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/portfolio"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/price"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/price/pyth"
+)
+
+// runPnL scores each wallet's closed trades in a file of getswaps results
+func runPnL(args []string) {
+	fs := newFlagSet("pnl")
+	input := fs.String("input", "-", "json or NDJSON results to read (- for stdin)")
+	costBasis := fs.String("cost-basis", "fifo", "lot matching method: fifo, lifo, hifo")
+	format := fs.String("output", "table", "output format: json, table")
+	historicalSOL := fs.Bool("historical-sol-price", false, "value swaps against SOL at the Pyth SOL/USD price at their slot rather than the file's median (RPC lookups, one per minute traded)")
+	pythAccount := fs.String("pyth-account", pyth.SOLUSD.String(), "Pyth SOL/USD price account for --historical-sol-price")
+	priceRate := fs.Float64("price-rate", 0, "most --historical-sol-price batches to look up per second (0 for no limit)")
+	priceBatch := fs.Int("price-batch-size", price.DefaultBatchSize, "most distinct tokens in one --historical-sol-price batch")
+	fs.Parse(args)
+
+	method, err := portfolio.ParseMethod(*costBasis)
+	if err != nil {
+		log.Fatalf("pnl: invalid --cost-basis: %s", err)
+	}
+
+	in := os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			log.Fatalf("pnl: %s", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var swaps []*model.SwapData
+	dec := json.NewDecoder(in)
+	for n := 1; ; n++ {
+		var result model.Result
+		err := dec.Decode(&result)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Fatalf("pnl: record %d: %s", n, err)
+		}
+		swaps = append(swaps, result.AllSwaps()...)
+	}
+	slices.SortStableFunc(swaps, func(a, b *model.SwapData) int {
+		return cmp.Or(
+			cmp.Compare(a.Slot, b.Slot),
+			a.BlockTime.Compare(b.BlockTime),
+		)
+	})
+	ctx, cancel := commandContext()
+	defer cancel()
+	if err := (price.StablecoinEnricher{}).Enrich(ctx, swaps); err != nil {
+		log.Fatalf("Error pricing swaps: %s", err)
+	}
+	if *historicalSOL {
+		account, err := solana.PublicKeyFromBase58(*pythAccount)
+		if err != nil {
+			log.Fatalf("pnl: invalid --pyth-account: %s", err)
+		}
+		limit := rate.Inf
+		if *priceRate > 0 {
+			limit = rate.Limit(*priceRate)
+		}
+		enricher := price.NewRateLimitedEnricher(pyth.HistoricalEnricher{RPC: newRPCClient(), Account: account}, limit)
+		enricher.BatchSize = *priceBatch
+		enricher.Log = logInfo
+		if err := enricher.Enrich(ctx, swaps); err != nil {
+			log.Fatalf("Error pricing swaps: %s", err)
+		}
+	}
+
+	wallets := pnl.ByWallet(swaps, method)
+	rows := make([][]string, len(wallets))
+	for i, w := range wallets {
+		profitFactor := "-"
+		if w.ProfitFactor != nil {
+			profitFactor = fmt.Sprintf("%.2f", *w.ProfitFactor)
+		}
+		rows[i] = []string{
+			w.Wallet.String(),
+			strconv.Itoa(w.TotalTrades),
+			fmt.Sprintf("%.1f%%", 100*w.WinRate),
+			fmt.Sprintf("%.2f", w.GrossProfit),
+			fmt.Sprintf("%.2f", w.GrossLoss),
+			fmt.Sprintf("%.2f", w.NetPnL),
+			profitFactor,
+		}
+	}
+	writeReport(*format, wallets, []string{"Wallet", "Trades", "WinRate", "GrossProfit", "GrossLoss", "NetPnL", "ProfitFactor"}, rows, 1, 2, 3, 4, 5, 6)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"slices"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/portfolio"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/price"
+)
+
+// runPortfolio builds a cost-basis ledger from a wallet's swap history
+func runPortfolio(args []string) {
+	fs := newFlagSet("portfolio")
+	wallet := fs.String("wallet", "", "wallet address (required)")
+	costBasis := fs.String("cost-basis", "fifo", "lot matching method: fifo, lifo, hifo")
+	limit := fs.Int("limit", 1000, "number of recent signatures to scan (0 = whole history)")
+	workers := fs.Int("workers", 8, "concurrent transaction fetches")
+	fs.Parse(args)
+
+	if *wallet == "" {
+		log.Fatal("portfolio: --wallet is required")
+	}
+	walletKey, err := solana.PublicKeyFromBase58(*wallet)
+	if err != nil {
+		log.Fatalf("portfolio: invalid --wallet: %s", err)
+	}
+	method, err := portfolio.ParseMethod(*costBasis)
+	if err != nil {
+		log.Fatalf("portfolio: invalid --cost-basis: %s", err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+
+	swaps := walletSwaps(ctx, rpcClient, walletKey, *limit, *workers)
+	ledger := portfolio.NewLedger(method)
+	for _, s := range swaps {
+		ledger.Add(s)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(ledger.Report(walletKey, portfolio.LatestPrices(swaps))); err != nil {
+		log.Fatalf("Error writing output: %s", err)
+	}
+}
+
+// walletSwaps returns the priced swaps a wallet signed among its last limit
+// transactions, oldest first
+func walletSwaps(ctx context.Context, rpcClient *rpc.Client, wallet solana.PublicKey, limit, workers int) []*model.SwapData {
+	var sigs []solana.Signature
+	err := signatureHistory(ctx, rpcClient, wallet, func(sig *rpc.TransactionSignature) bool {
+		if limit > 0 && len(sigs) >= limit {
+			return false
+		}
+		if sig.Err == nil {
+			sigs = append(sigs, sig.Signature)
+		}
+		return true
+	})
+	if err != nil {
+		log.Fatalf("Error scanning %s: %s", wallet, err)
+	}
+
+	var swaps []*model.SwapData
+	processSignatures(ctx, rpcClient, sigs, workers, func(result *model.Result) {
+		// The history also holds swaps other wallets made against this one
+		for _, swap := range result.AllSwaps() {
+			if swap.Signer.Equals(wallet) {
+				swaps = append(swaps, swap)
+			}
+		}
+	})
+	slices.SortFunc(swaps, func(a, b *model.SwapData) int {
+		return cmp.Or(
+			cmp.Compare(a.Slot, b.Slot),
+			a.BlockTime.Compare(b.BlockTime),
+		)
+	})
+
+	if err := (price.StablecoinEnricher{}).Enrich(ctx, swaps); err != nil {
+		log.Fatalf("Error pricing swaps: %s", err)
+	}
+	return swaps
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/price/pyth"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/slots"
+)
+
+// runPriceHistory prints a Pyth price account's value at evenly spaced
+// slots, read back from the transactions that updated it
+func runPriceHistory(args []string) {
+	fs := newFlagSet("price-history")
+	account := fs.String("account", pyth.SOLUSD.String(), "Pyth price account: a legacy one, or a push oracle feed")
+	hours := fs.Float64("hours", 24, "how far back to go")
+	fromSlot := fs.Uint64("from-slot", 0, "first slot, instead of --hours")
+	toSlot := fs.Uint64("to-slot", 0, "last slot, with --from-slot (default: the current slot)")
+	points := fs.Int("points", 24, "prices to read across the range")
+	format := fs.String("output", "table", "output format: json, table")
+	fs.Parse(args)
+
+	priceAccount, err := solana.PublicKeyFromBase58(*account)
+	if err != nil {
+		log.Fatalf("price-history: invalid --account: %s", err)
+	}
+	if *points < 1 {
+		log.Fatal("price-history: --points must be at least 1")
+	}
+	if *toSlot != 0 && *fromSlot == 0 {
+		log.Fatal("price-history: --to-slot needs --from-slot")
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+
+	start, end := *fromSlot, *toSlot
+	if end == 0 {
+		if end, err = rpcClient.GetSlot(ctx, rpc.CommitmentConfirmed); err != nil {
+			log.Fatalf("Error getting current slot: %s", err)
+		}
+	}
+	if start == 0 {
+		since := time.Now().Add(-time.Duration(*hours * float64(time.Hour)))
+		if start, err = slots.FindSlotForTime(ctx, rpcClient, since); err != nil {
+			log.Fatalf("Error finding start slot: %s", err)
+		}
+	}
+	if start > end {
+		log.Fatalf("price-history: slot range %d-%d is empty", start, end)
+	}
+
+	var prices []*pyth.PythPrice
+	var rows [][]string
+	for i := range *points {
+		if ctx.Err() != nil {
+			break
+		}
+		slot := end
+		if *points > 1 {
+			slot = start + (end-start)*uint64(i)/uint64(*points-1)
+		}
+		p, err := pyth.FetchHistoricalPrice(ctx, rpcClient, priceAccount, slot)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("slot %d: %s", slot, err)
+			}
+			continue
+		}
+		prices = append(prices, p)
+		rows = append(rows, []string{
+			strconv.FormatUint(p.Slot, 10),
+			p.PublishTime.Format(time.RFC3339),
+			fmt.Sprintf("%.6g", p.Price),
+			fmt.Sprintf("%.2g", p.Conf),
+		})
+	}
+	writeReport(*format, prices, []string{"Slot", "PublishTime", "Price", "Conf"}, rows, 0, 2, 3)
+}
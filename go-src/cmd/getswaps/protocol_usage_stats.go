@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/parser"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/price"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/reports"
+)
+
+// runProtocolUsageStats scans recent blocks and ranks the programs their
+// transactions invoked, with the swaps and volume of the DEXes among them
+func runProtocolUsageStats(args []string) {
+	fs := newFlagSet("protocol-usage-stats")
+	blocks := fs.Uint64("blocks", 100, "how many of the most recent slots to scan")
+	top := fs.Int("top", 20, "number of programs to report (0 = all)")
+	format := fs.String("output", "table", "output format: json, table")
+	fs.Parse(args)
+
+	if *blocks == 0 {
+		log.Fatal("protocol-usage-stats: --blocks must be at least 1")
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+
+	produced, err := recentBlocks(ctx, rpcClient, *blocks)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	usage := reports.NewProtocolUsage()
+	scanned := 0
+	for _, slot := range produced {
+		if ctx.Err() != nil {
+			break
+		}
+		var swaps []*model.SwapData
+		err := eachBlockTransaction(ctx, rpcClient, slot, func(_ int, tx *rpc.GetTransactionResult) {
+			if err := usage.AddTransaction(tx); err != nil {
+				return
+			}
+			// Most of a block isn't swaps, so parse failures aren't worth logging
+			result, err := parser.ParseSwap(tx)
+			if err != nil {
+				return
+			}
+			swaps = append(swaps, result.AllSwaps()...)
+		})
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+		// Price against the whole block, as find-large-swaps does
+		if err := (price.StablecoinEnricher{}).Enrich(ctx, swaps); err != nil {
+			log.Fatalf("Error pricing swaps: %s", err)
+		}
+		usage.AddSwaps(swaps)
+		scanned++
+	}
+	printInfo("Scanned %d of %d blocks\n", scanned, len(produced))
+
+	rows := usage.Rows(*top)
+	cells := make([][]string, len(rows))
+	for i, r := range rows {
+		name := r.ProgramName
+		if name == "" {
+			name = r.ProgramID.String()
+		}
+		cells[i] = []string{
+			strconv.Itoa(r.Rank),
+			name,
+			strconv.Itoa(r.InvocationCount),
+			strconv.Itoa(r.UniqueWallets),
+			strconv.Itoa(r.SwapCount),
+			fmt.Sprintf("%.2f", r.VolumeUSD),
+		}
+	}
+	writeReport(*format, rows, []string{"Rank", "ProgramName", "InvocationCount", "UniqueWallets", "SwapCount", "VolumeUSD"}, cells, 0, 2, 3, 4, 5)
+}
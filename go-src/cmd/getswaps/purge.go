@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// purgeWarnAge is how recent a --before cutoff can be without a warning
+const purgeWarnAge = 30 * 24 * time.Hour
+
+// runPurge deletes swaps older than a cutoff from a database written by
+// one of the storage output modes
+func runPurge(args []string) {
+	fs := newFlagSet("purge")
+	before := fs.String("before", "", "delete swaps with a block time before this date, e.g. 2024-01-01 or an RFC 3339 time (required)")
+	backend := fs.String("backend", "duckdb", "database to purge: "+strings.Join(storageFormats, ", "))
+	dryRun := fs.Bool("dry-run", false, "print how many swaps would be deleted without deleting them")
+	compact := fs.Bool("compact-after-purge", false, "compact the DuckDB file afterwards to give back the deleted rows' space (as compact-db)")
+	var opts storageOptions
+	opts.register(fs)
+	fs.Parse(args)
+
+	if *before == "" {
+		log.Fatal("purge: --before is required")
+	}
+	cutoff, err := time.Parse(time.DateOnly, *before)
+	if err != nil {
+		if cutoff, err = time.Parse(time.RFC3339, *before); err != nil {
+			log.Fatalf("purge: invalid --before %q: want YYYY-MM-DD or RFC 3339", *before)
+		}
+	}
+	if *compact && *backend != "duckdb" {
+		log.Fatal("purge: --compact-after-purge only applies to --backend duckdb")
+	}
+	if age := time.Since(cutoff); age < purgeWarnAge {
+		logInfo("Warning: --before %s is less than 30 days ago", *before)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	purger, err := opts.purger(ctx, *backend)
+	if err != nil {
+		log.Fatalf("purge: %s", err)
+	}
+	defer purger.Close()
+
+	if *dryRun {
+		n, err := purger.CountBefore(ctx, cutoff)
+		if err != nil {
+			log.Fatalf("Error counting swaps: %s", err)
+		}
+		fmt.Printf("%d swaps before %s would be deleted\n", n, cutoff.Format(time.RFC3339))
+		return
+	}
+	n, err := purger.Purge(ctx, cutoff)
+	if err != nil {
+		log.Fatalf("Error purging swaps: %s", err)
+	}
+	fmt.Printf("Deleted %d swaps before %s\n", n, cutoff.Format(time.RFC3339))
+
+	if *compact {
+		// The file can only be rewritten once nothing holds it open
+		purger.Close()
+		if err := compactDB(ctx, opts.duckdbPath); err != nil {
+			log.Fatalf("Error compacting %s: %s", opts.duckdbPath, err)
+		}
+	}
+}
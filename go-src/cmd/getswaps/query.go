@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage/duckdb"
+)
+
+// runQuery runs SQL against a DuckDB file written by --output duckdb
+func runQuery(args []string) {
+	fs := newFlagSet("query")
+	db := fs.String("db-path", duckdb.DefaultPath, "DuckDB file to query")
+	query := fs.String("sql", "", "SQL to run (required)")
+	fs.Parse(args)
+
+	if *query == "" {
+		log.Fatal("query: --sql is required")
+	}
+	if _, err := os.Stat(*db); err != nil {
+		log.Fatalf("query: %s", err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rows, err := duckdb.Query(ctx, *db, *query)
+	if err != nil {
+		log.Fatalf("Error running query: %s", err)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rows); err != nil {
+		log.Fatal(err)
+	}
+}
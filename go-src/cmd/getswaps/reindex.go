@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/parser"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage/duckdb"
+)
+
+// runReindex re-parses the transactions stored by --store-raw with
+// the current parser and rewrites their swaps
+func runReindex(args []string) {
+	fs := newFlagSet("reindex")
+	db := fs.String("db-path", duckdb.DefaultPath, "DuckDB file written with --output duckdb --store-raw")
+	table := fs.String("table", storage.DefaultTable, "swaps table to update")
+	fs.Parse(args)
+
+	if _, err := os.Stat(*db); err != nil {
+		log.Fatalf("reindex: %s", err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	reindexer, err := duckdb.NewReindexer(duckdb.Config{Path: *db, Table: *table})
+	if err != nil {
+		log.Fatalf("reindex: %s", err)
+	}
+	defer reindexer.Close()
+
+	// Transactions that no longer parse keep their old rows
+	var swaps []*model.SwapData
+	failed := 0
+	err = reindexer.RawTransactions(ctx, func(signature string, tx *rpc.GetTransactionResult, err error) {
+		var result *model.Result
+		if err == nil {
+			result, err = parser.ParseSwap(tx)
+		}
+		if err != nil {
+			log.Printf("%s: %s", signature, err)
+			failed++
+			return
+		}
+		swaps = append(swaps, result.AllSwaps()...)
+	})
+	if err != nil {
+		log.Fatalf("Error reading %s: %s", storage.RawTable, err)
+	}
+	if err := reindexer.Replace(ctx, swaps); err != nil {
+		log.Fatalf("Error updating %s: %s", *table, err)
+	}
+	fmt.Printf("Reindexed %d swaps with solanaswap-go %s (%d failed to parse)\n", len(swaps), parserVersion(), failed)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/output"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/price"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/registry"
+)
+
+// reportOptions are the flags shared by the commands that rank recent swaps
+// on a program
+type reportOptions struct {
+	program      string
+	hours        float64
+	top          int
+	limit        int
+	workers      int
+	format       string
+	resolveNames bool
+}
+
+func (o *reportOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.program, "program", "", "program name from the registry (e.g. raydium) or program address (required)")
+	fs.Float64Var(&o.hours, "hours", 1, "how far back to scan")
+	fs.IntVar(&o.top, "top", 20, "number of rows to report (0 = all)")
+	fs.IntVar(&o.limit, "limit", 2000, "stop after this many signatures per program (0 = no limit)")
+	fs.IntVar(&o.workers, "workers", 8, "concurrent transaction fetches")
+	fs.StringVar(&o.format, "output", "table", "output format: json, table")
+	fs.BoolVar(&o.resolveNames, "resolve-names", false, "show wallets by their .sol domain")
+}
+
+// collect scans the program's recent history and returns its price-enriched swaps
+func (o *reportOptions) collect(ctx context.Context, rpcClient *rpc.Client) []*model.SwapData {
+	if o.program == "" {
+		log.Fatal("--program is required")
+	}
+	programs := registry.Default().Find(o.program)
+	if len(programs) == 0 {
+		log.Fatalf("No program in the registry matches %q", o.program)
+	}
+	since := time.Now().Add(-time.Duration(o.hours * float64(time.Hour)))
+
+	swaps := collectProgramSwaps(ctx, rpcClient, programs, since, o.limit, o.workers)
+	if err := (price.StablecoinEnricher{}).Enrich(ctx, swaps); err != nil {
+		log.Fatalf("Error pricing swaps: %s", err)
+	}
+	return swaps
+}
+
+// write prints a report in the configured format
+func (o *reportOptions) write(report any, header []string, rows [][]string, numeric ...int) {
+	writeReport(o.format, report, header, rows, numeric...)
+}
+
+// writeReport prints a report as JSON, or as a table built from header and rows
+func writeReport(format string, report any, header []string, rows [][]string, numeric ...int) {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			log.Fatalf("Error writing output: %s", err)
+		}
+	case "table":
+		if err := output.WriteTable(os.Stdout, header, rows, numeric...); err != nil {
+			log.Fatalf("Error writing output: %s", err)
+		}
+	default:
+		log.Fatalf("unknown output format %q (want json or table)", format)
+	}
+}
+
+// collectProgramSwaps parses every successful transaction on the programs
+// since the given time, up to limit signatures per program. A transaction
+// touching more than one of the programs is only parsed once.
+func collectProgramSwaps(ctx context.Context, rpcClient *rpc.Client, programs []solana.PublicKey, since time.Time, limit, workers int) []*model.SwapData {
+	seen := make(map[solana.Signature]struct{})
+	var sigs []solana.Signature
+
+	for _, program := range programs {
+		count := 0
+		err := signatureHistory(ctx, rpcClient, program, func(sig *rpc.TransactionSignature) bool {
+			if (sig.BlockTime != nil && sig.BlockTime.Time().Before(since)) || (limit > 0 && count >= limit) {
+				return false
+			}
+			count++
+			if _, dup := seen[sig.Signature]; sig.Err == nil && !dup {
+				seen[sig.Signature] = struct{}{}
+				sigs = append(sigs, sig.Signature)
+			}
+			return true
+		})
+		if err != nil {
+			log.Fatalf("Error scanning %s: %s", program, err)
+		}
+	}
+
+	var swaps []*model.SwapData
+	processSignatures(ctx, rpcClient, sigs, workers, func(result *model.Result) {
+		swaps = append(swaps, result.AllSwaps()...)
+	})
+	return swaps
+}
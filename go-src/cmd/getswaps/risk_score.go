@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/price"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/risk"
+)
+
+// runRiskScore scores one swap for suspicious patterns
+func runRiskScore(args []string) {
+	fs := newFlagSet("risk-score")
+	sigStr := fs.String("sig", "", "transaction signature to score (required)")
+	workers := fs.Int("workers", 8, "concurrent transaction fetches when loading the swap's block")
+	fs.Parse(args)
+
+	if *sigStr == "" {
+		log.Fatal("risk-score: --sig is required")
+	}
+	txSig, err := solana.SignatureFromBase58(*sigStr)
+	if err != nil {
+		log.Fatalf("risk-score: invalid --sig: %s", err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+
+	result, err := fetchAndParse(ctx, rpcClient, txSig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// A transaction with several swaps gets a report for each
+	scorer := risk.NewScorer(rpcClient)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	for _, swap := range result.AllSwaps() {
+		report, err := scoreSwap(ctx, rpcClient, scorer, swap, *workers)
+		if err != nil {
+			log.Fatalf("Error scoring %s: %s", txSig, err)
+		}
+		if err := enc.Encode(report); err != nil {
+			log.Fatalf("Error writing output: %s", err)
+		}
+	}
+}
+
+// scoreSwap loads the swaps sharing s's block and pair, prices them, and scores s
+func scoreSwap(ctx context.Context, rpcClient *rpc.Client, scorer *risk.Scorer, s *model.SwapData, workers int) (*risk.RiskReport, error) {
+	block, victim, err := blockSwaps(ctx, rpcClient, s, workers)
+	if err != nil {
+		return nil, err
+	}
+	if err := (price.StablecoinEnricher{}).Enrich(ctx, block); err != nil {
+		return nil, fmt.Errorf("pricing swaps: %w", err)
+	}
+	return scorer.Score(ctx, block, victim)
+}
+
+// blockSwaps returns the swaps in s's slot that touch either of its tokens,
+// in block order, and the index of s among them. Only transactions whose
+// token balances include one of the mints are fetched and parsed.
+func blockSwaps(ctx context.Context, rpcClient *rpc.Client, s *model.SwapData, workers int) ([]*model.SwapData, int, error) {
+	var maxTxVersion uint64 = 0
+	rewards := false
+	block, err := rpcClient.GetBlockWithOpts(ctx, s.Slot, &rpc.GetBlockOpts{
+		Encoding:                       solana.EncodingBase64,
+		TransactionDetails:             rpc.TransactionDetailsFull,
+		Rewards:                        &rewards,
+		Commitment:                     rpc.CommitmentConfirmed,
+		MaxSupportedTransactionVersion: &maxTxVersion,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("fetching block %d: %w", s.Slot, err)
+	}
+
+	// wSOL balances are in nearly every swap, so match on the other mint when there is one
+	mints := make(map[solana.PublicKey]struct{})
+	for _, mint := range []solana.PublicKey{s.TokenInMint, s.TokenOutMint} {
+		if !mint.Equals(solana.SolMint) {
+			mints[mint] = struct{}{}
+		}
+	}
+	if len(mints) == 0 {
+		mints[solana.SolMint] = struct{}{}
+	}
+
+	var sigs []solana.Signature
+	for _, tx := range block.Transactions {
+		if tx.Meta == nil || tx.Meta.Err != nil || !touchesMints(tx.Meta, mints) {
+			continue
+		}
+		decoded, err := tx.GetTransaction()
+		if err != nil || len(decoded.Signatures) == 0 {
+			continue
+		}
+		sigs = append(sigs, decoded.Signatures[0])
+	}
+
+	parsed := make(map[solana.Signature][]*model.SwapData)
+	processSignatures(ctx, rpcClient, sigs, workers, func(result *model.Result) {
+		parsed[result.SwapData.Signature] = result.AllSwaps()
+	})
+
+	var swaps []*model.SwapData
+	victim := -1
+	for _, txSig := range sigs {
+		for _, swap := range parsed[txSig] {
+			if txSig == s.Signature && swap.HopIndex == s.HopIndex {
+				victim = len(swaps)
+				swap = s
+			}
+			swaps = append(swaps, swap)
+		}
+	}
+	if victim < 0 {
+		// s couldn't be matched in its own block; score it on its own
+		victim = len(swaps)
+		swaps = append(swaps, s)
+	}
+	return swaps, victim, nil
+}
+
+func touchesMints(meta *rpc.TransactionMeta, mints map[solana.PublicKey]struct{}) bool {
+	for _, balances := range [][]rpc.TokenBalance{meta.PreTokenBalances, meta.PostTokenBalances} {
+		for _, b := range balances {
+			if _, ok := mints[b.Mint]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
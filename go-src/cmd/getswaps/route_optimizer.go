@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/registry"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/routeopt"
+)
+
+// runRouteOptimizer suggests the DEX that recently gave the best output for
+// a swap, from its fills rather than live quotes
+func runRouteOptimizer(args []string) {
+	fs := newFlagSet("route-optimizer")
+	in := fs.String("in", "", "token to sell: symbol (e.g. SOL) or mint address (required)")
+	out := fs.String("out", "", "token to buy: symbol (e.g. USDC) or mint address (required)")
+	amount := fs.Float64("amount", 1, "amount of --in to estimate the output for")
+	hours := fs.Float64("hours", 1, "how far back to scan")
+	limit := fs.Int("limit", 500, "stop after this many signatures per program (0 = no limit)")
+	workers := fs.Int("workers", 8, "concurrent transaction fetches")
+	format := fs.String("output", "table", "output format: json, table")
+	fs.Parse(args)
+
+	inMint, err := parseToken(*in)
+	if err != nil {
+		log.Fatalf("route-optimizer: invalid --in: %s", err)
+	}
+	outMint, err := parseToken(*out)
+	if err != nil {
+		log.Fatalf("route-optimizer: invalid --out: %s", err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+	// Every name starts with "", so this is every registered program
+	programs := registry.Default().Find("")
+	since := time.Now().Add(-time.Duration(*hours * float64(time.Hour)))
+	swaps := collectProgramSwaps(ctx, rpcClient, programs, since, *limit, *workers)
+
+	suggestion := routeopt.Suggest(inMint, outMint, *amount, swaps)
+	if suggestion == nil {
+		log.Fatalf("No DEX has %d or more recent swaps from %s to %s", routeopt.MinSwaps, *in, *out)
+	}
+
+	cells := make([][]string, len(suggestion.Ranked))
+	for i, r := range suggestion.Ranked {
+		cells[i] = []string{
+			strconv.Itoa(i + 1),
+			r.Dex,
+			strconv.Itoa(r.SwapCount),
+			fmt.Sprintf("%.6g", r.MedianRate),
+			fmt.Sprintf("%.6g", r.ExpectedOut),
+		}
+	}
+	writeReport(*format, suggestion, []string{"Rank", "Dex", "SwapCount", "MedianRate", "ExpectedOut"}, cells, 0, 2, 3, 4)
+	printInfo("Best historical output: %s, about %.6g %s for %g %s\n", suggestion.Best.Dex, suggestion.Best.ExpectedOut, *out, *amount, *in)
+}
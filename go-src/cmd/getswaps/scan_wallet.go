@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/pagination"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/slots"
+)
+
+// runScanWallet walks a wallet's history newest-first and prints one combined
+// swap object per line for every transaction that parses as a swap
+func runScanWallet(args []string) {
+	fs := newFlagSet("scan-wallet")
+	wallet := fs.String("wallet", "", "wallet address to scan (required)")
+	limit := fs.Int("limit", 0, "stop after this many signatures (0 = no limit)")
+	sinceSlot := fs.Uint64("since-slot", 0, "stop at transactions older than this slot")
+	sinceBlockTime := fs.String("since-block-time", "", "stop at transactions older than this RFC3339 time, e.g. 2024-01-01T00:00:00Z")
+	cursorFlag := fs.String("cursor", "", "resume from a cursor printed by an earlier scan")
+	cursorFile := fs.String("cursor-file", "", "save the cursor here after each page instead of printing it, and resume from it if it exists")
+	var outOpts outputOptions
+	outOpts.register(fs, "ndjson")
+	fs.Parse(args)
+
+	if *wallet == "" {
+		log.Fatal("scan-wallet: --wallet is required")
+	}
+	walletKey, err := solana.PublicKeyFromBase58(*wallet)
+	if err != nil {
+		log.Fatalf("scan-wallet: invalid --wallet: %s", err)
+	}
+
+	cursor, err := startCursor(*cursorFlag, *cursorFile)
+	if err != nil {
+		log.Fatalf("scan-wallet: %s", err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+
+	// Translate the time boundary into a slot so both flags share one cutoff
+	if *sinceBlockTime != "" {
+		since, err := time.Parse(time.RFC3339, *sinceBlockTime)
+		if err != nil {
+			log.Fatalf("scan-wallet: invalid --since-block-time: %s", err)
+		}
+		slot, err := slots.FindSlotForTime(ctx, rpcClient, since)
+		if err != nil {
+			log.Fatalf("Error finding slot for %s: %s", since.Format(time.RFC3339), err)
+		}
+		*sinceSlot = max(*sinceSlot, slot)
+	}
+
+	out := outOpts.writer(ctx, rpcClient)
+	defer out.Close()
+	seen := 0
+
+	err = signatureHistoryFrom(ctx, rpcClient, walletKey, cursor, func(sig *rpc.TransactionSignature) bool {
+		if sig.Slot < *sinceSlot || (*limit > 0 && seen >= *limit) {
+			return false
+		}
+		seen++
+
+		// Failed transactions never moved any tokens
+		if sig.Err != nil {
+			return true
+		}
+
+		result, err := fetchAndParse(ctx, rpcClient, sig.Signature)
+		if err != nil {
+			// An interrupted fetch isn't done, so leave it out of the cursor
+			if ctx.Err() != nil {
+				return false
+			}
+			if !errors.Is(err, errFilteredOut) {
+				log.Printf("Skipping %s: %s", sig.Signature, err)
+			}
+			return true
+		}
+		if err := out.Write(result); err != nil {
+			log.Fatalf("Error writing output: %s", err)
+		}
+		return true
+	}, func(next pagination.Cursor) {
+		saveCursor(next, *cursorFile)
+	})
+	if err != nil {
+		log.Fatalf("Error scanning %s: %s", walletKey, err)
+	}
+}
+
+// startCursor picks where a scan resumes: --cursor if given, else the
+// contents of --cursor-file if it exists, else the newest signature
+func startCursor(flagValue, path string) (pagination.Cursor, error) {
+	if flagValue == "" && path != "" {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return pagination.Cursor{}, nil
+		}
+		if err != nil {
+			return pagination.Cursor{}, err
+		}
+		flagValue = strings.TrimSpace(string(data))
+	}
+	return pagination.Parse(flagValue)
+}
+
+// saveCursor records how far a scan got, in path if set or on stderr
+func saveCursor(cursor pagination.Cursor, path string) {
+	if path == "" {
+		// Not a summary: it's what resuming needs, so --quiet keeps it
+		fmt.Fprintf(os.Stderr, "Cursor: %s\n", cursor)
+		return
+	}
+	// Write then rename so an interruption never leaves a partial cursor
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(cursor.String()+"\n"), 0o644); err != nil {
+		log.Fatalf("Error saving cursor: %s", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		log.Fatalf("Error saving cursor: %s", err)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/schema"
+)
+
+// schemaTypes maps --type values to the record they describe
+var schemaTypes = map[string]func() ([]byte, error){
+	"result":           schema.Generate[model.Result],
+	"swap-data":        schema.Generate[model.SwapData],
+	"transaction-data": schema.Generate[model.TransactionData],
+}
+
+// runSchema prints the JSON Schema for one of the records getswaps emits
+func runSchema(args []string) {
+	names := make([]string, 0, len(schemaTypes))
+	for name := range schemaTypes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	fs := newFlagSet("schema")
+	typ := fs.String("type", "result", "record to describe: "+strings.Join(names, ", "))
+	fs.Parse(args)
+
+	generate, ok := schemaTypes[*typ]
+	if !ok {
+		log.Fatalf("schema: unknown --type %q (want one of %s)", *typ, strings.Join(names, ", "))
+	}
+	s, err := generate()
+	if err != nil {
+		log.Fatalf("Error generating schema: %s", err)
+	}
+	if _, err := os.Stdout.Write(append(s, '\n')); err != nil {
+		log.Fatalf("Error writing output: %s", err)
+	}
+}
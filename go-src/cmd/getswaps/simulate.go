@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/instructions"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/registry"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/simulate"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+)
+
+// runSimulate previews the swap an unsent transaction would make
+func runSimulate(args []string) {
+	fs := newFlagSet("simulate")
+	txBase64 := fs.String("tx-base64", "", "base64 encoded transaction to simulate (required)")
+	var outOpts outputOptions
+	outOpts.register(fs, "json")
+	fs.Parse(args)
+
+	if *txBase64 == "" {
+		log.Fatal("simulate: --tx-base64 is required")
+	}
+	tx, err := solana.TransactionFromBase64(*txBase64)
+	if err != nil {
+		log.Fatalf("simulate: invalid --tx-base64: %s", err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+
+	snapshot, err := simulate.Prepare(ctx, rpcClient, tx)
+	if err != nil {
+		log.Fatalf("Error preparing simulation: %s", err)
+	}
+	simResult, err := snapshot.Simulate(ctx, rpcClient, tx)
+	if err != nil {
+		log.Fatalf("Error simulating transaction: %s", err)
+	}
+	swap, err := simulate.ParseSimulatedSwap(simResult, snapshot)
+	if err != nil {
+		for _, line := range simResult.Logs {
+			log.Println(line)
+		}
+		log.Fatal(err)
+	}
+	fillMintDecimals(ctx, rpcClient, swap)
+
+	for _, ix := range tx.Message.Instructions {
+		programID, err := tx.Message.Program(ix.ProgramIDIndex)
+		if err != nil {
+			continue
+		}
+		if info, ok := registry.Default().Lookup(programID); ok {
+			swap.Dex, swap.DexVersion, swap.DexType = info.Name, info.Version, string(info.Type)
+			swap.ProgramID = &programID
+			break
+		}
+	}
+
+	txData := &model.TransactionData{Signer: snapshot.Signer, AllSigners: tx.Message.Signers()}
+	if len(tx.Signatures) > 0 {
+		txData.Signature = tx.Signatures[0]
+		swap.Signature = tx.Signatures[0]
+	}
+	if simResult.UnitsConsumed != nil {
+		txData.ComputeUnitsConsumed = *simResult.UnitsConsumed
+	}
+	txData.ProgramErrors = instructions.ExtractProgramErrors(simResult.Logs)
+
+	w := outOpts.writer(ctx, rpcClient)
+	defer w.Close()
+	if err := w.Write(&model.Result{SwapData: swap, TransactionData: txData}); err != nil {
+		log.Fatalf("Error writing output: %s", err)
+	}
+}
+
+// fillMintDecimals reads decimals for any leg the simulation couldn't
+// provide them for from the mint accounts
+func fillMintDecimals(ctx context.Context, rpcClient *rpc.Client, swap *model.SwapData) {
+	fill := func(mint solana.PublicKey, decimals *uint8) {
+		if *decimals != 0 {
+			return
+		}
+		d, err := tokenmetadata.FetchDecimals(ctx, rpcClient, mint)
+		if err != nil {
+			log.Printf("Error fetching mint decimals, amounts are unscaled: %s", err)
+			return
+		}
+		*decimals = d
+	}
+	fill(swap.TokenInMint, &swap.TokenInDecimals)
+	fill(swap.TokenOutMint, &swap.TokenOutDecimals)
+	swap.AmountInUI = model.UIAmount(swap.TokenInAmount, swap.TokenInDecimals)
+	swap.AmountOutUI = model.UIAmount(swap.TokenOutAmount, swap.TokenOutDecimals)
+}
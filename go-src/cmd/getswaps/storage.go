@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/output"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage/bigquery"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage/clickhouse"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage/duckdb"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage/opensearch"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage/spanner"
+)
+
+// storageFormats are the --output values that write to a database instead of stdout
+var storageFormats = []string{"bigquery", "cloud-spanner", "clickhouse", "duckdb", "opensearch"}
+
+// storageOptions configure the database output modes
+type storageOptions struct {
+	table    string
+	storeRaw bool
+
+	bigquery      bigquery.Config
+	spanner       spanner.Config
+	clickhouseDSN string
+	duckdbPath    string
+	opensearch    opensearch.Config
+}
+
+func (o *storageOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.table, "table", storage.DefaultTable, "table to write swaps to in database output modes")
+	fs.BoolVar(&o.storeRaw, "store-raw", false, "also store each getTransaction response in "+storage.RawTable+", for reindex (10-20x the space)")
+	fs.StringVar(&o.bigquery.Project, "bq-project", os.Getenv("GOOGLE_CLOUD_PROJECT"), "Google Cloud project for --output bigquery")
+	fs.StringVar(&o.bigquery.Dataset, "bq-dataset", "", "dataset for --output bigquery, created if it doesn't exist")
+	fs.StringVar(&o.bigquery.Table, "bq-table", "", "table for --output bigquery (default --table)")
+	fs.StringVar(&o.spanner.Project, "spanner-project", os.Getenv("GOOGLE_CLOUD_PROJECT"), "Google Cloud project for --output cloud-spanner")
+	fs.StringVar(&o.spanner.Instance, "spanner-instance", "", "Spanner instance for --output cloud-spanner")
+	fs.StringVar(&o.spanner.Database, "spanner-database", "", "Spanner database for --output cloud-spanner")
+	fs.StringVar(&o.clickhouseDSN, "clickhouse-dsn", os.Getenv("CLICKHOUSE_DSN"), "server for --output clickhouse, e.g. clickhouse://localhost:9000/default")
+	fs.StringVar(&o.duckdbPath, "db-path", duckdb.DefaultPath, "database file for --output duckdb")
+	fs.StringVar(&o.opensearch.URL, "opensearch-url", os.Getenv("OPENSEARCH_URL"), "cluster for --output opensearch, e.g. https://localhost:9200")
+	fs.StringVar(&o.opensearch.User, "opensearch-user", os.Getenv("OPENSEARCH_USER"), "user for --output opensearch")
+	fs.StringVar(&o.opensearch.Password, "opensearch-pass", os.Getenv("OPENSEARCH_PASSWORD"), "password for --output opensearch (default OPENSEARCH_PASSWORD, which keeps it out of the process list)")
+}
+
+// writer connects to the database backing format
+func (o *storageOptions) writer(ctx context.Context, format string) (output.Writer, error) {
+	switch format {
+	case "bigquery":
+		cfg, err := o.bigqueryConfig("--output")
+		if err != nil {
+			return nil, err
+		}
+		cfg.StoreRaw = o.storeRaw
+		return bigquery.NewWriter(ctx, cfg)
+	case "cloud-spanner":
+		cfg := o.spanner
+		if cfg.Project == "" || cfg.Instance == "" || cfg.Database == "" {
+			return nil, fmt.Errorf("--output cloud-spanner needs --spanner-project, --spanner-instance and --spanner-database")
+		}
+		cfg.Table, cfg.StoreRaw = o.table, o.storeRaw
+		return spanner.NewWriter(ctx, cfg)
+	case "clickhouse":
+		if o.clickhouseDSN == "" {
+			return nil, fmt.Errorf("--output clickhouse needs --clickhouse-dsn")
+		}
+		return clickhouse.NewWriter(ctx, clickhouse.Config{DSN: o.clickhouseDSN, Table: o.table, StoreRaw: o.storeRaw})
+	case "duckdb":
+		return duckdb.NewWriter(ctx, duckdb.Config{Path: o.duckdbPath, Table: o.table, StoreRaw: o.storeRaw})
+	case "opensearch":
+		if o.opensearch.URL == "" {
+			return nil, fmt.Errorf("--output opensearch needs --opensearch-url")
+		}
+		cfg := o.opensearch
+		cfg.StoreRaw = o.storeRaw
+		return opensearch.NewWriter(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage format %q", format)
+	}
+}
+
+// purger connects to the database backing format for purge
+func (o *storageOptions) purger(ctx context.Context, format string) (storage.Purger, error) {
+	switch format {
+	case "bigquery":
+		cfg, err := o.bigqueryConfig("--backend")
+		if err != nil {
+			return nil, err
+		}
+		return bigquery.NewPurger(ctx, cfg)
+	case "cloud-spanner":
+		cfg := o.spanner
+		if cfg.Project == "" || cfg.Instance == "" || cfg.Database == "" {
+			return nil, fmt.Errorf("--backend cloud-spanner needs --spanner-project, --spanner-instance and --spanner-database")
+		}
+		cfg.Table = o.table
+		return spanner.NewPurger(ctx, cfg)
+	case "clickhouse":
+		if o.clickhouseDSN == "" {
+			return nil, fmt.Errorf("--backend clickhouse needs --clickhouse-dsn")
+		}
+		return clickhouse.NewPurger(clickhouse.Config{DSN: o.clickhouseDSN, Table: o.table})
+	case "duckdb":
+		if _, err := os.Stat(o.duckdbPath); err != nil {
+			return nil, err
+		}
+		return duckdb.NewPurger(duckdb.Config{Path: o.duckdbPath, Table: o.table})
+	case "opensearch":
+		if o.opensearch.URL == "" {
+			return nil, fmt.Errorf("--backend opensearch needs --opensearch-url")
+		}
+		return opensearch.NewPurger(o.opensearch)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (want one of %v)", format, storageFormats)
+	}
+}
+
+// bigqueryConfig checks the BigQuery flags are set, naming the flag that
+// chose BigQuery in the error if not
+func (o *storageOptions) bigqueryConfig(flag string) (bigquery.Config, error) {
+	cfg := o.bigquery
+	if cfg.Project == "" || cfg.Dataset == "" {
+		return cfg, fmt.Errorf("%s bigquery needs --bq-project and --bq-dataset", flag)
+	}
+	if cfg.Table == "" {
+		cfg.Table = o.table
+	}
+	return cfg, nil
+}
+
+func isStorageFormat(format string) bool {
+	return slices.Contains(storageFormats, format)
+}
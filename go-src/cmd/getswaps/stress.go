@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/parser"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/testutil"
+)
+
+// runStressTest parses generated transactions as fast as it can to measure
+// the parser's throughput and memory use without an RPC endpoint. The
+// result is printed as a Go benchmark line so benchstat and CI benchmark
+// trackers can read it.
+func runStressTest(args []string) {
+	fs := newFlagSet("stress-test")
+	transactions := fs.Int("transactions", 100000, "transactions to parse")
+	workers := fs.Int("workers", 32, "transactions parsed at once")
+	synthetic := fs.Bool("synthetic", true, "parse generated transactions; replaying real ones isn't supported")
+	dexes := fs.String("dex", "raydium,orca,meteora,pump", "comma separated DEXes to generate swaps on, taken in turn")
+	fs.Parse(args)
+
+	if !*synthetic {
+		log.Fatal("stress-test: only --synthetic is supported; use bench to load an RPC endpoint")
+	}
+	if *transactions < 1 || *workers < 1 {
+		log.Fatal("stress-test: --transactions and --workers must be at least 1")
+	}
+
+	// Transactions are generated and encoded up front so the timed part
+	// decodes and parses them the way one fetched over RPC would be
+	var names []string
+	for _, name := range strings.Split(*dexes, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		log.Fatal("stress-test: --dex is empty")
+	}
+	logInfo("Generating %d synthetic transactions", *transactions)
+	docs := make([][]byte, *transactions)
+	for i := range docs {
+		tx := testutil.GenerateSyntheticTransaction(names[i%len(names)])
+		if tx == nil {
+			log.Fatalf("stress-test: no registered program matches --dex %q", names[i%len(names)])
+		}
+		doc, err := json.Marshal(tx)
+		if err != nil {
+			log.Fatalf("stress-test: encoding transaction: %s", err)
+		}
+		docs[i] = doc
+	}
+
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	var (
+		failures atomic.Int64
+		peakHeap atomic.Uint64
+		next     atomic.Int64
+		wg       sync.WaitGroup
+	)
+	done := make(chan struct{})
+	go sampleHeap(&peakHeap, done)
+
+	start := time.Now()
+	for range min(*workers, *transactions) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= len(docs) {
+					return
+				}
+				var tx rpc.GetTransactionResult
+				if err := json.Unmarshal(docs[i], &tx); err != nil {
+					failures.Add(1)
+					continue
+				}
+				if _, err := parser.ParseSwap(&tx); err != nil {
+					failures.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	close(done)
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	peakHeap.Store(max(peakHeap.Load(), after.HeapAlloc))
+
+	result := testing.BenchmarkResult{
+		N:         *transactions,
+		T:         elapsed,
+		MemAllocs: after.Mallocs - before.Mallocs,
+		MemBytes:  after.TotalAlloc - before.TotalAlloc,
+		Extra: map[string]float64{
+			"tx/s":         float64(*transactions) / elapsed.Seconds(),
+			"peak-heap-MB": float64(peakHeap.Load()) / (1 << 20),
+		},
+	}
+	fmt.Printf("BenchmarkParseSwap-%d\t%s\t%s\n", *workers, result.String(), result.MemString())
+	if n := failures.Load(); n > 0 {
+		log.Fatalf("stress-test: %d of %d transactions failed to parse", n, *transactions)
+	}
+}
+
+// heapSampleInterval is how often the heap is sampled for its peak size.
+// ReadMemStats stops the world, so sampling much faster would slow the run.
+const heapSampleInterval = 50 * time.Millisecond
+
+// sampleHeap records the largest live heap seen until done is closed
+func sampleHeap(peak *atomic.Uint64, done <-chan struct{}) {
+	ticker := time.NewTicker(heapSampleInterval)
+	defer ticker.Stop()
+	var stats runtime.MemStats
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			runtime.ReadMemStats(&stats)
+			if stats.HeapAlloc > peak.Load() {
+				peak.Store(stats.HeapAlloc)
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/backoff"
+)
+
+// fetchTransaction fetches a confirmed transaction by signature. With
+// --rpc-timeout each call is cut off after that long and retried, up to
+// --max-retries times; the client's own retries of failed requests happen
+// within each call.
+func fetchTransaction(ctx context.Context, rpcClient *rpc.Client, txSig solana.Signature) (*rpc.GetTransactionResult, error) {
+	if global.rpcTimeout <= 0 {
+		return getTransaction(ctx, rpcClient, txSig)
+	}
+	// newRPCClient has already checked the name
+	strategy, _ := backoff.New(global.backoffStrategy)
+	for attempt := 1; ; attempt++ {
+		callCtx, cancel := context.WithTimeout(ctx, global.rpcTimeout)
+		tx, err := getTransaction(callCtx, rpcClient, txSig)
+		timedOut := err != nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+		cancel()
+		if !timedOut {
+			return tx, err
+		}
+		if attempt > global.maxRetries {
+			return nil, fmt.Errorf("getTransaction timed out after --rpc-timeout %s, %d times", global.rpcTimeout, attempt)
+		}
+		timer := time.NewTimer(strategy.NextDelay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func getTransaction(ctx context.Context, rpcClient *rpc.Client, txSig solana.Signature) (*rpc.GetTransactionResult, error) {
+	// Specify the maximum transaction version supported
+	var maxTxVersion uint64 = 0
+
+	return rpcClient.GetTransaction(
+		ctx,
+		txSig,
+		&rpc.GetTransactionOpts{
+			Commitment:                     rpc.CommitmentConfirmed,
+			MaxSupportedTransactionVersion: &maxTxVersion,
+		},
+	)
+}
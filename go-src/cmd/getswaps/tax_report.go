@@ -0,0 +1,50 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/portfolio"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/taxreport"
+)
+
+// runTaxReport exports a wallet's swaps in one tax year as CSV
+func runTaxReport(args []string) {
+	fs := newFlagSet("tax-report")
+	wallet := fs.String("wallet", "", "wallet address (required)")
+	year := fs.Int("year", time.Now().UTC().Year()-1, "tax year")
+	currency := fs.String("currency", "USD", "fiat currency for trade values")
+	limit := fs.Int("limit", 0, "number of recent signatures to scan (0 = whole history)")
+	workers := fs.Int("workers", 8, "concurrent transaction fetches")
+	fs.Parse(args)
+
+	if *wallet == "" {
+		log.Fatal("tax-report: --wallet is required")
+	}
+	walletKey, err := solana.PublicKeyFromBase58(*wallet)
+	if err != nil {
+		log.Fatalf("tax-report: invalid --wallet: %s", err)
+	}
+	if err := taxreport.ValidateCurrency(*currency); err != nil {
+		log.Fatalf("tax-report: invalid --currency: %s", err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+
+	ledger := portfolio.NewLedger(portfolio.FIFO)
+	for _, s := range walletSwaps(ctx, rpcClient, walletKey, *limit, *workers) {
+		ledger.Add(s)
+	}
+	rows, err := taxreport.Generate(ledger, *year, *currency)
+	if err != nil {
+		log.Fatalf("tax-report: %s", err)
+	}
+	if err := taxreport.WriteCSV(os.Stdout, rows); err != nil {
+		log.Fatalf("Error writing output: %s", err)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// errFilteredOut is returned for swaps --token-filter-file excludes; batch
+// modes drop them without logging
+var errFilteredOut = errors.New("swap involves none of the --token-filter-file mints")
+
+// tokenFilter is the set of mints from --token-filter-file, re-read on
+// SIGHUP so long-running commands can change it without restarting
+type tokenFilter struct {
+	path string
+
+	mu    sync.RWMutex
+	mints map[solana.PublicKey]struct{}
+}
+
+// loadTokenFilter is the --token-filter-file flag's parser
+func loadTokenFilter(path string) error {
+	f := &tokenFilter{path: path}
+	if err := f.reload(); err != nil {
+		return err
+	}
+	global.tokenFilter = f
+
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	go func() {
+		for range hangups {
+			if err := f.reload(); err != nil {
+				log.Printf("Keeping the previous token filter: %s", err)
+				continue
+			}
+			logInfo("Reloaded %s", f.path)
+		}
+	}()
+	return nil
+}
+
+// reload reads the filter file: one mint per line, ignoring blank lines and
+// # comments
+func (f *tokenFilter) reload() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	mints := make(map[solana.PublicKey]struct{})
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		mint, err := solana.PublicKeyFromBase58(line)
+		if err != nil {
+			return fmt.Errorf("%s line %d: %w", f.path, lineNo, err)
+		}
+		mints[mint] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.mints = mints
+	f.mu.Unlock()
+	return nil
+}
+
+// allows reports whether either leg of the swap is a listed mint. A nil
+// filter allows everything.
+func (f *tokenFilter) allows(swap *model.SwapData) bool {
+	if f == nil {
+		return true
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	_, in := f.mints[swap.TokenInMint]
+	_, out := f.mints[swap.TokenOutMint]
+	return in || out
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/reports"
+)
+
+// runTopPools ranks the pools traded against on a program by recent activity
+func runTopPools(args []string) {
+	fs := newFlagSet("top-pools")
+	var opts reportOptions
+	opts.register(fs)
+	fs.Parse(args)
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	swaps := opts.collect(ctx, newRPCClient())
+	rows := reports.TopPools(swaps, opts.top)
+
+	cells := make([][]string, len(rows))
+	for i, r := range rows {
+		cells[i] = []string{
+			strconv.Itoa(r.Rank),
+			r.Pool.String(),
+			r.Dex,
+			r.Pair,
+			strconv.Itoa(r.SwapCount),
+			fmt.Sprintf("%.2f", r.VolumeUSD),
+			strconv.Itoa(r.UniqueWallets),
+		}
+	}
+	opts.write(rows, []string{"Rank", "Pool", "Dex", "Pair", "SwapCount", "VolumeUSD", "UniqueWallets"}, cells, 0, 4, 5, 6)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/analytics"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/reports"
+)
+
+// runTopTokens ranks the tokens traded on a program by recent volume
+func runTopTokens(args []string) {
+	fs := newFlagSet("top-tokens")
+	washScore := fs.Bool("wash-score", false, "add each token's wash trading score (0-1) over the swaps scanned")
+	var opts reportOptions
+	opts.register(fs)
+	fs.Parse(args)
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	swaps := opts.collect(ctx, newRPCClient())
+	rows := reports.TopTokens(swaps, opts.top)
+
+	header := []string{"Rank", "Mint", "Symbol", "VolumeUSD", "SwapCount", "UniqueWallets"}
+	numeric := []int{0, 3, 4, 5}
+	if *washScore {
+		header = append(header, "WashScore")
+		numeric = append(numeric, 6)
+	}
+	cells := make([][]string, len(rows))
+	for i, r := range rows {
+		cells[i] = []string{
+			strconv.Itoa(r.Rank),
+			r.Mint.String(),
+			r.Symbol,
+			fmt.Sprintf("%.2f", r.VolumeUSD),
+			strconv.Itoa(r.SwapCount),
+			strconv.Itoa(r.UniqueWallets),
+		}
+		if *washScore {
+			score := analytics.WashTradingScore(swaps, r.Mint)
+			r.WashTradingScore = &score
+			cells[i] = append(cells[i], fmt.Sprintf("%.2f", score))
+		}
+	}
+	opts.write(rows, header, cells, numeric...)
+}
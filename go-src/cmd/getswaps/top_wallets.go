@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/reports"
+)
+
+// runTopWallets ranks the wallets trading on a program by recent volume
+func runTopWallets(args []string) {
+	fs := newFlagSet("top-wallets")
+	var opts reportOptions
+	opts.register(fs)
+	fs.Parse(args)
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+	swaps := opts.collect(ctx, rpcClient)
+	rows := reports.TopWallets(swaps, opts.top)
+
+	if opts.resolveNames {
+		name := walletNamer(ctx, rpcClient)
+		for _, r := range rows {
+			r.Name = name(r.Wallet)
+		}
+	}
+
+	cells := make([][]string, len(rows))
+	for i, r := range rows {
+		wallet := r.Wallet.String()
+		if r.Name != "" {
+			wallet = r.Name
+		}
+		cells[i] = []string{
+			strconv.Itoa(r.Rank),
+			wallet,
+			strconv.Itoa(r.SwapCount),
+			fmt.Sprintf("%.2f", r.VolumeUSD),
+			strconv.Itoa(r.UniqueTokens),
+			fmt.Sprintf("%.2f", r.EstimatedPnL),
+		}
+	}
+	opts.write(rows, []string{"Rank", "Wallet", "SwapCount", "VolumeUSD", "UniqueTokens", "EstimatedPnL"}, cells, 0, 2, 3, 4, 5)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/itchyny/gojq"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/output"
+)
+
+// runTransform applies a jq expression to each record of an NDJSON file,
+// printing every value it produces on its own line
+func runTransform(args []string) {
+	fs := newFlagSet("transform")
+	expr := fs.String("jq", "", "jq expression to apply to each record (required)")
+	input := fs.String("input", "-", "NDJSON file to read (- for stdin)")
+	outPath := fs.String("output", "-", "file to write (- for stdout)")
+	fs.Parse(args)
+
+	if *expr == "" {
+		log.Fatal("transform: --jq is required")
+	}
+	query, err := gojq.Parse(*expr)
+	if err != nil {
+		log.Fatalf("transform: invalid --jq: %s", err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		log.Fatalf("transform: invalid --jq: %s", err)
+	}
+
+	in := os.Stdin
+	if *input != "-" {
+		f, err := os.Open(*input)
+		if err != nil {
+			log.Fatalf("transform: %s", err)
+		}
+		defer f.Close()
+		in = f
+	}
+	var out io.Writer = os.Stdout
+	var file *output.AtomicWriter
+	if *outPath != "-" {
+		f, err := output.NewAtomicWriter(*outPath)
+		if err != nil {
+			log.Fatalf("transform: %s", err)
+		}
+		file, out = f, f
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	w := bufio.NewWriter(out)
+	err = transform(ctx, code, in, w)
+	if flushErr := w.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		log.Fatalf("transform: %s", err)
+	}
+	commitOutput("transform", file)
+}
+
+// transform runs code over each NDJSON record in r, writing its results to w
+// as compact JSON. Records that fail to decode or evaluate are logged and
+// skipped; only read and write errors stop the run.
+func transform(ctx context.Context, code *gojq.Code, r io.Reader, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	br := bufio.NewReader(r)
+	for lineNo := 1; ctx.Err() == nil; lineNo++ {
+		line, readErr := br.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if err := transformRecord(ctx, code, line, enc); err != nil {
+				if _, skipped := err.(recordError); !skipped {
+					return err
+				}
+				log.Printf("Skipping line %d: %s", lineNo, err)
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	return nil
+}
+
+// recordError is a problem with one record rather than the output
+type recordError struct {
+	err error
+}
+
+func (e recordError) Error() string { return e.err.Error() }
+
+// transformRecord evaluates code against one record. Results produced
+// before an evaluation error are still written, as jq does.
+func transformRecord(ctx context.Context, code *gojq.Code, line []byte, enc *json.Encoder) error {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	// Keep large integers such as token amounts exact
+	dec.UseNumber()
+	var record any
+	if err := dec.Decode(&record); err != nil {
+		return recordError{fmt.Errorf("invalid JSON: %w", err)}
+	}
+
+	iter := code.RunWithContext(ctx, record)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			return nil
+		}
+		if err, isErr := v.(error); isErr {
+			return recordError{err}
+		}
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+}
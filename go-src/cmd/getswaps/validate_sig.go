@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	solana "github.com/gagliardetto/solana-go"
+)
+
+// runValidateSig checks that each argument is a well-formed signature,
+// without touching the RPC endpoint
+func runValidateSig(args []string) {
+	fs := newFlagSet("validate-sig")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal("usage: getswaps validate-sig <signature>...")
+	}
+
+	invalid := false
+	for _, arg := range fs.Args() {
+		// SignatureFromBase58 rejects anything that doesn't decode to 64 bytes
+		if _, err := solana.SignatureFromBase58(arg); err != nil {
+			fmt.Printf("%s: %s\n", arg, err)
+			invalid = true
+			continue
+		}
+		fmt.Printf("%s: OK\n", arg)
+	}
+	if invalid {
+		os.Exit(1)
+	}
+}
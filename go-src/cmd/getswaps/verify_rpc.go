@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Outcomes of a verify-rpc check. A check is skipped when an earlier one
+// it depends on failed, or the block had nothing for it to fetch.
+const (
+	checkPass = "pass"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+// recentBlockWindow is how many slots back verify-rpc looks for a block
+// when --slot isn't given
+const recentBlockWindow = 50
+
+// rpcCheck is one API call verify-rpc made and how it went
+type rpcCheck struct {
+	Method    string  `json:"method"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Detail    string  `json:"detail,omitempty"`
+}
+
+// verifyReport is what verify-rpc prints
+type verifyReport struct {
+	Checks []rpcCheck `json:"checks"`
+	Passed bool       `json:"passed"`
+}
+
+// runVerifyRPC calls each RPC method getswaps depends on and reports
+// whether the endpoint answered, exiting 1 if any check failed
+func runVerifyRPC(args []string) {
+	fs := newFlagSet("verify-rpc")
+	url := fs.String("url", "", "endpoint to check (default SOLANA_RPC_URL)")
+	slot := fs.Uint64("slot", 0, "block to fetch; an old one checks the node keeps history (default the latest)")
+	sig := fs.String("sig", "", "transaction to fetch; an old one checks the node keeps history (default one from the block)")
+	format := fs.String("output", "table", "output format: json, table")
+	fs.Parse(args)
+
+	var txSig solana.Signature
+	if *sig != "" {
+		var err error
+		if txSig, err = solana.SignatureFromBase58(*sig); err != nil {
+			log.Fatalf("verify-rpc: invalid --sig: %s", err)
+		}
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	var rpcClient *rpc.Client
+	if *url != "" {
+		rpcClient = newRPCClientFor(*url)
+	} else {
+		rpcClient = newRPCClient()
+	}
+
+	report := &verifyReport{Passed: true}
+	check := func(method string, call func() (string, error)) bool {
+		start := time.Now()
+		detail, err := call()
+		c := rpcCheck{Method: method, Status: checkPass, LatencyMs: msSince(start), Detail: detail}
+		if err != nil {
+			c.Status, c.Detail = checkFail, err.Error()
+			report.Passed = false
+		}
+		report.Checks = append(report.Checks, c)
+		return err == nil
+	}
+	skip := func(method, reason string) {
+		report.Checks = append(report.Checks, rpcCheck{Method: method, Status: checkSkip, Detail: reason})
+	}
+
+	check("getVersion", func() (string, error) {
+		version, err := rpcClient.GetVersion(ctx)
+		if err != nil {
+			return "", err
+		}
+		return "solana-core " + version.SolanaCore, nil
+	})
+	check("getHealth", func() (string, error) {
+		return rpcClient.GetHealth(ctx)
+	})
+	check("getSlot", func() (string, error) {
+		tip, err := rpcClient.GetSlot(ctx, rpc.CommitmentConfirmed)
+		return fmt.Sprintf("slot %d", tip), err
+	})
+	check("getBlockHeight", func() (string, error) {
+		height, err := rpcClient.GetBlockHeight(ctx, rpc.CommitmentConfirmed)
+		return fmt.Sprintf("height %d", height), err
+	})
+
+	// The block supplies a transaction to fetch when --sig isn't given,
+	// and a version 0 one for the versioned check
+	var versioned solana.Signature
+	blockSlot := *slot
+	if blockSlot == 0 {
+		// The newest blocks may not be served yet, and the tip slot may
+		// have been skipped, so take a produced one a little way back
+		if produced, err := recentBlocks(ctx, rpcClient, recentBlockWindow); err == nil && len(produced) > 0 {
+			blockSlot = produced[0]
+		}
+	}
+	if blockSlot == 0 {
+		skip("getBlock", "no recent block found to fetch")
+	} else {
+		check("getBlock", func() (string, error) {
+			block, err := fetchFullBlock(ctx, rpcClient, blockSlot)
+			if err != nil {
+				return "", err
+			}
+			for _, tx := range block.Transactions {
+				parsed, err := tx.GetTransaction()
+				if err != nil || len(parsed.Signatures) == 0 {
+					continue
+				}
+				if txSig.IsZero() {
+					txSig = parsed.Signatures[0]
+				}
+				if versioned.IsZero() && tx.Version == 0 {
+					versioned = parsed.Signatures[0]
+				}
+			}
+			return fmt.Sprintf("slot %d, %d transactions", blockSlot, len(block.Transactions)), nil
+		})
+	}
+
+	if txSig.IsZero() {
+		skip("getTransaction", "no transaction to fetch without --sig or getBlock")
+	} else {
+		check("getTransaction", func() (string, error) {
+			tx, err := fetchTransaction(ctx, rpcClient, txSig)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s, slot %d", txSig, tx.Slot), nil
+		})
+	}
+
+	// Most swaps are version 0 transactions, which the node only returns
+	// when asked with maxSupportedTransactionVersion: 0
+	if versioned.IsZero() {
+		skip("getTransaction v0", "no version 0 transaction in the block")
+	} else {
+		check("getTransaction v0", func() (string, error) {
+			tx, err := fetchTransaction(ctx, rpcClient, versioned)
+			if err != nil {
+				return "", err
+			}
+			if tx.Version != 0 {
+				return "", fmt.Errorf("%s came back as version %d, not 0", versioned, tx.Version)
+			}
+			return fmt.Sprintf("%s, maxSupportedTransactionVersion 0", versioned), nil
+		})
+	}
+
+	rows := make([][]string, len(report.Checks))
+	for i, c := range report.Checks {
+		latency := "-"
+		if c.Status != checkSkip {
+			latency = fmt.Sprintf("%.0f", c.LatencyMs)
+		}
+		rows[i] = []string{c.Method, c.Status, latency, c.Detail}
+	}
+	writeReport(*format, report, []string{"Method", "Status", "LatencyMs", "Detail"}, rows, 2)
+	if !report.Passed {
+		os.Exit(1)
+	}
+}
+
+// fetchFullBlock fetches a block's transactions, with version 0 ones
+// included, but not its rewards
+func fetchFullBlock(ctx context.Context, rpcClient *rpc.Client, slot uint64) (*rpc.GetBlockResult, error) {
+	var maxTxVersion uint64 = 0
+	rewards := false
+	return rpcClient.GetBlockWithOpts(ctx, slot, &rpc.GetBlockOpts{
+		Encoding:                       solana.EncodingBase64,
+		TransactionDetails:             rpc.TransactionDetailsFull,
+		Rewards:                        &rewards,
+		Commitment:                     rpc.CommitmentConfirmed,
+		MaxSupportedTransactionVersion: &maxTxVersion,
+	})
+}
+
+// msSince is the milliseconds elapsed since start
+func msSince(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
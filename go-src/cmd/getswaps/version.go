@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build information, set with -ldflags "-X main.version=..." (see make build)
+var (
+	version   = "0.0.0-dev"
+	gitCommit = ""
+	buildTime = ""
+)
+
+// buildInfo is what the version subcommand prints
+type buildInfo struct {
+	Version    string `json:"version"`
+	GitCommit  string `json:"git_commit"`
+	BuildTime  string `json:"build_time"`
+	GoVersion  string `json:"go_version"`
+	TargetOS   string `json:"target_os"`
+	TargetArch string `json:"target_arch"`
+}
+
+// currentBuildInfo returns the injected build information, falling back to
+// the VCS stamp go build records when the ldflags weren't set
+func currentBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:    version,
+		GitCommit:  gitCommit,
+		BuildTime:  buildTime,
+		GoVersion:  runtime.Version(),
+		TargetOS:   runtime.GOOS,
+		TargetArch: runtime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.GitCommit == "":
+				info.GitCommit = s.Value
+			case s.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = s.Value
+			}
+		}
+	}
+	return info
+}
+
+// runVersion prints the build information
+func runVersion(args []string) {
+	fs := newFlagSet("version")
+	asJSON := fs.Bool("json", false, "print as JSON")
+	fs.Parse(args)
+
+	info := currentBuildInfo()
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			log.Fatalf("Error writing output: %s", err)
+		}
+		return
+	}
+	fmt.Printf("Version:    %s\n", info.Version)
+	fmt.Printf("GitCommit:  %s\n", info.GitCommit)
+	fmt.Printf("BuildTime:  %s\n", info.BuildTime)
+	fmt.Printf("GoVersion:  %s\n", info.GoVersion)
+	fmt.Printf("TargetOS:   %s\n", info.TargetOS)
+	fmt.Printf("TargetArch: %s\n", info.TargetArch)
+}
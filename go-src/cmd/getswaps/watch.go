@@ -0,0 +1,181 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/output"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/registry"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/risk"
+)
+
+// runWatch polls a program or wallet for new transactions and prints each
+// swap as it lands
+func runWatch(args []string) {
+	fs := newFlagSet("watch")
+	program := fs.String("program", "", "program name from the registry (e.g. raydium) or program address")
+	wallet := fs.String("wallet", "", "wallet address")
+	interval := fs.Duration("interval", 2*time.Second, "how often to poll for new transactions")
+	workers := fs.Int("workers", 8, "concurrent transaction fetches")
+	riskThreshold := fs.Int("risk-threshold", 0, "only print swaps with at least this risk score (0-100, 0 = print all)")
+	pidFile := fs.String("pid-file", "", "write the process ID here while running, refusing to start if another watch holds it")
+	var outOpts outputOptions
+	outOpts.register(fs, "ndjson")
+	fs.Parse(args)
+
+	var addresses []solana.PublicKey
+	switch {
+	case *program != "" && *wallet != "":
+		log.Fatal("watch: --program and --wallet are mutually exclusive")
+	case *program != "":
+		// Resolved once the RPC client has picked the cluster's registry
+	case *wallet != "":
+		walletKey, err := solana.PublicKeyFromBase58(*wallet)
+		if err != nil {
+			log.Fatalf("watch: invalid --wallet: %s", err)
+		}
+		addresses = []solana.PublicKey{walletKey}
+	default:
+		log.Fatal("watch: --program or --wallet is required")
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+	rpcClient := newRPCClient()
+	if *program != "" {
+		addresses = registry.Default().Find(*program)
+		if len(addresses) == 0 {
+			log.Fatalf("No program in the registry matches %q", *program)
+		}
+	}
+	out := outOpts.writer(ctx, rpcClient)
+	defer out.Close()
+
+	// Taken last, so the fatal errors above can't leave it behind; watch
+	// returns its errors for the file to be removed before exiting
+	remove := func() {}
+	if *pidFile != "" {
+		var err error
+		if remove, err = createPIDFile(*pidFile); err != nil {
+			log.Fatalf("watch: --pid-file: %s", err)
+		}
+	}
+	err := watch(ctx, rpcClient, addresses, out, *interval, *workers, *riskThreshold)
+	remove()
+	if err != nil {
+		out.Close()
+		log.Fatalf("watch: %s", err)
+	}
+}
+
+// watch polls the addresses until ctx ends, writing each new swap to out
+func watch(ctx context.Context, rpcClient *rpc.Client, addresses []solana.PublicKey, out output.Writer, interval time.Duration, workers, riskThreshold int) error {
+	scorer := risk.NewScorer(rpcClient)
+
+	// Start from the present; only transactions after the first poll are printed
+	latest := make(map[solana.PublicKey]solana.Signature)
+	for _, address := range addresses {
+		sigs, err := newSignatures(ctx, rpcClient, address, solana.Signature{}, 1)
+		if err != nil {
+			return fmt.Errorf("polling %s: %w", address, err)
+		}
+		if len(sigs) > 0 {
+			latest[address] = sigs[0].Signature
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		seen := make(map[solana.Signature]struct{})
+		var batch []solana.Signature
+		for _, address := range addresses {
+			// A program busier than a page per interval loses the oldest
+			// signatures of each poll; shorten --interval to keep up
+			sigs, err := newSignatures(ctx, rpcClient, address, latest[address], signaturesPageSize)
+			if err != nil {
+				log.Printf("Error polling %s: %s", address, err)
+				continue
+			}
+			if len(sigs) == 0 {
+				continue
+			}
+			latest[address] = sigs[0].Signature
+			for _, sig := range sigs {
+				if _, dup := seen[sig.Signature]; sig.Err == nil && !dup {
+					seen[sig.Signature] = struct{}{}
+					batch = append(batch, sig.Signature)
+				}
+			}
+		}
+
+		var results []*model.Result
+		processSignatures(ctx, rpcClient, batch, workers, func(result *model.Result) {
+			results = append(results, result)
+		})
+		slices.SortFunc(results, func(a, b *model.Result) int {
+			return cmp.Compare(a.SwapData.Slot, b.SwapData.Slot)
+		})
+
+		for _, result := range results {
+			if riskThreshold > 0 {
+				// Write just the swaps that score at or above the threshold
+				for _, swap := range result.AllSwaps() {
+					report, err := scoreSwap(ctx, rpcClient, scorer, swap, workers)
+					if err != nil {
+						log.Printf("Error scoring %s: %s", swap.Signature, err)
+						continue
+					}
+					if report.Score < riskThreshold {
+						continue
+					}
+					logInfo("Risk %d for %s hop %d: %s", report.Score, swap.Signature, swap.HopIndex, factorNames(report))
+					if err := out.Write(result.ForSwap(swap)); err != nil {
+						return fmt.Errorf("writing output: %w", err)
+					}
+				}
+				continue
+			}
+			if err := out.Write(result); err != nil {
+				return fmt.Errorf("writing output: %w", err)
+			}
+		}
+	}
+}
+
+// newSignatures returns up to limit of the address's signatures newer than
+// until, newest first. A zero until returns the most recent.
+func newSignatures(ctx context.Context, rpcClient *rpc.Client, address solana.PublicKey, until solana.Signature, limit int) ([]*rpc.TransactionSignature, error) {
+	sigs, err := rpcClient.GetSignaturesForAddressWithOpts(ctx, address, &rpc.GetSignaturesForAddressOpts{
+		Limit:      &limit,
+		Until:      until,
+		Commitment: rpc.CommitmentConfirmed,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching signatures for %s: %w", address, err)
+	}
+	return sigs, nil
+}
+
+func factorNames(report *risk.RiskReport) string {
+	names := make([]string, len(report.Factors))
+	for i, f := range report.Factors {
+		names[i] = f.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,30 @@
+//go:build js && wasm
+
+// Command wasm is getswaps' swap parser built for the browser. Loaded with
+// Go's wasm_exec.js, it defines a global parseSwap(txJSON) that takes a
+// getTransaction result as JSON and returns the parsed record as JSON.
+// Only parsing runs here: enrichment that needs further RPC calls, like
+// pricing or --fetch-pool-state, is left to the page.
+package main
+
+import (
+	"errors"
+	"syscall/js"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/parser"
+)
+
+func main() {
+	js.Global().Set("parseSwap", js.FuncOf(parseSwap))
+	// Keep the Go runtime alive to serve calls from JavaScript
+	select {}
+}
+
+// parseSwap is parseSwap(txJSON string) string in JavaScript, returning
+// what parser.ParseJSON does; it doesn't throw
+func parseSwap(this js.Value, args []js.Value) any {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return string(parser.ErrorJSON(errors.New("parseSwap takes one argument, the getTransaction result as a JSON string")))
+	}
+	return string(parser.ParseJSON([]byte(args[0].String())))
+}
@@ -0,0 +1,95 @@
+// Package analytics scores trading activity in parsed swaps.
+package analytics
+
+import (
+	"cmp"
+	"slices"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// washScoreRoundTripSlots is how soon a wallet must trade a token back for
+// WashTradingScore to count it as a round trip, and washScoreTopWallets how
+// many of the busiest wallets its concentration measure covers
+const (
+	washScoreRoundTripSlots = 10
+	washScoreTopWallets     = 5
+)
+
+// WashTradingScore rates how much of a token's trading in swaps looks
+// artificial, from 0 to 1. It is the mean of three ratios, each measured in
+// the token's own units so unpriced swaps count too:
+//
+//   - self-trading: the share of volume wallets bought and sold back
+//     themselves, i.e. each wallet's smaller side counted twice
+//   - round trips: the share of swaps reversing the same wallet's previous
+//     swap of the token within washScoreRoundTripSlots slots, whatever the
+//     amount
+//   - concentration: the share of volume from the washScoreTopWallets
+//     busiest wallets
+//
+// A token with no swaps scores 0. The score only ranks tokens against each
+// other; it doesn't prove manipulation.
+func WashTradingScore(swaps []*model.SwapData, mint solana.PublicKey) float64 {
+	type walletVolume struct {
+		bought, sold float64
+		// The wallet's previous swap of the token and whether it bought
+		lastSlot   uint64
+		lastBought bool
+		traded     bool
+	}
+	byWallet := make(map[solana.PublicKey]*walletVolume)
+
+	var matching []*model.SwapData
+	for _, s := range swaps {
+		if !s.Failed && (s.TokenInMint.Equals(mint) || s.TokenOutMint.Equals(mint)) {
+			matching = append(matching, s)
+		}
+	}
+	if len(matching) == 0 {
+		return 0
+	}
+	slices.SortStableFunc(matching, func(a, b *model.SwapData) int { return cmp.Compare(a.Slot, b.Slot) })
+
+	var total float64
+	roundTrips := 0
+	for _, s := range matching {
+		w, ok := byWallet[s.Signer]
+		if !ok {
+			w = &walletVolume{}
+			byWallet[s.Signer] = w
+		}
+		bought := s.TokenOutMint.Equals(mint)
+		if bought {
+			w.bought += s.AmountOutUI
+			total += s.AmountOutUI
+		} else {
+			w.sold += s.AmountInUI
+			total += s.AmountInUI
+		}
+		if w.traded && w.lastBought != bought && s.Slot-w.lastSlot <= washScoreRoundTripSlots {
+			roundTrips++
+		}
+		w.lastSlot, w.lastBought, w.traded = s.Slot, bought, true
+	}
+
+	var selfTraded float64
+	volumes := make([]float64, 0, len(byWallet))
+	for _, w := range byWallet {
+		selfTraded += 2 * min(w.bought, w.sold)
+		volumes = append(volumes, w.bought+w.sold)
+	}
+	slices.SortFunc(volumes, func(a, b float64) int { return cmp.Compare(b, a) })
+	var top float64
+	for _, v := range volumes[:min(len(volumes), washScoreTopWallets)] {
+		top += v
+	}
+
+	roundTripRatio := float64(roundTrips) / float64(len(matching))
+	if total <= 0 {
+		return roundTripRatio / 3
+	}
+	return (selfTraded/total + roundTripRatio + top/total) / 3
+}
@@ -0,0 +1,82 @@
+// Package backoff decides how long to wait between retries, and retries
+// failed RPC requests.
+package backoff
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Strategy returns the delay before retry number attempt, starting at 1
+type Strategy interface {
+	NextDelay(attempt int) time.Duration
+}
+
+// Defaults used by New
+const (
+	DefaultBase = 250 * time.Millisecond
+	DefaultMax  = 30 * time.Second
+)
+
+// LinearBackoff waits Base, 2×Base, 3×Base, ... up to Max
+type LinearBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+func (b LinearBackoff) NextDelay(attempt int) time.Duration {
+	return capped(float64(b.Base)*float64(attempt), b.Max)
+}
+
+// ExponentialBackoff waits Base, 2×Base, 4×Base, ... up to Max
+type ExponentialBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+func (b ExponentialBackoff) NextDelay(attempt int) time.Duration {
+	return capped(float64(b.Base)*math.Pow(2, float64(attempt-1)), b.Max)
+}
+
+// FibonacciBackoff waits Base, Base, 2×Base, 3×Base, 5×Base, ... up to Max,
+// which grows more gently than exponential
+type FibonacciBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+func (b FibonacciBackoff) NextDelay(attempt int) time.Duration {
+	prev, cur := 0.0, 1.0
+	for range attempt - 1 {
+		prev, cur = cur, prev+cur
+		if float64(b.Base)*cur >= float64(b.Max) {
+			break
+		}
+	}
+	return capped(float64(b.Base)*cur, b.Max)
+}
+
+func capped(delay float64, limit time.Duration) time.Duration {
+	if limit > 0 && delay > float64(limit) {
+		return limit
+	}
+	return time.Duration(delay)
+}
+
+// Strategies lists the names accepted by New
+var Strategies = []string{"linear", "exponential", "fibonacci"}
+
+// New returns the named strategy with DefaultBase and DefaultMax
+func New(name string) (Strategy, error) {
+	switch name {
+	case "linear":
+		return LinearBackoff{Base: DefaultBase, Max: DefaultMax}, nil
+	case "exponential":
+		return ExponentialBackoff{Base: DefaultBase, Max: DefaultMax}, nil
+	case "fibonacci":
+		return FibonacciBackoff{Base: DefaultBase, Max: DefaultMax}, nil
+	default:
+		return nil, fmt.Errorf("unknown backoff strategy %q (want one of %v)", name, Strategies)
+	}
+}
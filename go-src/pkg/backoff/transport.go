@@ -0,0 +1,76 @@
+package backoff
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Transport is an http.RoundTripper that retries requests failing with a
+// network error, 429 Too Many Requests or a 5xx status, waiting as Strategy
+// says (or as long as a Retry-After header asks, if longer) between attempts.
+// Requests whose body can't be replayed are not retried.
+type Transport struct {
+	// Defaults to http.DefaultTransport
+	Base       http.RoundTripper
+	Strategy   Strategy
+	MaxRetries int
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := base.RoundTrip(req)
+		if attempt > t.MaxRetries || !retryable(resp, err) || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+
+		delay := t.Strategy.NextDelay(attempt)
+		if resp != nil {
+			if after := retryAfter(resp); after > delay {
+				delay = after
+			}
+			// Drain so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// retryAfter returns the delay a Retry-After header in seconds asks for
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
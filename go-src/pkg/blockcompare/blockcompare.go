@@ -0,0 +1,108 @@
+// Package blockcompare compares the swaps in two blocks.
+package blockcompare
+
+import (
+	"cmp"
+	"slices"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// BlockDiff is the difference between the swaps of two blocks. Swaps are
+// matched by venue: the program that handled them (or the DEX name if the
+// program is unknown) and their token pair, in direction. Output records
+// don't identify the pool itself, so this is the closest stand-in.
+type BlockDiff struct {
+	SlotA uint64 `json:"slot_a"`
+	SlotB uint64 `json:"slot_b"`
+
+	// Swaps with the same venue and amounts in both blocks
+	Unchanged int `json:"unchanged"`
+
+	// Swaps on a venue in both blocks whose amounts differ, paired in block order
+	Changed []ChangedSwap `json:"changed"`
+
+	// Swaps with no counterpart in the other block
+	OnlyA []*model.SwapData `json:"only_a"`
+	OnlyB []*model.SwapData `json:"only_b"`
+}
+
+// ChangedSwap pairs swaps on one venue that moved different amounts
+type ChangedSwap struct {
+	A *model.SwapData `json:"a"`
+	B *model.SwapData `json:"b"`
+}
+
+// venue identifies where a swap happened
+type venue struct {
+	program  solana.PublicKey
+	dex      string
+	tokenIn  solana.PublicKey
+	tokenOut solana.PublicKey
+}
+
+func venueOf(s *model.SwapData) venue {
+	v := venue{tokenIn: s.TokenInMint, tokenOut: s.TokenOutMint}
+	if s.ProgramID != nil {
+		v.program = *s.ProgramID
+	} else {
+		v.dex = s.Dex
+	}
+	return v
+}
+
+func sameAmounts(a, b *model.SwapData) bool {
+	return a.TokenInAmount == b.TokenInAmount && a.TokenOutAmount == b.TokenOutAmount
+}
+
+// Diff compares the swaps of block A with those of block B. Within a venue,
+// swaps with identical amounts are matched first; the remainder are paired
+// as changed in block order, and whatever is left over is only in one block.
+func Diff(slotA uint64, a []*model.SwapData, slotB uint64, b []*model.SwapData) *BlockDiff {
+	diff := &BlockDiff{SlotA: slotA, SlotB: slotB, Changed: []ChangedSwap{}, OnlyA: []*model.SwapData{}, OnlyB: []*model.SwapData{}}
+
+	byVenue := make(map[venue][]*model.SwapData)
+	var venues []venue
+	for _, s := range b {
+		v := venueOf(s)
+		if _, ok := byVenue[v]; !ok {
+			venues = append(venues, v)
+		}
+		byVenue[v] = append(byVenue[v], s)
+	}
+
+	// Exact matches first, so a changed swap isn't paired with one that
+	// has an identical counterpart further down
+	var unmatchedA []*model.SwapData
+	for _, s := range a {
+		candidates := byVenue[venueOf(s)]
+		if i := slices.IndexFunc(candidates, func(c *model.SwapData) bool { return sameAmounts(s, c) }); i >= 0 {
+			byVenue[venueOf(s)] = slices.Delete(candidates, i, i+1)
+			diff.Unchanged++
+			continue
+		}
+		unmatchedA = append(unmatchedA, s)
+	}
+
+	for _, s := range unmatchedA {
+		v := venueOf(s)
+		if candidates := byVenue[v]; len(candidates) > 0 {
+			diff.Changed = append(diff.Changed, ChangedSwap{A: s, B: candidates[0]})
+			byVenue[v] = candidates[1:]
+			continue
+		}
+		diff.OnlyA = append(diff.OnlyA, s)
+	}
+	for _, v := range venues {
+		diff.OnlyB = append(diff.OnlyB, byVenue[v]...)
+	}
+	// Keep B's leftovers in block order rather than venue order
+	order := make(map[*model.SwapData]int, len(b))
+	for i, s := range b {
+		order[s] = i
+	}
+	slices.SortFunc(diff.OnlyB, func(x, y *model.SwapData) int { return cmp.Compare(order[x], order[y]) })
+	return diff
+}
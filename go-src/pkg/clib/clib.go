@@ -0,0 +1,37 @@
+// Package clib converts between C strings and the parser's JSON for the
+// C shared library build in cmd/clib. cgo types are local to the package
+// that imports "C", so C strings cross the package boundary as
+// unsafe.Pointer.
+package clib
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/parser"
+)
+
+// ParseSwapJSON parses the NUL-terminated getTransaction JSON at txJSON
+// with parser.ParseJSON and returns the record in a new C string, which the
+// caller must release with FreeString. A NULL txJSON is reported as an
+// error, in the same JSON.
+func ParseSwapJSON(txJSON unsafe.Pointer) unsafe.Pointer {
+	var out []byte
+	if txJSON == nil {
+		out = []byte(`{"error":"transaction JSON is NULL"}`)
+	} else {
+		out = parser.ParseJSON([]byte(C.GoString((*C.char)(txJSON))))
+	}
+	return unsafe.Pointer(C.CString(string(out)))
+}
+
+// FreeString releases a string returned by ParseSwapJSON. It was allocated
+// with C's malloc, so only this, not the caller's own allocator, may free
+// it.
+func FreeString(s unsafe.Pointer) {
+	C.free(s)
+}
@@ -0,0 +1,71 @@
+// Package copytrade turns a tracked wallet's swaps into alerts describing
+// the trade that would mirror them. It never builds or sends transactions.
+package copytrade
+
+import (
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+)
+
+// Sides of a recommended action
+const (
+	// Spending SOL or a stablecoin on another token
+	Buy = "buy"
+	// Selling a token for SOL or a stablecoin
+	Sell = "sell"
+	// Any other pair, e.g. token to token or SOL to a stablecoin
+	Swap = "swap"
+)
+
+// Alert is one swap by a tracked wallet and the trade that mirrors it
+type Alert struct {
+	WalletAlias       string          `json:"wallet_alias"`
+	SwapData          *model.SwapData `json:"swap_data"`
+	RecommendedAction Action          `json:"recommended_action"`
+}
+
+// Action is the trade to make to copy a swap
+type Action struct {
+	Side       string           `json:"side"`
+	InputMint  solana.PublicKey `json:"input_mint"`
+	OutputMint solana.PublicKey `json:"output_mint"`
+	// The wallet's input amount scaled by the copy size, in whole tokens
+	InputAmountUI float64 `json:"input_amount_ui"`
+	// Output the wallet got per unit of input, the rate a copy should
+	// expect to do no better than
+	ReferenceRate float64 `json:"reference_rate"`
+}
+
+// NewAlert describes how to copy swap at scale times the wallet's size
+func NewAlert(alias string, swap *model.SwapData, scale float64) Alert {
+	action := Action{
+		Side:          side(swap),
+		InputMint:     swap.TokenInMint,
+		OutputMint:    swap.TokenOutMint,
+		InputAmountUI: swap.AmountInUI * scale,
+	}
+	if swap.AmountInUI > 0 {
+		action.ReferenceRate = swap.AmountOutUI / swap.AmountInUI
+	}
+	return Alert{WalletAlias: alias, SwapData: swap, RecommendedAction: action}
+}
+
+func side(swap *model.SwapData) string {
+	in, out := isQuote(swap.TokenInMint), isQuote(swap.TokenOutMint)
+	switch {
+	case in && !out:
+		return Buy
+	case out && !in:
+		return Sell
+	default:
+		return Swap
+	}
+}
+
+// isQuote reports whether tokens are priced in mint, as with SOL and
+// stablecoins
+func isQuote(mint solana.PublicKey) bool {
+	return mint.Equals(solana.SolMint) || tokenmetadata.IsStablecoin(mint)
+}
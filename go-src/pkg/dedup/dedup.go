@@ -0,0 +1,84 @@
+// Package dedup finds swaps that were submitted more than once: the same
+// signed trade sent again, e.g. by a bot retrying, which lands under a
+// different signature.
+package dedup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// Window is how close in block time two copies of a trade must land to
+// count as one resubmitted swap
+const Window = 5 * time.Second
+
+// InstructionsHash digests a transaction's top-level instructions (each
+// program ID and data, in order), which a resubmission repeats exactly
+func InstructionsHash(tx *solana.Transaction) string {
+	h := sha256.New()
+	for _, ix := range tx.Message.Instructions {
+		if programID, err := tx.Message.Program(ix.ProgramIDIndex); err == nil {
+			h.Write(programID[:])
+		}
+		h.Write(ix.Data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type key struct {
+	signer       solana.PublicKey
+	blockhash    solana.Hash
+	instructions string
+}
+
+// DeduplicateResubmissions returns swaps without their resubmissions: swaps
+// sharing a fee payer, recent blockhash and instruction data with another
+// that landed within Window. The first to land of each is kept, preferring
+// one that succeeded, and the others get IsResubmission set so callers can
+// still show them. Swaps without a recorded blockhash never match. The
+// result keeps the input order.
+func DeduplicateResubmissions(swaps []*model.SwapData) []*model.SwapData {
+	ordered := slices.Clone(swaps)
+	slices.SortStableFunc(ordered, func(a, b *model.SwapData) int {
+		if a.Slot != b.Slot {
+			if a.Slot < b.Slot {
+				return -1
+			}
+			return 1
+		}
+		return a.BlockTime.Compare(b.BlockTime)
+	})
+
+	kept := make(map[key]*model.SwapData)
+	for _, s := range ordered {
+		if s.RecentBlockhash == nil || s.InstructionsHash == "" {
+			continue
+		}
+		k := key{signer: s.Signer, blockhash: *s.RecentBlockhash, instructions: s.InstructionsHash}
+		first, ok := kept[k]
+		if !ok || s.BlockTime.Sub(first.BlockTime) > Window {
+			kept[k] = s
+			continue
+		}
+		if first.Failed && !s.Failed {
+			first.IsResubmission = true
+			kept[k] = s
+			continue
+		}
+		s.IsResubmission = true
+	}
+
+	out := make([]*model.SwapData, 0, len(swaps))
+	for _, s := range swaps {
+		if !s.IsResubmission {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+// Package format renders values for people reading a terminal rather than
+// programs reading output.
+package format
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// siSuffixes are the suffixes HumanAmount abbreviates amounts of a million
+// and more with
+var siSuffixes = []struct {
+	scale  float64
+	suffix string
+}{
+	{1e12, "T"},
+	{1e9, "B"},
+	{1e6, "M"},
+}
+
+// HumanAmount formats a token amount for display: amounts of a million and
+// more are abbreviated with a suffix (1.5M, 2B), and smaller ones are shown
+// to the token's precision, always 9 places for SOL and 6 for USDC and USDT,
+// otherwise decimals with trailing zeros trimmed. symbol is appended if set.
+func HumanAmount(amount float64, decimals uint8, symbol string) string {
+	s := HumanNumber(amount, decimals, symbol)
+	if symbol != "" {
+		s += " " + symbol
+	}
+	return s
+}
+
+// HumanNumber is HumanAmount without the symbol, for callers that align
+// the number and symbol separately
+func HumanNumber(amount float64, decimals uint8, symbol string) string {
+	for _, si := range siSuffixes {
+		if math.Abs(amount) >= si.scale {
+			return trimZeros(strconv.FormatFloat(amount/si.scale, 'f', 2, 64)) + si.suffix
+		}
+	}
+	switch strings.ToUpper(symbol) {
+	case "SOL", "WSOL":
+		return strconv.FormatFloat(amount, 'f', 9, 64)
+	case "USDC", "USDT":
+		return strconv.FormatFloat(amount, 'f', 6, 64)
+	}
+	return trimZeros(strconv.FormatFloat(amount, 'f', int(decimals), 64))
+}
+
+// trimZeros drops trailing zeros after the decimal point, and the point
+// itself if nothing is left after it
+func trimZeros(s string) string {
+	if !strings.Contains(s, ".") {
+		return s
+	}
+	return strings.TrimRight(strings.TrimRight(s, "0"), ".")
+}
@@ -0,0 +1,107 @@
+// Package governance decodes SPL Governance (Realms) votes.
+package governance
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// ProgramID is the SPL Governance deployment Realms uses
+var ProgramID = solana.MustPublicKeyFromBase58("GovER5Lthms3bLBqWub97yVrMmEogzX7xNjdXpPPCVZw")
+
+// ErrNoVote is returned when a transaction casts no vote
+var ErrNoVote = errors.New("no governance vote in transaction")
+
+// ixCastVote is the CastVote variant of the governance instruction enum
+const ixCastVote = 13
+
+// Vote is the side a vote was cast for
+type Vote string
+
+const (
+	Yes     Vote = "yes"
+	No      Vote = "no"
+	Abstain Vote = "abstain"
+	Veto    Vote = "veto"
+)
+
+// votes maps the Vote enum tag in CastVote data to a Vote
+var votes = map[byte]Vote{0: Yes, 1: No, 2: Abstain, 3: Veto}
+
+// GovernanceVote is a decoded CastVote instruction
+type GovernanceVote struct {
+	Realm      solana.PublicKey `json:"realm"`
+	Governance solana.PublicKey `json:"governance"`
+	Proposal   solana.PublicKey `json:"proposal"`
+	Voter      solana.PublicKey `json:"voter"`
+	Vote       Vote             `json:"vote"`
+
+	// Account the program records the vote in
+	VoteRecord solana.PublicKey `json:"vote_record"`
+	// Raw governing token weight, read from VoteRecord by FetchVoteWeight;
+	// the instruction itself doesn't carry it
+	VoteWeight uint64 `json:"vote_weight,omitempty"`
+}
+
+// ParseGovernanceVote returns the first CastVote instruction in a
+// transaction, or ErrNoVote
+func ParseGovernanceVote(tx *rpc.GetTransactionResult) (*GovernanceVote, error) {
+	instructions, err := txutil.Instructions(tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, ix := range instructions {
+		if !ix.ProgramID.Equals(ProgramID) || len(ix.Data) < 2 || ix.Data[0] != ixCastVote {
+			continue
+		}
+		// realm, governance, proposal, proposal owner record, voter record,
+		// governance authority, vote record, ...
+		if len(ix.Accounts) < 7 {
+			return nil, fmt.Errorf("CastVote has %d accounts, want at least 7", len(ix.Accounts))
+		}
+		vote, ok := votes[ix.Data[1]]
+		if !ok {
+			return nil, fmt.Errorf("CastVote has unknown vote type %d", ix.Data[1])
+		}
+		return &GovernanceVote{
+			Realm:      ix.Accounts[0],
+			Governance: ix.Accounts[1],
+			Proposal:   ix.Accounts[2],
+			Voter:      ix.Accounts[5],
+			Vote:       vote,
+			VoteRecord: ix.Accounts[6],
+		}, nil
+	}
+	return nil, ErrNoVote
+}
+
+// VoteRecordV2 layout: account type (1), proposal (32), governing token
+// owner (32), is relinquished (1), voter weight (u64)
+const (
+	voteRecordV2AccountType = 12
+	voteWeightOffset        = 66
+)
+
+// FetchVoteWeight fills in vote.VoteWeight from its vote record account
+func FetchVoteWeight(ctx context.Context, rpcClient *rpc.Client, vote *GovernanceVote) error {
+	account, err := rpcClient.GetAccountInfo(ctx, vote.VoteRecord)
+	if err != nil {
+		return fmt.Errorf("fetching vote record %s: %w", vote.VoteRecord, err)
+	}
+	if account.Value == nil || account.Value.Data == nil {
+		return fmt.Errorf("vote record %s is empty", vote.VoteRecord)
+	}
+	data := account.Value.Data.GetBinary()
+	if len(data) < voteWeightOffset+8 || data[0] != voteRecordV2AccountType {
+		return fmt.Errorf("vote record %s is not a V2 vote record", vote.VoteRecord)
+	}
+	vote.VoteWeight = binary.LittleEndian.Uint64(data[voteWeightOffset:])
+	return nil
+}
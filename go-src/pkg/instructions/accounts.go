@@ -0,0 +1,96 @@
+package instructions
+
+import (
+	"strconv"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// TokenAccountRentLamports is the rent-exempt minimum for a 165 byte SPL
+// token account, used when the transaction's balances don't show what the
+// new account was funded with
+const TokenAccountRentLamports = 2039280
+
+// Token program instruction discriminators that initialize a token account
+const (
+	initializeAccount  = 1
+	initializeAccount2 = 16
+	initializeAccount3 = 18
+)
+
+// AccountCreation is a token account initialized by a transaction
+type AccountCreation struct {
+	Account solana.PublicKey `json:"account"`
+	Mint    solana.PublicKey `json:"mint"`
+	// Rent the account was left holding: 0 if the transaction closed it
+	// again, as with temporary wrapped SOL accounts
+	RentLamports uint64 `json:"rent_lamports"`
+}
+
+// DetectTokenAccountCreations finds the SPL Token and Token-2022
+// InitializeAccount instructions in a transaction, top-level or inner (the
+// associated token account program creates accounts through one), and
+// what each new account cost its funder in rent
+func DetectTokenAccountCreations(tx *rpc.GetTransactionResult) ([]AccountCreation, error) {
+	all, err := txutil.Instructions(tx)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := txutil.AccountKeys(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var creations []AccountCreation
+	for _, ix := range all {
+		if !ix.ProgramID.Equals(solana.TokenProgramID) && !ix.ProgramID.Equals(solana.Token2022ProgramID) {
+			continue
+		}
+		if len(ix.Data) == 0 || len(ix.Accounts) < 2 {
+			continue
+		}
+		switch ix.Data[0] {
+		case initializeAccount, initializeAccount2, initializeAccount3:
+		default:
+			continue
+		}
+		creation := AccountCreation{Account: ix.Accounts[0], Mint: ix.Accounts[1]}
+		creation.RentLamports = rentPaid(tx, keys, creation)
+		creations = append(creations, creation)
+	}
+	return creations, nil
+}
+
+// rentPaid returns the lamports a new token account holds at the end of
+// the transaction, less any wrapped SOL it holds as its balance
+func rentPaid(tx *rpc.GetTransactionResult, keys solana.PublicKeySlice, c AccountCreation) uint64 {
+	index := -1
+	for i, key := range keys {
+		if key.Equals(c.Account) {
+			index = i
+			break
+		}
+	}
+	if tx.Meta == nil || index < 0 || index >= len(tx.Meta.PostBalances) || index >= len(tx.Meta.PreBalances) {
+		return TokenAccountRentLamports
+	}
+	// Funded before this transaction, so its rent was paid then
+	if tx.Meta.PreBalances[index] != 0 {
+		return 0
+	}
+	lamports := tx.Meta.PostBalances[index]
+	if c.Mint.Equals(solana.SolMint) {
+		for _, b := range tx.Meta.PostTokenBalances {
+			if int(b.AccountIndex) != index || b.UiTokenAmount == nil {
+				continue
+			}
+			if wrapped, err := strconv.ParseUint(b.UiTokenAmount.Amount, 10, 64); err == nil && wrapped <= lamports {
+				lamports -= wrapped
+			}
+		}
+	}
+	return lamports
+}
@@ -0,0 +1,172 @@
+// Package instructions analyses a transaction's instructions: how they
+// invoke each other, the errors they log and the token accounts they create.
+package instructions
+
+import (
+	"regexp"
+	"strconv"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// CPINode is one instruction in a transaction's call tree. The root is the
+// transaction itself, with a zero ProgramID, and its children are the
+// top-level instructions.
+type CPINode struct {
+	ProgramID solana.PublicKey `json:"program_id"`
+	// Position of a top-level instruction in the message, or of an inner
+	// instruction among those its top-level instruction invoked; -1 for the root
+	InstructionIndex int `json:"instruction_index"`
+	// Instruction data; its leading bytes are the discriminator that
+	// picks the instruction
+	Data []byte `json:"data,omitempty"`
+	// Whether the logs report the invocation failing. Only known when the
+	// logs line up with the instructions.
+	Failed   bool       `json:"failed,omitempty"`
+	Children []*CPINode `json:"children,omitempty"`
+}
+
+// invokeLog matches the runtime's log line for each program invocation,
+// which carries the invocation stack height (1 for top-level)
+var invokeLog = regexp.MustCompile(`^Program (\w+) invoke \[(\d+)\]$`)
+
+// BuildCPITree returns the transaction's call tree, or nil if it can't be
+// decoded. RPC inner instructions don't say which instruction invoked them,
+// so nesting is recovered from the invoke lines in the logs; where those
+// don't line up (logs truncated or missing) a top-level instruction's inner
+// instructions are all attached directly beneath it.
+func BuildCPITree(tx *rpc.GetTransactionResult) *CPINode {
+	ixs, err := txutil.Instructions(tx)
+	if err != nil {
+		return nil
+	}
+	var logs []string
+	if tx.Meta != nil {
+		logs = tx.Meta.LogMessages
+	}
+	heights := invokeHeights(logs)
+
+	root := &CPINode{InstructionIndex: -1}
+	for i := 0; i < len(ixs); {
+		top := ixs[i]
+		node := &CPINode{ProgramID: top.ProgramID, InstructionIndex: top.Index, Data: top.Data}
+		root.Children = append(root.Children, node)
+
+		j := i + 1
+		for j < len(ixs) && ixs[j].Index == top.Index {
+			j++
+		}
+		var invocations []invocation
+		if top.Index < len(heights) {
+			invocations = heights[top.Index]
+		}
+		attach(node, ixs[i:j], invocations)
+		i = j
+	}
+	return root
+}
+
+// invocation is one invoke log line, and whether the matching result line
+// reported failure
+type invocation struct {
+	program solana.PublicKey
+	height  int
+	failed  bool
+}
+
+// invokeHeights groups the invoke log lines by top-level instruction: each
+// group starts at a height-1 invocation
+func invokeHeights(logs []string) [][]invocation {
+	var groups [][]invocation
+	// Positions in the last group of the invocations still running
+	var running []int
+	for _, line := range logs {
+		if m := programResultLog.FindStringSubmatch(line); m != nil {
+			if n := len(running); n > 0 {
+				if m[2] != "success" {
+					group := groups[len(groups)-1]
+					group[running[n-1]].failed = true
+				}
+				running = running[:n-1]
+			}
+			continue
+		}
+		m := invokeLog.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		program, err := solana.PublicKeyFromBase58(m[1])
+		if err != nil {
+			continue
+		}
+		height, _ := strconv.Atoi(m[2])
+		if height == 1 {
+			groups = append(groups, nil)
+			running = running[:0]
+		}
+		if len(groups) > 0 {
+			last := len(groups) - 1
+			running = append(running[:min(max(height-1, 0), len(running))], len(groups[last]))
+			groups[last] = append(groups[last], invocation{program: program, height: height})
+		}
+	}
+	return groups
+}
+
+// attach builds the subtree under a top-level node from its instruction
+// group (the top-level instruction followed by its inner instructions) and
+// the matching invoke lines
+func attach(node *CPINode, group []txutil.Instruction, invocations []invocation) {
+	inner := group[1:]
+	if !matches(group, invocations) {
+		for _, ix := range inner {
+			node.Children = append(node.Children, &CPINode{ProgramID: ix.ProgramID, InstructionIndex: ix.InnerIndex, Data: ix.Data})
+		}
+		return
+	}
+	node.Failed = invocations[0].failed
+
+	// stack[h-1] is the most recent node at height h
+	stack := []*CPINode{node}
+	for k, ix := range inner {
+		height := invocations[k+1].height
+		child := &CPINode{ProgramID: ix.ProgramID, InstructionIndex: ix.InnerIndex, Data: ix.Data, Failed: invocations[k+1].failed}
+		stack = stack[:height-1]
+		parent := stack[len(stack)-1]
+		parent.Children = append(parent.Children, child)
+		stack = append(stack, child)
+	}
+}
+
+// matches reports whether the invoke lines line up one-to-one with the
+// instructions and describe a valid stack
+func matches(group []txutil.Instruction, invocations []invocation) bool {
+	if len(invocations) != len(group) {
+		return false
+	}
+	prev := 0
+	for k, inv := range invocations {
+		if !inv.program.Equals(group[k].ProgramID) || inv.height < 1 || inv.height > prev+1 || (k > 0 && inv.height < 2) {
+			return false
+		}
+		prev = inv.height
+	}
+	return true
+}
+
+// MaxDepth returns how many levels of invocations are below n. For the
+// root that is the deepest invocation stack height: 1 if no instruction
+// invoked another, 0 for a nil tree.
+func (n *CPINode) MaxDepth() int {
+	if n == nil {
+		return 0
+	}
+	depth := 0
+	for _, child := range n.Children {
+		depth = max(depth, child.MaxDepth()+1)
+	}
+	return depth
+}
@@ -0,0 +1,65 @@
+package instructions
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+
+	solana "github.com/gagliardetto/solana-go"
+)
+
+// discriminatorLen is how much instruction data edges are labeled with:
+// Anchor's 8 byte discriminator, or all of a shorter native instruction's
+const discriminatorLen = 8
+
+// Discriminator returns the leading bytes of instruction data in hex
+func Discriminator(data []byte) string {
+	return hex.EncodeToString(data[:min(len(data), discriminatorLen)])
+}
+
+// WriteDOT writes the call tree as a Graphviz digraph. Each program is one
+// node, labeled by name (falling back to its address), and each invocation
+// an edge from its caller, labeled with its position and discriminator and
+// drawn dashed red if it failed. The transaction itself is the root node.
+func WriteDOT(w io.Writer, root *CPINode, name func(program solana.PublicKey) string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph cpi {")
+	fmt.Fprintln(bw, `  node [shape=box, fontname="Helvetica"];`)
+	fmt.Fprintln(bw, `  edge [fontname="Helvetica", fontsize=10];`)
+	fmt.Fprintln(bw, `  tx [label="transaction", shape=ellipse];`)
+
+	declared := make(map[solana.PublicKey]bool)
+	var walk func(parent string, n *CPINode, path string)
+	walk = func(parent string, n *CPINode, path string) {
+		id := strconv.Quote(n.ProgramID.String())
+		if !declared[n.ProgramID] {
+			declared[n.ProgramID] = true
+			label := name(n.ProgramID)
+			if label == "" {
+				label = n.ProgramID.String()
+			}
+			fmt.Fprintf(bw, "  %s [label=%s];\n", id, strconv.Quote(label))
+		}
+		edge := path
+		if d := Discriminator(n.Data); d != "" {
+			edge += " " + d
+		}
+		style := ""
+		if n.Failed {
+			style = ", style=dashed, color=red, fontcolor=red"
+		}
+		fmt.Fprintf(bw, "  %s -> %s [label=%s%s];\n", parent, id, strconv.Quote(edge), style)
+		for i, child := range n.Children {
+			walk(id, child, path+"."+strconv.Itoa(i+1))
+		}
+	}
+	if root != nil {
+		for _, top := range root.Children {
+			walk("tx", top, "#"+strconv.Itoa(top.InstructionIndex+1))
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
@@ -0,0 +1,91 @@
+package instructions
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	solana "github.com/gagliardetto/solana-go"
+)
+
+// ProgramError is an error a program logged or failed with
+type ProgramError struct {
+	// Zero if the logs don't say which program was executing
+	ProgramID    solana.PublicKey `json:"program_id"`
+	ErrorMessage string           `json:"error_message"`
+	// Numeric error code in decimal (e.g. "6001" for an Anchor custom
+	// error), empty if the program didn't give one
+	ErrorCode string `json:"error_code,omitempty"`
+}
+
+var (
+	programResultLog = regexp.MustCompile(`^Program (\w+) (success|failed: (.*))$`)
+	customErrorLog   = regexp.MustCompile(`^custom program error: 0x([0-9a-fA-F]+)$`)
+	anchorErrorLog   = regexp.MustCompile(`^Program log: AnchorError .*Error Code: (\w+)\. Error Number: (\d+)\. Error Message: (.*?)\.?$`)
+)
+
+// ExtractProgramErrors returns the errors reported in a transaction's logs,
+// attributed to the program executing at the time. These cover both fatal
+// failures ("Program X failed: ...", "Program failed to complete: ...") and
+// errors programs log and recover from ("Program log: Error: ..."), so a
+// successful transaction can have some too.
+func ExtractProgramErrors(logs []string) []ProgramError {
+	var errs []ProgramError
+	var stack []solana.PublicKey
+	current := func() solana.PublicKey {
+		if len(stack) == 0 {
+			return solana.PublicKey{}
+		}
+		return stack[len(stack)-1]
+	}
+
+	for _, line := range logs {
+		if m := invokeLog.FindStringSubmatch(line); m != nil {
+			program, _ := solana.PublicKeyFromBase58(m[1])
+			height, _ := strconv.Atoi(m[2])
+			stack = append(stack[:min(max(height-1, 0), len(stack))], program)
+			continue
+		}
+
+		if m := programResultLog.FindStringSubmatch(line); m != nil {
+			program, err := solana.PublicKeyFromBase58(m[1])
+			if err != nil {
+				continue
+			}
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			if m[2] == "success" {
+				continue
+			}
+			pe := ProgramError{ProgramID: program, ErrorMessage: m[3]}
+			if c := customErrorLog.FindStringSubmatch(m[3]); c != nil {
+				if code, err := strconv.ParseUint(c[1], 16, 32); err == nil {
+					pe.ErrorCode = strconv.FormatUint(code, 10)
+				}
+			}
+			// Anchor logs the error before the runtime reports the failure;
+			// keep the more descriptive first report
+			if n := len(errs); n > 0 && errs[n-1].ProgramID.Equals(pe.ProgramID) && pe.ErrorCode != "" && errs[n-1].ErrorCode == pe.ErrorCode {
+				continue
+			}
+			errs = append(errs, pe)
+			continue
+		}
+
+		switch {
+		case anchorErrorLog.MatchString(line):
+			m := anchorErrorLog.FindStringSubmatch(line)
+			errs = append(errs, ProgramError{ProgramID: current(), ErrorMessage: m[1] + ": " + m[3], ErrorCode: m[2]})
+		case strings.HasPrefix(line, "Program log: Error: "):
+			errs = append(errs, ProgramError{ProgramID: current(), ErrorMessage: strings.TrimPrefix(line, "Program log: Error: ")})
+		case strings.HasPrefix(line, "Program failed to complete"):
+			msg := strings.TrimPrefix(strings.TrimPrefix(line, "Program failed to complete"), ": ")
+			if msg == "" {
+				msg = "failed to complete"
+			}
+			errs = append(errs, ProgramError{ProgramID: current(), ErrorMessage: msg})
+		}
+	}
+	return errs
+}
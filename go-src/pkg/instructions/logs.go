@@ -0,0 +1,41 @@
+package instructions
+
+import (
+	"strconv"
+
+	solana "github.com/gagliardetto/solana-go"
+)
+
+// LogLine is a program log line with the program that wrote it
+type LogLine struct {
+	// Zero for lines outside any invocation
+	ProgramID solana.PublicKey `json:"program_id"`
+	// Invocation stack height, 1 for a top-level instruction and 0 outside
+	// any invocation
+	Depth   int    `json:"depth"`
+	Message string `json:"message"`
+}
+
+// AttributeLogs pairs each log line with the program executing when it was
+// written, following the invoke and success/failed lines. The invoke and
+// result lines themselves belong to the program they name.
+func AttributeLogs(logs []string) []LogLine {
+	lines := make([]LogLine, 0, len(logs))
+	var stack []solana.PublicKey
+	for _, line := range logs {
+		if m := invokeLog.FindStringSubmatch(line); m != nil {
+			program, _ := solana.PublicKeyFromBase58(m[1])
+			height, _ := strconv.Atoi(m[2])
+			stack = append(stack[:min(max(height-1, 0), len(stack))], program)
+		}
+		l := LogLine{Depth: len(stack), Message: line}
+		if len(stack) > 0 {
+			l.ProgramID = stack[len(stack)-1]
+		}
+		if programResultLog.MatchString(line) && len(stack) > 0 {
+			stack = stack[:len(stack)-1]
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
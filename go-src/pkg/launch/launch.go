@@ -0,0 +1,42 @@
+// Package launch spots the first trades on newly created pools.
+package launch
+
+import (
+	"context"
+	"fmt"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// historyPageSize is the largest page getSignaturesForAddress will return
+const historyPageSize = 1000
+
+// IsFirstSwap reports whether no transaction involving the pool landed
+// before txSlot. Transactions in the same slot don't count as earlier, since
+// launches often create the pool and buy in one block.
+//
+// The first page asks for only 2 signatures, which settles it for a swap
+// seen as it lands: a single signature means this is the pool's only
+// transaction. Older swaps page back through the history until one lands
+// before txSlot or the history runs out.
+func IsFirstSwap(ctx context.Context, rpcClient *rpc.Client, poolAddress solana.PublicKey, txSlot uint64) (bool, error) {
+	limit := 2
+	opts := &rpc.GetSignaturesForAddressOpts{Limit: &limit, Commitment: rpc.CommitmentConfirmed}
+	for {
+		page, err := rpcClient.GetSignaturesForAddressWithOpts(ctx, poolAddress, opts)
+		if err != nil {
+			return false, fmt.Errorf("fetching signatures for pool %s: %w", poolAddress, err)
+		}
+		for _, sig := range page {
+			if sig.Slot < txSlot {
+				return false, nil
+			}
+		}
+		if len(page) < limit {
+			return true, nil
+		}
+		opts.Before = page[len(page)-1].Signature
+		limit = historyPageSize
+	}
+}
@@ -0,0 +1,121 @@
+// Package lint checks parsed swaps for values that can't be right, which
+// usually point at a parser bug.
+package lint
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// Severity says how sure a check is that the record is wrong
+type Severity string
+
+const (
+	// The record can't describe a real swap
+	SeverityError Severity = "error"
+	// The record is implausible but could be genuine
+	SeverityWarning Severity = "warning"
+)
+
+// Violation is one problem found in a record
+type Violation struct {
+	Signature solana.Signature `json:"signature"`
+	Field     string           `json:"field"`
+	Violation string           `json:"violation"`
+	Severity  Severity         `json:"severity"`
+}
+
+var (
+	// EarliestBlockTime is when mainnet-beta's first blocks were produced;
+	// anything earlier is a bad timestamp
+	EarliestBlockTime = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// RateDeviation is how many times above or below the median rate for
+	// its pair a swap's rate must be to be flagged
+	RateDeviation = 10.0
+
+	// MinPairSwaps is how many swaps a pair needs before its median rate
+	// is trusted as the market rate
+	MinPairSwaps = 3
+)
+
+type pair struct {
+	in, out solana.PublicKey
+}
+
+// Validate checks each swap on its own, and its exchange rate against the
+// median rate of the other swaps in the batch for the same pair, which
+// stands in for the market price. Failed transactions only have their
+// timestamps checked, as they have no legs.
+func Validate(swaps []*model.SwapData) []Violation {
+	var violations []Violation
+	add := func(s *model.SwapData, field, violation string, severity Severity) {
+		violations = append(violations, Violation{Signature: s.Signature, Field: field, Violation: violation, Severity: severity})
+	}
+
+	rates := make(map[pair][]float64)
+	for _, s := range swaps {
+		if r, ok := rate(s); ok {
+			p := pair{s.TokenInMint, s.TokenOutMint}
+			rates[p] = append(rates[p], r)
+		}
+	}
+	medians := make(map[pair]float64, len(rates))
+	for p, rs := range rates {
+		if len(rs) >= MinPairSwaps {
+			slices.Sort(rs)
+			medians[p] = rs[len(rs)/2]
+		}
+	}
+
+	for _, s := range swaps {
+		if s.BlockTime.IsZero() {
+			add(s, "block_time", "missing", SeverityWarning)
+		} else if s.BlockTime.Before(EarliestBlockTime) {
+			add(s, "block_time", "before "+EarliestBlockTime.Format("2006-01-02"), SeverityError)
+		} else if s.BlockTime.After(time.Now().Add(time.Hour)) {
+			add(s, "block_time", "in the future", SeverityError)
+		}
+		if s.Failed {
+			continue
+		}
+
+		if s.TokenInAmount == 0 {
+			add(s, "token_in_amount", "zero", SeverityError)
+		}
+		if s.TokenOutAmount == 0 {
+			add(s, "token_out_amount", "zero", SeverityError)
+		}
+		if s.TokenInMint.Equals(s.TokenOutMint) {
+			add(s, "token_out_mint", "same as token_in_mint, not a swap", SeverityError)
+		}
+		if s.FeeLamports == 0 {
+			add(s, "fee_lamports", "zero, every transaction pays a base fee", SeverityWarning)
+		} else if s.PriorityFeeLamports > s.FeeLamports {
+			add(s, "priority_fee_lamports", "more than the total fee", SeverityError)
+		}
+
+		if r, ok := rate(s); ok {
+			if median, ok := medians[pair{s.TokenInMint, s.TokenOutMint}]; ok && median > 0 {
+				if deviation := r / median; deviation > RateDeviation || deviation < 1/RateDeviation {
+					add(s, "amount_out_ui", "rate far from the pair's median rate", SeverityWarning)
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// rate is how many output tokens the swap got per input token
+func rate(s *model.SwapData) (float64, bool) {
+	if s.Failed || s.AmountInUI <= 0 || s.AmountOutUI <= 0 {
+		return 0, false
+	}
+	r := s.AmountOutUI / s.AmountInUI
+	return r, !math.IsInf(r, 0) && !math.IsNaN(r)
+}
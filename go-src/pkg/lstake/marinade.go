@@ -0,0 +1,122 @@
+// Package lstake decodes liquid staking protocols' deposits, withdrawals
+// and LST swaps.
+package lstake
+
+import (
+	"encoding/binary"
+	"errors"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// Marinade's liquid staking program and its LST
+var (
+	MarinadeProgramID = solana.MustPublicKeyFromBase58("MarBmsSgKXdrN1egZf5sqe1TMai9K1rChYNDJgjq7aD")
+	MSOLMint          = solana.MustPublicKeyFromBase58("mSoLzYCxHdYgdzU16g5QSh3i5K3z3KZK7ytfqcJm7So")
+)
+
+// ErrNoLiquidStake is returned when a transaction has no liquid staking
+// instruction
+var ErrNoLiquidStake = errors.New("no liquid staking instruction in transaction")
+
+// EventType is the Marinade instruction an event came from
+type EventType string
+
+const (
+	Deposit             EventType = "deposit"
+	DepositStakeAccount EventType = "deposit_stake_account"
+	LiquidUnstake       EventType = "liquid_unstake"
+	ClaimTicket         EventType = "claim_ticket"
+)
+
+// marinadeInstruction is where an instruction's amounts are found
+type marinadeInstruction struct {
+	eventType EventType
+	// Account receiving mSOL, or sending it for LiquidUnstake
+	msolAccount int
+	// Account SOL comes from (Deposit, DepositStakeAccount) or goes to
+	solAccount int
+}
+
+// marinadeInstructions maps instruction discriminators to their layouts.
+// ClaimTicket is the program's claim instruction.
+var marinadeInstructions = map[[8]byte]marinadeInstruction{
+	// state, msol mint, liq pool sol leg, liq pool msol leg, msol leg
+	// authority, reserve, transfer from, mint to, ...
+	[8]byte(txutil.AnchorDiscriminator("deposit")): {Deposit, 7, 6},
+	// state, validator list, stake list, stake account, stake authority,
+	// duplication flag, rent payer, msol mint, mint to, ...
+	[8]byte(txutil.AnchorDiscriminator("deposit_stake_account")): {DepositStakeAccount, 8, 3},
+	// state, msol mint, liq pool sol leg, liq pool msol leg, treasury msol
+	// account, get msol from, get msol from authority, transfer sol to, ...
+	[8]byte(txutil.AnchorDiscriminator("liquid_unstake")): {LiquidUnstake, 5, 7},
+	// state, reserve, ticket account, transfer sol to, ...
+	[8]byte(txutil.AnchorDiscriminator("claim")): {ClaimTicket, -1, 3},
+}
+
+// LiquidStakeEvent is one decoded liquid staking instruction
+type LiquidStakeEvent struct {
+	Type             EventType `json:"type"`
+	InstructionIndex int       `json:"instruction_index"`
+
+	// Lamports deposited or received
+	SOLAmount uint64 `json:"sol_amount"`
+	// Raw mSOL minted or burned; zero for ClaimTicket, which pays out SOL
+	// for mSOL unstaked earlier
+	MSOLAmount uint64 `json:"msol_amount"`
+	// SOL per mSOL implied by the amounts, including any unstake fee
+	MSOLPrice float64 `json:"msol_price,omitempty"`
+}
+
+// ParseMarinade returns the first Deposit, DepositStakeAccount,
+// LiquidUnstake or ClaimTicket instruction in a transaction, or
+// ErrNoLiquidStake. Amounts come from the balance changes of the accounts
+// involved, since only some instructions carry one.
+func ParseMarinade(tx *rpc.GetTransactionResult) (*LiquidStakeEvent, error) {
+	instructions, err := txutil.Instructions(tx)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := txutil.AccountKeys(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ix := range instructions {
+		if !ix.ProgramID.Equals(MarinadeProgramID) || len(ix.Data) < 8 {
+			continue
+		}
+		layout, ok := marinadeInstructions[[8]byte(ix.Data[:8])]
+		if !ok || layout.solAccount >= len(ix.Accounts) || layout.msolAccount >= len(ix.Accounts) {
+			continue
+		}
+
+		event := &LiquidStakeEvent{Type: layout.eventType, InstructionIndex: ix.Index}
+		sol := txutil.LamportChange(tx, keys, ix.Accounts[layout.solAccount])
+		switch layout.eventType {
+		case Deposit:
+			// The instruction carries the lamports deposited
+			if len(ix.Data) >= 16 {
+				event.SOLAmount = binary.LittleEndian.Uint64(ix.Data[8:16])
+			}
+		case DepositStakeAccount:
+			// The whole stake account is handed over; its lamports stay put
+			event.SOLAmount = txutil.Lamports(tx, keys, ix.Accounts[layout.solAccount], true)
+		default:
+			event.SOLAmount = uint64(max(sol, 0))
+		}
+		if layout.msolAccount >= 0 {
+			if msol, ok := txutil.TokenChange(tx, keys, ix.Accounts[layout.msolAccount]); ok && msol.Mint.Equals(MSOLMint) {
+				event.MSOLAmount = uint64(max(msol.Change, -msol.Change))
+			}
+		}
+		if event.SOLAmount > 0 && event.MSOLAmount > 0 {
+			event.MSOLPrice = float64(event.SOLAmount) / float64(event.MSOLAmount)
+		}
+		return event, nil
+	}
+	return nil, ErrNoLiquidStake
+}
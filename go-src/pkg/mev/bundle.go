@@ -0,0 +1,154 @@
+package mev
+
+import (
+	"cmp"
+	"encoding/binary"
+	"slices"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// JitoTipAccounts are the accounts Jito's block engine accepts bundle tips in
+var JitoTipAccounts = []solana.PublicKey{
+	solana.MustPublicKeyFromBase58("96gYZGLnJYVFmbjzopPSU6QiEV5fGqZNyN9nmNhvrZU5"),
+	solana.MustPublicKeyFromBase58("HFqU5x63VTqvQss8hp11i4wVV8bD44PvwucfZ2bU7gRe"),
+	solana.MustPublicKeyFromBase58("Cw8CFyM9FkoMi7K7Crf6HNQqf4uEMzpKw6QNghXLvLkY"),
+	solana.MustPublicKeyFromBase58("ADaUMid9yfUytqMBgopwjb2DTLSokTSzL1zt6iGPaS49"),
+	solana.MustPublicKeyFromBase58("DfXygSm4jCyNCybVYYK6DwvWqjKee8pbDmJGcLWNDXjh"),
+	solana.MustPublicKeyFromBase58("ADuUkR4vqLUMWXxW9gh6D6L8pMSawimctcNZ5pGwDcEt"),
+	solana.MustPublicKeyFromBase58("DttWaMuVvTiduZRnguLF7jNxTgiMBZ1hyAumKUiL2KRL"),
+	solana.MustPublicKeyFromBase58("3AVi9Tg9Uo68tJfuvoKvqKNWKkC5wPdSSdeBnizKZ6jT"),
+}
+
+// maxBundleSize is the most transactions Jito lets a bundle hold
+const maxBundleSize = 5
+
+// systemTransfer is the System program's Transfer instruction index
+const systemTransfer = 2
+
+// JitoTip returns the lamports a transaction sent to Jito tip accounts with
+// System program transfers, top-level or inner
+func JitoTip(tx *rpc.GetTransactionResult) (uint64, error) {
+	all, err := txutil.Instructions(tx)
+	if err != nil {
+		return 0, err
+	}
+	var tip uint64
+	for _, ix := range all {
+		// Transfer is a u32 instruction index then a u64 amount, from
+		// accounts[0] to accounts[1]
+		if !ix.ProgramID.Equals(solana.SystemProgramID) || len(ix.Data) < 12 || len(ix.Accounts) < 2 {
+			continue
+		}
+		if binary.LittleEndian.Uint32(ix.Data) != systemTransfer || !slices.Contains(JitoTipAccounts, ix.Accounts[1]) {
+			continue
+		}
+		tip += binary.LittleEndian.Uint64(ix.Data[4:12])
+	}
+	return tip, nil
+}
+
+// BundleAnalysis compares what a bundle's searcher made on its swaps with
+// what it paid Jito to land them
+type BundleAnalysis struct {
+	Slot       uint64             `json:"slot"`
+	Signatures []solana.Signature `json:"signatures"`
+	// Wallets that paid the tip, whose swaps are the bundle's profit
+	Searchers []solana.PublicKey `json:"searchers"`
+
+	BundleTip    uint64  `json:"bundle_tip_lamports"`
+	BundleTipUSD float64 `json:"bundle_tip_usd"`
+	// The searchers' swaps' output value less input value, in USD, over
+	// the swaps with both legs priced
+	EstimatedProfit float64 `json:"estimated_profit"`
+	// EstimatedProfit / BundleTipUSD; 0 if SOL couldn't be priced
+	ProfitRatio float64 `json:"profit_ratio"`
+	// The profit didn't cover the tip, which is often a mistake
+	Unprofitable bool `json:"unprofitable"`
+}
+
+// AnalyzeBundleProfitability finds the Jito bundles among swaps and how
+// profitable each was. swaps must be priced and carry BlockIndex, as when
+// parsed from getBlock; others are ignored. Bundles aren't marked on-chain,
+// so a bundle is taken to be a run of swaps at consecutive positions in one
+// slot ending in one that tips, at most maxBundleSize long. Victims' swaps
+// in a sandwich are part of the bundle but not its profit, which only
+// counts the swaps of the wallets that paid the tip. A tip paid from a
+// transaction without a swap isn't seen. SOL is valued at the median rate
+// it traded at in swaps.
+func AnalyzeBundleProfitability(swaps []*model.SwapData) []*BundleAnalysis {
+	var placed []*model.SwapData
+	for _, s := range swaps {
+		if s.BlockIndex != nil && !s.Failed {
+			placed = append(placed, s)
+		}
+	}
+	slices.SortStableFunc(placed, func(a, b *model.SwapData) int {
+		return cmp.Or(cmp.Compare(a.Slot, b.Slot), cmp.Compare(*a.BlockIndex, *b.BlockIndex))
+	})
+	solPrice := solPriceUSD(swaps)
+
+	var analyses []*BundleAnalysis
+	start := 0
+	for i, s := range placed {
+		// A transaction's swaps share its position
+		consecutive := i > 0 && placed[i-1].Slot == s.Slot && *s.BlockIndex-*placed[i-1].BlockIndex <= 1
+		if !consecutive {
+			start = i
+		}
+		if s.JitoTipLamports == 0 {
+			continue
+		}
+		from := max(start, i+1-maxBundleSize)
+		analyses = append(analyses, analyzeBundle(placed[from:i+1], solPrice))
+		start = i + 1
+	}
+	return analyses
+}
+
+func analyzeBundle(bundle []*model.SwapData, solPrice float64) *BundleAnalysis {
+	a := &BundleAnalysis{Slot: bundle[0].Slot}
+	for _, s := range bundle {
+		a.Signatures = append(a.Signatures, s.Signature)
+		if s.JitoTipLamports > 0 {
+			a.BundleTip += s.JitoTipLamports
+			if !slices.Contains(a.Searchers, s.Signer) {
+				a.Searchers = append(a.Searchers, s.Signer)
+			}
+		}
+	}
+	for _, s := range bundle {
+		// A swap with an unpriced leg has no meaningful profit
+		if slices.Contains(a.Searchers, s.Signer) && s.ValueInUSD > 0 && s.ValueOutUSD > 0 {
+			a.EstimatedProfit += s.ValueOutUSD - s.ValueInUSD
+		}
+	}
+	a.BundleTipUSD = float64(a.BundleTip) / float64(solana.LAMPORTS_PER_SOL) * solPrice
+	if a.BundleTipUSD > 0 {
+		a.ProfitRatio = a.EstimatedProfit / a.BundleTipUSD
+		a.Unprofitable = a.ProfitRatio < 1
+	}
+	return a
+}
+
+// solPriceUSD is the median USD price of SOL in the priced swaps, or 0
+func solPriceUSD(swaps []*model.SwapData) float64 {
+	var prices []float64
+	for _, s := range swaps {
+		switch {
+		case s.TokenInMint.Equals(solana.SolMint) && s.ValueInUSD > 0 && s.AmountInUI > 0:
+			prices = append(prices, s.ValueInUSD/s.AmountInUI)
+		case s.TokenOutMint.Equals(solana.SolMint) && s.ValueOutUSD > 0 && s.AmountOutUI > 0:
+			prices = append(prices, s.ValueOutUSD/s.AmountOutUI)
+		}
+	}
+	if len(prices) == 0 {
+		return 0
+	}
+	slices.Sort(prices)
+	return prices[len(prices)/2]
+}
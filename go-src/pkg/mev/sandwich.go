@@ -0,0 +1,41 @@
+// Package mev detects extractive trading patterns in parsed swaps.
+package mev
+
+import (
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// Sandwich is a victim swap with the attacker's swaps either side of it
+type Sandwich struct {
+	Frontrun *model.SwapData `json:"frontrun"`
+	Victim   *model.SwapData `json:"victim"`
+	Backrun  *model.SwapData `json:"backrun"`
+}
+
+// FindSandwich reports whether block[victim] was sandwiched: an earlier swap
+// in the same direction and a later swap in the reverse direction, both by
+// one signer other than the victim's. block must be the swaps of a single
+// slot in block order.
+func FindSandwich(block []*model.SwapData, victim int) (*Sandwich, bool) {
+	v := block[victim]
+	for _, front := range block[:victim] {
+		if front.Signer.Equals(v.Signer) || !sameDirection(front, v) {
+			continue
+		}
+		for _, back := range block[victim+1:] {
+			if back.Signer.Equals(front.Signer) && sameDirection(back, reversed(v)) {
+				return &Sandwich{Frontrun: front, Victim: v, Backrun: back}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func sameDirection(a, b *model.SwapData) bool {
+	return a.TokenInMint.Equals(b.TokenInMint) && a.TokenOutMint.Equals(b.TokenOutMint)
+}
+
+// reversed returns the pair of s swapped the other way, for direction checks
+func reversed(s *model.SwapData) *model.SwapData {
+	return &model.SwapData{TokenInMint: s.TokenOutMint, TokenOutMint: s.TokenInMint}
+}
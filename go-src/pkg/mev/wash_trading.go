@@ -0,0 +1,193 @@
+package mev
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// Wash trading thresholds: a wallet swapping a token back within
+// washMaxSlotGap slots, for an amount within washAmountTolerance of what it
+// just received, is taken to be trading with itself
+const (
+	washMaxSlotGap      = 5
+	washAmountTolerance = 0.01
+)
+
+// WashTradingAlert is a run of back-to-back round trips by one wallet on
+// one pair, directly or through wallets linked to it
+type WashTradingAlert struct {
+	Wallet solana.PublicKey `json:"wallet"`
+	// Linked wallets that signed some of the run's swaps, for alerts from
+	// DetectLinkedWashTrading
+	Intermediaries []solana.PublicKey `json:"intermediaries,omitempty"`
+	// The pair, ordered as traded by the first swap of the run
+	TokenA solana.PublicKey `json:"token_a"`
+	TokenB solana.PublicKey `json:"token_b"`
+
+	Signatures []solana.Signature `json:"signatures"`
+	FirstSlot  uint64             `json:"first_slot"`
+	LastSlot   uint64             `json:"last_slot"`
+
+	// USD volume of the run's swaps, all of which is artificial. Zero for
+	// swaps that weren't priced.
+	InflatedVolumeUSD float64 `json:"inflated_volume_usd"`
+}
+
+// washKey groups swaps by wallet and unordered pair
+type washKey struct {
+	wallet solana.PublicKey
+	lo, hi solana.PublicKey
+}
+
+// WalletLinks groups wallets that are taken to be one trader, such as a
+// fee payer and the wallets whose transactions it pays for. The zero
+// value links nothing.
+type WalletLinks struct {
+	parent map[solana.PublicKey]solana.PublicKey
+}
+
+// Link puts a and b, and every wallet already linked to either, in one group
+func (l *WalletLinks) Link(a, b solana.PublicKey) {
+	if l.parent == nil {
+		l.parent = make(map[solana.PublicKey]solana.PublicKey)
+	}
+	ra, rb := l.Owner(a), l.Owner(b)
+	if ra.Equals(rb) {
+		return
+	}
+	// The lower key leads, so a group's owner doesn't depend on link order
+	if rb.String() < ra.String() {
+		ra, rb = rb, ra
+	}
+	l.parent[rb] = ra
+}
+
+// Owner returns the wallet leading w's group, w itself if it isn't linked
+func (l *WalletLinks) Owner(w solana.PublicKey) solana.PublicKey {
+	for {
+		p, ok := l.parent[w]
+		if !ok {
+			return w
+		}
+		w = p
+	}
+}
+
+// LinksFromResults links the wallets that act together in results: each
+// swap's signer with its transaction's fee payer and other signers, and a
+// Squads vault with its multisig
+func LinksFromResults(results []*model.Result) *WalletLinks {
+	links := &WalletLinks{}
+	for _, r := range results {
+		tx := r.TransactionData
+		if tx == nil {
+			continue
+		}
+		for _, signer := range tx.AllSigners {
+			links.Link(tx.Signer, signer)
+		}
+		for _, s := range r.AllSwaps() {
+			links.Link(tx.Signer, s.Signer)
+		}
+		if tx.SquadsVaultAddress != nil && tx.MultisigPDA != nil {
+			links.Link(*tx.MultisigPDA, *tx.SquadsVaultAddress)
+		}
+	}
+	return links
+}
+
+// DetectWashTrading flags wallets that swap a token pair back and forth:
+// consecutive swaps by the same wallet on the same pair, in opposite
+// directions, within washMaxSlotGap slots of each other, where the second
+// sends back within washAmountTolerance of what the first received.
+// Consecutive round trips are reported as one alert.
+func DetectWashTrading(swaps []*model.SwapData) []*WashTradingAlert {
+	return DetectLinkedWashTrading(swaps, &WalletLinks{})
+}
+
+// DetectLinkedWashTrading is DetectWashTrading with each group of linked
+// wallets taken as one, so a wallet trading with itself through
+// intermediaries is caught too. A round trip between two different
+// wallets of a group must land in one block, where it can't be chance
+// timing; the alert is on the group's owner.
+func DetectLinkedWashTrading(swaps []*model.SwapData, links *WalletLinks) []*WashTradingAlert {
+	groups := make(map[washKey][]*model.SwapData)
+	for _, s := range swaps {
+		if s.Failed {
+			continue
+		}
+		lo, hi := s.TokenInMint, s.TokenOutMint
+		if lo.String() > hi.String() {
+			lo, hi = hi, lo
+		}
+		key := washKey{wallet: links.Owner(s.Signer), lo: lo, hi: hi}
+		groups[key] = append(groups[key], s)
+	}
+
+	var alerts []*WashTradingAlert
+	for key, group := range groups {
+		slices.SortStableFunc(group, func(a, b *model.SwapData) int { return cmp.Compare(a.Slot, b.Slot) })
+
+		var current *WashTradingAlert
+		for i := 1; i < len(group); i++ {
+			prev, s := group[i-1], group[i]
+			if !roundTrip(prev, s) {
+				current = nil
+				continue
+			}
+			if current == nil {
+				current = &WashTradingAlert{
+					Wallet:            key.wallet,
+					TokenA:            prev.TokenInMint,
+					TokenB:            prev.TokenOutMint,
+					Signatures:        []solana.Signature{prev.Signature},
+					FirstSlot:         prev.Slot,
+					InflatedVolumeUSD: prev.VolumeUSD,
+				}
+				current.addIntermediary(prev.Signer)
+				alerts = append(alerts, current)
+			}
+			current.addIntermediary(s.Signer)
+			current.Signatures = append(current.Signatures, s.Signature)
+			current.LastSlot = s.Slot
+			current.InflatedVolumeUSD += s.VolumeUSD
+		}
+	}
+
+	slices.SortFunc(alerts, func(a, b *WashTradingAlert) int {
+		return cmp.Or(
+			cmp.Compare(b.InflatedVolumeUSD, a.InflatedVolumeUSD),
+			cmp.Compare(a.FirstSlot, b.FirstSlot),
+			cmp.Compare(a.Wallet.String(), b.Wallet.String()),
+		)
+	})
+	return alerts
+}
+
+// addIntermediary records signer if it isn't the alert's own wallet
+func (a *WashTradingAlert) addIntermediary(signer solana.PublicKey) {
+	if !signer.Equals(a.Wallet) && !slices.Contains(a.Intermediaries, signer) {
+		a.Intermediaries = append(a.Intermediaries, signer)
+	}
+}
+
+// roundTrip reports whether b undoes a: the reverse direction, soon after
+// (in the same block if another wallet signed it), sending back about what
+// a received
+func roundTrip(a, b *model.SwapData) bool {
+	if !a.TokenInMint.Equals(b.TokenOutMint) || !a.TokenOutMint.Equals(b.TokenInMint) {
+		return false
+	}
+	if b.Slot-a.Slot > washMaxSlotGap || a.AmountOutUI <= 0 {
+		return false
+	}
+	if !a.Signer.Equals(b.Signer) && a.Slot != b.Slot {
+		return false
+	}
+	return math.Abs(b.AmountInUI-a.AmountOutUI)/a.AmountOutUI <= washAmountTolerance
+}
@@ -0,0 +1,234 @@
+// Package model defines the records getswaps emits, and that its report and
+// file commands read back in.
+package model
+
+import (
+	"math"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/governance"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/instructions"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/lstake"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/stake"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// SwapData is the normalized view of a single swap
+type SwapData struct {
+	Signature solana.Signature `json:"signature"`
+	Slot      uint64           `json:"slot"`
+	BlockTime time.Time        `json:"block_time"`
+
+	// Position of the swap among the independent swaps in its transaction,
+	// from 0. A multi-hop route through several pools is one swap.
+	HopIndex int `json:"hop_index"`
+	// Position of the transaction in its block, for swaps parsed from a
+	// getBlock response
+	BlockIndex *int `json:"block_index,omitempty"`
+
+	// Protocol detected from the registry, empty if the program is unknown
+	Dex        string            `json:"dex"`
+	DexVersion string            `json:"dex_version,omitempty"`
+	DexType    string            `json:"dex_type,omitempty"`
+	ProgramID  *solana.PublicKey `json:"program_id,omitempty"`
+
+	// Set when something other than the built-in parser produced the
+	// swap, e.g. "plugin:mydex"
+	ParserSource string `json:"parser_source,omitempty"`
+
+	// Set for swaps between liquid staking tokens (or SOL and one), with
+	// the stake pool programs that handled it
+	IsLSTSwap   bool   `json:"is_lst_swap,omitempty"`
+	LSTProtocol string `json:"lst_protocol,omitempty"`
+
+	// Set for swaps that filled an order book order: how the order was
+	// placed ("take" for an OpenBook V2 PlaceTakeOrder) and the most it
+	// allowed to trade, in the market's lots
+	OrderType    string `json:"order_type,omitempty"`
+	MaxBaseLots  int64  `json:"max_base_lots,omitempty"`
+	MaxQuoteLots int64  `json:"max_quote_lots,omitempty"`
+
+	Signer solana.PublicKey `json:"signer"`
+
+	// Pool the swap traded against, for the AMMs and CLMMs whose swap
+	// instructions are recognised
+	PoolAddress *solana.PublicKey `json:"pool_address,omitempty"`
+
+	TokenInMint     solana.PublicKey `json:"token_in_mint"`
+	TokenInAmount   uint64           `json:"token_in_amount"`
+	TokenInDecimals uint8            `json:"token_in_decimals"`
+	AmountInUI      float64          `json:"amount_in_ui"`
+
+	TokenOutMint     solana.PublicKey `json:"token_out_mint"`
+	TokenOutAmount   uint64           `json:"token_out_amount"`
+	TokenOutDecimals uint8            `json:"token_out_decimals"`
+	AmountOutUI      float64          `json:"amount_out_ui"`
+
+	FeeLamports         uint64 `json:"fee_lamports"`
+	PriorityFeeLamports uint64 `json:"priority_fee_lamports"`
+
+	// Rent the transaction put into token accounts it created, e.g. the
+	// signer's account for a token bought for the first time. Unlike the fee
+	// it comes back if the account is closed.
+	TokenAccountRentLamports uint64 `json:"token_account_rent_lamports,omitempty"`
+
+	// SOL the transaction tipped Jito to land in a bundle
+	JitoTipLamports uint64 `json:"jito_tip_lamports,omitempty"`
+
+	// Failed transactions carry no swap legs; they are only emitted where
+	// the landing rate matters, e.g. fee analysis
+	Failed bool `json:"failed,omitempty"`
+
+	// Set by price enrichment. Each leg is 0 if its token couldn't be
+	// priced; VolumeUSD is whichever leg was priced, preferring the input.
+	ValueInUSD  float64 `json:"value_in_usd,omitempty"`
+	ValueOutUSD float64 `json:"value_out_usd,omitempty"`
+	VolumeUSD   float64 `json:"volume_usd,omitempty"`
+
+	// Set with --fetch-pool-state: the pool's reserves valued in USD
+	PoolLiquidityAtSwapUSD float64 `json:"pool_liquidity_at_swap_usd,omitempty"`
+
+	// Each leg's Pyth USD price when the swap landed. Feeds only hold their
+	// latest update, so these are set for recent swaps only and 0 for tokens
+	// without a feed; --no-oracle turns them off.
+	OraclePriceInAtSwap  float64 `json:"oracle_price_in_at_swap,omitempty"`
+	OraclePriceOutAtSwap float64 `json:"oracle_price_out_at_swap,omitempty"`
+
+	// For Pump.fun bonding curve trades, the token's market cap before and
+	// after, from the curve's reserves
+	PreSwapMarketCapSOL  float64 `json:"pre_swap_market_cap_sol,omitempty"`
+	PostSwapMarketCapSOL float64 `json:"post_swap_market_cap_sol,omitempty"`
+
+	// How far the swap moved the price of the token bought, in basis
+	// points: always set for Pump.fun curve trades, and with
+	// --fetch-pool-state for the pools it can read
+	MarketImpactBps int `json:"market_impact_bps,omitempty"`
+
+	// Set with --detect-launches when no earlier transaction touched the pool
+	IsFirstPoolSwap bool `json:"is_first_pool_swap,omitempty"`
+
+	// Set with --mint-age: when each leg's mint was created. Left unset for
+	// the built-in list's tokens and others too established to date.
+	TokenInMintCreatedAt  *time.Time `json:"token_in_mint_created_at,omitempty"`
+	TokenOutMintCreatedAt *time.Time `json:"token_out_mint_created_at,omitempty"`
+
+	// The transaction's recent blockhash and a digest of its instructions,
+	// which identify the same trade sent again under another signature
+	RecentBlockhash  *solana.Hash `json:"recent_blockhash,omitempty"`
+	InstructionsHash string       `json:"instructions_hash,omitempty"`
+	// Set by dedupe --resubmissions on every copy of a resubmitted swap but
+	// the first to land
+	IsResubmission bool `json:"is_resubmission,omitempty"`
+}
+
+// TransactionData holds transaction-level details that aren't specific to the swap
+type TransactionData struct {
+	Signature solana.Signature `json:"signature"`
+	Slot      uint64           `json:"slot"`
+	BlockTime time.Time        `json:"block_time"`
+
+	// Fee payer
+	Signer solana.PublicKey `json:"signer"`
+
+	// Every account that signed, fee payer first
+	AllSigners []solana.PublicKey `json:"all_signers"`
+
+	// Squads multisig the transaction acts on, if any
+	MultisigPDA *solana.PublicKey `json:"multisig_pda,omitempty"`
+	// For a Squads vault transaction execution, the vault the swap ran as
+	// and the multisig's index for the vault transaction
+	SquadsVaultAddress     *solana.PublicKey `json:"squads_vault_address,omitempty"`
+	SquadsTransactionIndex uint64            `json:"squads_transaction_index,omitempty"`
+
+	FeeLamports          uint64 `json:"fee_lamports"`
+	PriorityFeeLamports  uint64 `json:"priority_fee_lamports"`
+	ComputeUnitsConsumed uint64 `json:"compute_units_consumed"`
+
+	// How long the getTransaction request took; 0 for simulated transactions
+	FetchLatencyMs int64 `json:"fetch_latency_ms"`
+
+	// Deepest cross-program invocation stack height; 1 if no instruction
+	// invoked another program
+	MaxCPIDepth int `json:"max_cpi_depth"`
+
+	// Errors found in the program logs, including ones programs recovered from
+	ProgramErrors []instructions.ProgramError `json:"program_errors,omitempty"`
+
+	// Per-instruction output of the solanaswap-go parser
+	Instructions any `json:"instructions"`
+
+	// Stake program activity in the same transaction
+	StakeEvents []stake.StakeEvent `json:"stake_events,omitempty"`
+
+	// Realms votes cast in the same transaction
+	GovernanceEvents []*governance.GovernanceVote `json:"governance_events,omitempty"`
+
+	// Marinade deposits and unstakes, which often sit alongside swaps in
+	// MEV bundles
+	LiquidStakeEvents []*lstake.LiquidStakeEvent `json:"liquid_stake_events,omitempty"`
+
+	// Every writable account's SOL and token balance changes, with
+	// --full-account-changes
+	WritableAccountChanges []txutil.AccountChange `json:"writable_account_changes,omitempty"`
+}
+
+// BaseFeeLamportsPerSignature is the fixed fee charged for each signature;
+// anything a transaction pays above this is priority fee
+const BaseFeeLamportsPerSignature = 5000
+
+// Result is one record of getswaps output
+type Result struct {
+	// Cluster the transaction was fetched from, e.g. mainnet-beta
+	Network string `json:"network,omitempty"`
+
+	SwapData        *SwapData        `json:"swap_data"`
+	TransactionData *TransactionData `json:"transaction_data"`
+
+	// Every swap in the transaction, SwapData first, when it made more than
+	// one independent swap
+	Swaps []*SwapData `json:"swaps,omitempty"`
+
+	// The getTransaction response the result was parsed from, kept for
+	// --store-raw. Not part of the output.
+	RawTransaction *rpc.GetTransactionResult `json:"-"`
+}
+
+// AllSwaps returns every swap in the transaction: Swaps, or SwapData alone
+// if it made just the one, or nil for a record without a swap, such as an
+// error record
+func (r *Result) AllSwaps() []*SwapData {
+	if len(r.Swaps) > 0 {
+		return r.Swaps
+	}
+	if r.SwapData == nil {
+		return nil
+	}
+	return []*SwapData{r.SwapData}
+}
+
+// ForSwap returns r with s, one of its swaps, as its only swap, for
+// commands that pick swaps out of transactions
+func (r *Result) ForSwap(s *SwapData) *Result {
+	only := *r
+	only.SwapData, only.Swaps = s, nil
+	return &only
+}
+
+// UIAmount converts a raw token amount into whole tokens
+func UIAmount(amount uint64, decimals uint8) float64 {
+	return float64(amount) / math.Pow10(int(decimals))
+}
+
+// TotalCostLamports is what the transaction cost beyond the swap itself:
+// the fee plus rent for new token accounts
+func (s *SwapData) TotalCostLamports() uint64 {
+	return s.FeeLamports + s.TokenAccountRentLamports
+}
+
+// FeeSOL returns the transaction fee in SOL
+func (s *SwapData) FeeSOL() float64 {
+	return float64(s.FeeLamports) / float64(solana.LAMPORTS_PER_SOL)
+}
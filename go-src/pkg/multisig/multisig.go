@@ -0,0 +1,260 @@
+// Package multisig recognises transactions run through Squads multisigs and
+// unwraps the vault instructions they execute.
+package multisig
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/instructions"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// Squads program deployments
+var (
+	SquadsV4ProgramID = solana.MustPublicKeyFromBase58("SQDS4ep65T869zMMBKyuUq6aD6EgTu8psMjkvj52pCf")
+	SquadsV3ProgramID = solana.MustPublicKeyFromBase58("SMPLecH534NA9acpos4G6x7uf3LWbCAwZQE9e8ZekMu")
+)
+
+// ErrNoExecution is returned when a transaction executes no vault transaction
+var ErrNoExecution = errors.New("no Squads vault transaction execution in transaction")
+
+var (
+	// multisigCreateV2 is the one Squads v4 instruction whose first account
+	// isn't the multisig; there it is the third
+	multisigCreateV2 = txutil.AnchorDiscriminator("multisig_create_v2")
+	// vaultTransactionExecute is Squads v4's ExecuteTransaction
+	vaultTransactionExecute = txutil.AnchorDiscriminator("vault_transaction_execute")
+)
+
+// Account positions in vault_transaction_execute; the vault transaction's
+// own accounts follow
+const (
+	executeMultisigAccount    = 0
+	executeTransactionAccount = 2
+	executeMemberAccount      = 3
+	executeFixedAccounts      = 4
+)
+
+// vaultTransactionIndexOffset is where the index sits in a VaultTransaction
+// account, after the discriminator, multisig and creator
+const vaultTransactionIndexOffset = 8 + 32 + 32
+
+// FindMultisig returns the Squads multisig account, a PDA, that the first
+// Squads instruction in the transaction acts on
+func FindMultisig(tx *rpc.GetTransactionResult) (solana.PublicKey, bool) {
+	ixs, err := txutil.Instructions(tx)
+	if err != nil {
+		return solana.PublicKey{}, false
+	}
+	for _, ix := range ixs {
+		if !ix.ProgramID.Equals(SquadsV4ProgramID) && !ix.ProgramID.Equals(SquadsV3ProgramID) {
+			continue
+		}
+		index := 0
+		if ix.ProgramID.Equals(SquadsV4ProgramID) && bytes.HasPrefix(ix.Data, multisigCreateV2) {
+			index = 2
+		}
+		if index < len(ix.Accounts) {
+			return ix.Accounts[index], true
+		}
+	}
+	return solana.PublicKey{}, false
+}
+
+// Execution is a top-level Squads v4 instruction executing an approved
+// vault transaction
+type Execution struct {
+	Multisig solana.PublicKey
+	// Vault the inner instructions run as
+	Vault solana.PublicKey
+	// VaultTransaction account holding the executed message
+	Transaction solana.PublicKey
+	// Member that executed it
+	Member solana.PublicKey
+
+	// Position of the execute instruction in the message
+	InstructionIndex int
+	// The multisig's sequence number for the vault transaction, read from
+	// Transaction by FetchTransactionIndex; the instruction doesn't carry it
+	TransactionIndex uint64
+}
+
+// FindExecution returns the first top-level vault transaction execution by
+// a member who signed the transaction, or ErrNoExecution
+func FindExecution(tx *rpc.GetTransactionResult) (*Execution, error) {
+	ixs, err := txutil.Instructions(tx)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := txutil.Decode(tx)
+	if err != nil {
+		return nil, err
+	}
+	signers := decoded.Message.Signers()
+
+	for _, ix := range ixs {
+		if ix.InnerIndex != -1 || !ix.ProgramID.Equals(SquadsV4ProgramID) || !bytes.HasPrefix(ix.Data, vaultTransactionExecute) {
+			continue
+		}
+		if len(ix.Accounts) < executeFixedAccounts || !signers.Contains(ix.Accounts[executeMemberAccount]) {
+			continue
+		}
+		exec := &Execution{
+			Multisig:         ix.Accounts[executeMultisigAccount],
+			Transaction:      ix.Accounts[executeTransactionAccount],
+			Member:           ix.Accounts[executeMemberAccount],
+			InstructionIndex: ix.Index,
+		}
+		vault, ok := findVault(exec.Multisig, ix.Accounts[executeFixedAccounts:])
+		if !ok {
+			return nil, fmt.Errorf("no vault of multisig %s among the accounts of instruction %d", exec.Multisig, ix.Index)
+		}
+		exec.Vault = vault
+		return exec, nil
+	}
+	return nil, ErrNoExecution
+}
+
+// findVault returns the vault PDA among a vault transaction's accounts. The
+// vault index isn't in the instruction, so each of the 256 possible vaults
+// is tried, most multisigs only using vault 0.
+func findVault(multisig solana.PublicKey, accounts []solana.PublicKey) (solana.PublicKey, bool) {
+	for i := 0; i < 256; i++ {
+		vault, _, err := solana.FindProgramAddress([][]byte{
+			[]byte("multisig"), multisig[:], []byte("vault"), {byte(i)},
+		}, SquadsV4ProgramID)
+		if err != nil {
+			continue
+		}
+		for _, account := range accounts {
+			if account.Equals(vault) {
+				return vault, true
+			}
+		}
+	}
+	return solana.PublicKey{}, false
+}
+
+// Unwrap returns tx rewritten as if the vault instructions exec ran had been
+// sent directly: the execute instruction is replaced by the instructions it
+// invoked, each followed by its own inner instructions, so swap parsers
+// that look at top-level instructions see the vault's swap. Balances and
+// logs are kept as they are.
+func Unwrap(tx *rpc.GetTransactionResult, exec *Execution) (*rpc.GetTransactionResult, error) {
+	decoded, err := txutil.Decode(tx)
+	if err != nil {
+		return nil, err
+	}
+	tree := instructions.BuildCPITree(tx)
+	if tree == nil || exec.InstructionIndex >= len(tree.Children) {
+		return nil, fmt.Errorf("instruction %d is not in the call tree", exec.InstructionIndex)
+	}
+	invoked := tree.Children[exec.InstructionIndex].Children
+	if len(invoked) == 0 {
+		return nil, errors.New("vault transaction invoked no instructions")
+	}
+
+	inner := make(map[int][]solana.CompiledInstruction)
+	for _, set := range tx.Meta.InnerInstructions {
+		inner[int(set.Index)] = append(inner[int(set.Index)], set.Instructions...)
+	}
+	executed := inner[exec.InstructionIndex]
+	at := func(node *instructions.CPINode) (solana.CompiledInstruction, error) {
+		if node.InstructionIndex < 0 || node.InstructionIndex >= len(executed) {
+			return solana.CompiledInstruction{}, fmt.Errorf("inner instruction %d out of range", node.InstructionIndex)
+		}
+		return executed[node.InstructionIndex], nil
+	}
+
+	var top []solana.CompiledInstruction
+	var sets []rpc.InnerInstruction
+	// Instructions before the execute keep their positions; those after it
+	// move along by however many instructions it unwraps to
+	push := func(ix solana.CompiledInstruction, ixInner []solana.CompiledInstruction) {
+		if len(ixInner) > 0 {
+			sets = append(sets, rpc.InnerInstruction{Index: uint16(len(top)), Instructions: ixInner})
+		}
+		top = append(top, ix)
+	}
+	for i, ix := range decoded.Message.Instructions {
+		if i != exec.InstructionIndex {
+			push(ix, inner[i])
+			continue
+		}
+		for _, node := range invoked {
+			lifted, err := at(node)
+			if err != nil {
+				return nil, err
+			}
+			var descendants []solana.CompiledInstruction
+			var walk func(n *instructions.CPINode) error
+			walk = func(n *instructions.CPINode) error {
+				for _, child := range n.Children {
+					ix, err := at(child)
+					if err != nil {
+						return err
+					}
+					descendants = append(descendants, ix)
+					if err := walk(child); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			if err := walk(node); err != nil {
+				return nil, err
+			}
+			push(lifted, descendants)
+		}
+	}
+
+	unwrapped := *decoded
+	unwrapped.Message.Instructions = top
+	raw, err := unwrapped.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("encoding unwrapped transaction: %w", err)
+	}
+	// The envelope can only be built by decoding an RPC-style encoding
+	encoded, err := json.Marshal([]string{base64.StdEncoding.EncodeToString(raw), "base64"})
+	if err != nil {
+		return nil, err
+	}
+	envelope := new(rpc.TransactionResultEnvelope)
+	if err := json.Unmarshal(encoded, envelope); err != nil {
+		return nil, fmt.Errorf("decoding unwrapped transaction: %w", err)
+	}
+
+	meta := *tx.Meta
+	meta.InnerInstructions = sets
+	out := *tx
+	out.Transaction = envelope
+	out.Meta = &meta
+	return &out, nil
+}
+
+// FetchTransactionIndex fills in exec.TransactionIndex from its
+// VaultTransaction account
+func FetchTransactionIndex(ctx context.Context, rpcClient *rpc.Client, exec *Execution) error {
+	account, err := rpcClient.GetAccountInfo(ctx, exec.Transaction)
+	if err != nil {
+		return fmt.Errorf("fetching vault transaction %s: %w", exec.Transaction, err)
+	}
+	if account.Value == nil || account.Value.Data == nil {
+		return fmt.Errorf("vault transaction %s is empty", exec.Transaction)
+	}
+	data := account.Value.Data.GetBinary()
+	if len(data) < vaultTransactionIndexOffset+8 || !account.Value.Owner.Equals(SquadsV4ProgramID) {
+		return fmt.Errorf("vault transaction %s is not a Squads v4 account", exec.Transaction)
+	}
+	exec.TransactionIndex = binary.LittleEndian.Uint64(data[vaultTransactionIndexOffset:])
+	return nil
+}
@@ -0,0 +1,65 @@
+// Package network identifies which Solana cluster an RPC endpoint serves.
+package network
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Cluster names, as used by the Solana CLI
+const (
+	Mainnet  = "mainnet-beta"
+	Devnet   = "devnet"
+	Testnet  = "testnet"
+	Localnet = "localnet"
+)
+
+// Names lists the recognised cluster names
+var Names = []string{Mainnet, Devnet, Testnet, Localnet}
+
+// genesisHashes identify the public clusters; anything else is treated as
+// a local validator
+var genesisHashes = map[solana.Hash]string{
+	solana.MustHashFromBase58("5eykt4UsFv8P8NJdTREpY1vzqKqZKvdpKuc147dw2N9d"): Mainnet,
+	solana.MustHashFromBase58("EtWTRABZaYq6iMfeYKouRu166VU2xqa1wcaWoxPkrZBG"): Devnet,
+	solana.MustHashFromBase58("4uhcVJyU9pJkvQyS88uRDiswHXSCkY3zQawwpjk2NsNY"): Testnet,
+}
+
+// FromURL guesses the cluster from an RPC URL's host, as providers usually
+// name it there. It returns "" if the URL doesn't say.
+func FromURL(rpcURL string) string {
+	u, err := url.Parse(rpcURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case strings.Contains(host, "devnet"):
+		return Devnet
+	case strings.Contains(host, "testnet"):
+		return Testnet
+	case strings.Contains(host, "mainnet"):
+		return Mainnet
+	case host == "localhost" || host == "127.0.0.1" || host == "::1":
+		return Localnet
+	default:
+		return ""
+	}
+}
+
+// FromGenesis identifies the cluster by its genesis hash, which is
+// authoritative where FromURL is a guess
+func FromGenesis(ctx context.Context, rpcClient *rpc.Client) (string, error) {
+	hash, err := rpcClient.GetGenesisHash(ctx)
+	if err != nil {
+		return "", err
+	}
+	if name, ok := genesisHashes[hash]; ok {
+		return name, nil
+	}
+	return Localnet, nil
+}
@@ -0,0 +1,109 @@
+// Package openbook parses taker swaps against OpenBook V2 order books.
+package openbook
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// ProgramIDV2 is the OpenBook V2 program, which shares neither accounts
+// nor instruction layouts with V1
+var ProgramIDV2 = solana.MustPublicKeyFromBase58("opnb2LAfJYbRMAHHvqjCwQxanZn7ReEHp1k81EohpZb")
+
+// OrderTypeTake is SwapData.OrderType for a PlaceTakeOrder fill
+const OrderTypeTake = "take"
+
+// ErrNoTakeOrder is returned when a transaction places no OpenBook V2 take
+// order of its own
+var ErrNoTakeOrder = errors.New("no OpenBook V2 take order in transaction")
+
+var placeTakeOrder = [8]byte(txutil.AnchorDiscriminator("place_take_order"))
+
+// PlaceTakeOrder arguments after the discriminator: side, price lots, max
+// base lots, max quote lots including fees, order type and limit
+const (
+	takeSideOffset         = 8
+	takeMaxBaseLotsOffset  = 17
+	takeMaxQuoteLotsOffset = 25
+	takeArgsLen            = 35
+)
+
+// Side values; a bid buys the base token with the quote token
+const (
+	sideBid = 0
+	sideAsk = 1
+)
+
+// Account positions in PlaceTakeOrder: signer, penalty payer, market,
+// market authority, bids, asks, the two market vaults, event heap, then the
+// user's token accounts
+const (
+	takeMarketAccount    = 2
+	takeUserBaseAccount  = 9
+	takeUserQuoteAccount = 10
+	takeMinAccounts      = 11
+)
+
+// ParseOpenBookV2 returns the swap made by the first top-level
+// PlaceTakeOrder instruction, or ErrNoTakeOrder. One an aggregator invokes
+// is a single hop of its route, so that's left to the aggregator's parser.
+// The order only caps what it trades, so the fill is taken from the balance
+// changes of the user's base and quote token accounts. Only the legs, pool
+// and order fields are set.
+func ParseOpenBookV2(tx *rpc.GetTransactionResult) (*model.SwapData, error) {
+	instructions, err := txutil.Instructions(tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, ix := range instructions {
+		if ix.InnerIndex >= 0 || !ix.ProgramID.Equals(ProgramIDV2) ||
+			len(ix.Data) < takeArgsLen || [8]byte(ix.Data[:8]) != placeTakeOrder ||
+			len(ix.Accounts) < takeMinAccounts {
+			continue
+		}
+		keys, err := txutil.AccountKeys(tx)
+		if err != nil {
+			return nil, err
+		}
+		base, baseOK := txutil.TokenChange(tx, keys, ix.Accounts[takeUserBaseAccount])
+		quote, quoteOK := txutil.TokenChange(tx, keys, ix.Accounts[takeUserQuoteAccount])
+		if !baseOK || !quoteOK {
+			return nil, errors.New("take order's token accounts have no balances")
+		}
+
+		in, out := quote, base
+		switch side := ix.Data[takeSideOffset]; side {
+		case sideBid:
+		case sideAsk:
+			in, out = base, quote
+		default:
+			return nil, fmt.Errorf("take order has unknown side %d", side)
+		}
+		if in.Change >= 0 || out.Change <= 0 {
+			return nil, errors.New("take order wasn't filled")
+		}
+		market := ix.Accounts[takeMarketAccount]
+		return &model.SwapData{
+			PoolAddress:      &market,
+			TokenInMint:      in.Mint,
+			TokenInAmount:    uint64(-in.Change),
+			TokenInDecimals:  in.Decimals,
+			AmountInUI:       model.UIAmount(uint64(-in.Change), in.Decimals),
+			TokenOutMint:     out.Mint,
+			TokenOutAmount:   uint64(out.Change),
+			TokenOutDecimals: out.Decimals,
+			AmountOutUI:      model.UIAmount(uint64(out.Change), out.Decimals),
+			OrderType:        OrderTypeTake,
+			MaxBaseLots:      int64(binary.LittleEndian.Uint64(ix.Data[takeMaxBaseLotsOffset:])),
+			MaxQuoteLots:     int64(binary.LittleEndian.Uint64(ix.Data[takeMaxQuoteLotsOffset:])),
+		}, nil
+	}
+	return nil, ErrNoTakeOrder
+}
@@ -0,0 +1,132 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage"
+)
+
+// arrowBatchRows is how many swaps are buffered per record batch, so a
+// long-running watch still streams batches as it goes
+const arrowBatchRows = 1024
+
+// ArrowSchema is the swaps table (storage.Columns) as an Arrow schema:
+// keys and the signature are Utf8, raw amounts and lamports Uint64, UI and
+// USD amounts Float64 and block times microsecond UTC timestamps
+var ArrowSchema = arrowSchema()
+
+func arrowSchema() *arrow.Schema {
+	fields := make([]arrow.Field, len(storage.Columns))
+	for i, c := range storage.Columns {
+		var t arrow.DataType
+		switch c.Type {
+		case storage.String:
+			t = arrow.BinaryTypes.String
+		case storage.Int:
+			t = arrow.PrimitiveTypes.Int64
+		case storage.Uint64:
+			t = arrow.PrimitiveTypes.Uint64
+		case storage.Float:
+			t = arrow.PrimitiveTypes.Float64
+		case storage.Bool:
+			t = arrow.FixedWidthTypes.Boolean
+		case storage.Timestamp:
+			t = &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+		default:
+			panic(fmt.Sprintf("no Arrow type for column %s", c.Name))
+		}
+		fields[i] = arrow.Field{Name: c.Name, Type: t, Nullable: c.Nullable}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// arrowRows buffers swaps as rows of ArrowSchema
+type arrowRows struct {
+	builder *array.RecordBuilder
+	rows    int
+}
+
+func newArrowRows() *arrowRows {
+	return &arrowRows{builder: array.NewRecordBuilder(memory.DefaultAllocator, ArrowSchema)}
+}
+
+func (a *arrowRows) append(s *model.SwapData) {
+	for i, v := range storage.Row(s) {
+		field := a.builder.Field(i)
+		if v == nil {
+			field.AppendNull()
+			continue
+		}
+		switch b := field.(type) {
+		case *array.StringBuilder:
+			b.Append(v.(string))
+		case *array.Int64Builder:
+			b.Append(v.(int64))
+		case *array.Uint64Builder:
+			b.Append(v.(uint64))
+		case *array.Float64Builder:
+			b.Append(v.(float64))
+		case *array.BooleanBuilder:
+			b.Append(v.(bool))
+		case *array.TimestampBuilder:
+			b.Append(arrow.Timestamp(v.(time.Time).UnixMicro()))
+		}
+	}
+	a.rows++
+}
+
+// record returns the buffered rows as a record and starts a new one. The
+// caller releases it.
+func (a *arrowRows) record() arrow.Record {
+	a.rows = 0
+	return a.builder.NewRecord()
+}
+
+// ArrowWriter writes swaps as an Arrow IPC stream, which pandas
+// (pyarrow.ipc.open_stream), Polars and DuckDB read without conversion
+type ArrowWriter struct {
+	buf *arrowRows
+	ipc *ipc.Writer
+}
+
+// NewArrowWriter returns an ArrowWriter streaming to w
+func NewArrowWriter(w io.Writer) *ArrowWriter {
+	return &ArrowWriter{buf: newArrowRows(), ipc: ipc.NewWriter(w, ipc.WithSchema(ArrowSchema))}
+}
+
+func (a *ArrowWriter) Write(r *model.Result) error {
+	for _, swap := range r.AllSwaps() {
+		a.buf.append(swap)
+	}
+	if a.buf.rows >= arrowBatchRows {
+		return a.flush()
+	}
+	return nil
+}
+
+// flush writes the buffered rows as one record batch
+func (a *ArrowWriter) flush() error {
+	if a.buf.rows == 0 {
+		return nil
+	}
+	record := a.buf.record()
+	defer record.Release()
+	return a.ipc.Write(record)
+}
+
+// Close writes any buffered rows and the end-of-stream marker
+func (a *ArrowWriter) Close() error {
+	defer a.buf.builder.Release()
+	if err := a.flush(); err != nil {
+		return err
+	}
+	return a.ipc.Close()
+}
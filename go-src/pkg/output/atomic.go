@@ -0,0 +1,70 @@
+package output
+
+import (
+	"errors"
+	"os"
+)
+
+// TempSuffix is added to a file's path while an AtomicWriter writes it
+const TempSuffix = ".tmp"
+
+// AtomicWriter writes a file under a temporary name, path plus TempSuffix,
+// and only renames it into place on Commit. A run that crashes or fails
+// partway leaves the temporary file behind and whatever was at path, such
+// as the previous run's output, untouched.
+type AtomicWriter struct {
+	file *os.File
+	path string
+	done bool
+}
+
+// NewAtomicWriter starts writing path, replacing any temporary file an
+// earlier run left
+func NewAtomicWriter(path string) (*AtomicWriter, error) {
+	f, err := os.OpenFile(path+TempSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o666)
+	if err != nil {
+		return nil, err
+	}
+	return &AtomicWriter{file: f, path: path}, nil
+}
+
+func (a *AtomicWriter) Write(p []byte) (int, error) {
+	return a.file.Write(p)
+}
+
+// Path is where the file ends up once committed
+func (a *AtomicWriter) Path() string {
+	return a.path
+}
+
+// Commit syncs the temporary file to disk, so a crash can't leave path
+// holding a truncated file, and renames it to path
+func (a *AtomicWriter) Commit() error {
+	if a.done {
+		return errors.New("output: " + a.path + " already committed or aborted")
+	}
+	a.done = true
+	err := a.file.Sync()
+	if closeErr := a.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(a.file.Name())
+		return err
+	}
+	return os.Rename(a.file.Name(), a.path)
+}
+
+// Abort closes and removes the temporary file, leaving path as it was. It
+// does nothing after Commit, so it can be deferred.
+func (a *AtomicWriter) Abort() error {
+	if a.done {
+		return nil
+	}
+	a.done = true
+	err := a.file.Close()
+	if removeErr := os.Remove(a.file.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
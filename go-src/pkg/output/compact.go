@@ -0,0 +1,156 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Compact marshals V like encoding/json, but leaves out struct fields that
+// hold a zero number or an empty string, at any depth. Fields keep their
+// declared order.
+type Compact struct {
+	V any
+}
+
+func (c Compact) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeCompact(&buf, reflect.ValueOf(c.V)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var marshalerType = reflect.TypeFor[json.Marshaler]()
+
+func writeCompact(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteString("null")
+		return nil
+	}
+	// Types with their own encoding (keys, times, raw JSON) are left alone
+	if v.Type().Implements(marshalerType) || reflect.PointerTo(v.Type()).Implements(marshalerType) {
+		return writeJSON(buf, v.Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeCompact(buf, v.Elem())
+	case reflect.Struct:
+		buf.WriteByte('{')
+		first := true
+		if err := writeFields(buf, v, &first); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+		return nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8) {
+			return writeJSON(buf, v.Interface())
+		}
+		buf.WriteByte('[')
+		for i := range v.Len() {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCompact(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return writeJSON(buf, v.Interface())
+		}
+		// Let encoding/json sort the keys, as it would for the plain map
+		values := make(map[string]json.RawMessage, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			var elem bytes.Buffer
+			if err := writeCompact(&elem, iter.Value()); err != nil {
+				return err
+			}
+			values[iter.Key().String()] = elem.Bytes()
+		}
+		return writeJSON(buf, values)
+	default:
+		return writeJSON(buf, v.Interface())
+	}
+}
+
+// writeFields writes v's exported fields, inlining untagged embedded structs
+// the way encoding/json does
+func writeFields(buf *bytes.Buffer, v reflect.Value, first *bool) error {
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		value := v.Field(i)
+
+		if field.Anonymous && name == "" {
+			embedded := value
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := writeFields(buf, embedded, first); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if isZeroScalar(value) || strings.Contains(","+opts+",", ",omitempty,") && value.IsZero() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if !*first {
+			buf.WriteByte(',')
+		}
+		*first = false
+		if err := writeJSON(buf, name); err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+		if err := writeCompact(buf, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isZeroScalar reports whether v is a zero number or an empty string
+func isZeroScalar(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return v.IsZero()
+	}
+	return false
+}
+
+func writeJSON(buf *bytes.Buffer, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(raw)
+	return nil
+}
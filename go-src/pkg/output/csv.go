@@ -0,0 +1,65 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage"
+)
+
+// csvWriter writes the swaps table's columns (storage.Columns) as CSV with
+// a header row. NULLs are empty fields and block times RFC 3339.
+type csvWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Write(r *model.Result) error {
+	if !c.header {
+		if err := c.w.Write(storage.ColumnNames()); err != nil {
+			return err
+		}
+		c.header = true
+	}
+	for _, swap := range r.AllSwaps() {
+		if err := c.writeRow(storage.Row(swap)); err != nil {
+			return err
+		}
+	}
+	// Flush every row so rows stream, and so RollingWriter sees their size
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) writeRow(row []any) error {
+	record := make([]string, len(row))
+	for i, v := range row {
+		switch v := v.(type) {
+		case string:
+			record[i] = v
+		case int64:
+			record[i] = strconv.FormatInt(v, 10)
+		case uint64:
+			record[i] = strconv.FormatUint(v, 10)
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			record[i] = strconv.FormatBool(v)
+		case time.Time:
+			record[i] = v.UTC().Format(time.RFC3339)
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
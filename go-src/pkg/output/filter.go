@@ -0,0 +1,78 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmespath/go-jmespath"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// Filter is a compiled JMESPath expression evaluated against each result's
+// JSON form
+type Filter struct {
+	expr *jmespath.JMESPath
+}
+
+// NewFilter compiles a JMESPath expression
+func NewFilter(expression string) (*Filter, error) {
+	expr, err := jmespath.Compile(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JMESPath expression %q: %w", expression, err)
+	}
+	return &Filter{expr: expr}, nil
+}
+
+// Match reports whether the expression is truthy for r: anything but false,
+// null, or an empty string, array or object
+func (f *Filter) Match(r *model.Result) (bool, error) {
+	// Round trip through JSON so field names match the output
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return false, err
+	}
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return false, err
+	}
+	value, err := f.expr.Search(doc)
+	if err != nil {
+		return false, err
+	}
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	case string:
+		return v != "", nil
+	case []any:
+		return len(v) > 0, nil
+	case map[string]any:
+		return len(v) > 0, nil
+	default:
+		return true, nil
+	}
+}
+
+// Filtered returns a Writer that passes on only the results f matches
+func Filtered(w Writer, f *Filter) Writer {
+	return &filterWriter{Writer: w, filter: f}
+}
+
+type filterWriter struct {
+	Writer
+	filter *Filter
+}
+
+func (w *filterWriter) Write(r *model.Result) error {
+	ok, err := w.filter.Match(r)
+	if err != nil {
+		return fmt.Errorf("evaluating --filter-expr: %w", err)
+	}
+	if !ok {
+		return nil
+	}
+	return w.Writer.Write(r)
+}
@@ -0,0 +1,34 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+type jsonWriter struct {
+	enc     *json.Encoder
+	compact bool
+}
+
+// newJSONWriter writes one JSON object per result, indented for reading or
+// compact for NDJSON, optionally dropping zero-valued fields
+func newJSONWriter(w io.Writer, indent, compact bool) *jsonWriter {
+	enc := json.NewEncoder(w)
+	if indent {
+		enc.SetIndent("", "  ")
+	}
+	return &jsonWriter{enc: enc, compact: compact}
+}
+
+func (j *jsonWriter) Write(r *model.Result) error {
+	if j.compact {
+		return j.enc.Encode(Compact{r})
+	}
+	return j.enc.Encode(r)
+}
+
+func (j *jsonWriter) Close() error {
+	return nil
+}
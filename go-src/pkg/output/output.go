@@ -0,0 +1,54 @@
+// Package output renders getswaps results in the formats selectable with --output.
+package output
+
+import (
+	"fmt"
+	"io"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// Writer renders results one at a time, so batch modes can stream rows as
+// they complete. Close flushes anything buffered; it does not close the
+// underlying io.Writer.
+type Writer interface {
+	Write(r *model.Result) error
+	Close() error
+}
+
+// Options tune the human-readable formats
+type Options struct {
+	// Swaps moving at least this much SOL are highlighted in table output
+	LargeSwapSOL float64
+
+	// If set, table output gets a Wallet column showing this name for each signer
+	WalletName func(wallet solana.PublicKey) string
+
+	// JSON formats leave out zero numbers and empty strings
+	CompactJSON bool
+}
+
+// Formats lists the values accepted by New
+var Formats = []string{"json", "ndjson", "table", "csv", "arrow", "parquet"}
+
+// New returns a Writer for the named format
+func New(format string, w io.Writer, opts Options) (Writer, error) {
+	switch format {
+	case "json":
+		return newJSONWriter(w, true, opts.CompactJSON), nil
+	case "ndjson":
+		return newJSONWriter(w, false, opts.CompactJSON), nil
+	case "table":
+		return newTableWriter(w, opts), nil
+	case "csv":
+		return newCSVWriter(w), nil
+	case "arrow":
+		return NewArrowWriter(w), nil
+	case "parquet":
+		return NewParquetWriter(w)
+	default:
+		return nil, fmt.Errorf("unknown output format %q (want one of %v)", format, Formats)
+	}
+}
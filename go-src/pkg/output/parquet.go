@@ -0,0 +1,62 @@
+package output
+
+import (
+	"io"
+
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// parquetRowGroupRows is how many swaps go into each row group. Row groups
+// are the unit readers skip by, so they're much larger than Arrow batches.
+const parquetRowGroupRows = 64 * 1024
+
+// ParquetWriter writes swaps as a Snappy-compressed Parquet file with the
+// columns of ArrowSchema. The footer is written by Close, so the file is
+// only readable once it returns.
+type ParquetWriter struct {
+	buf *arrowRows
+	pq  *pqarrow.FileWriter
+}
+
+// NewParquetWriter returns a ParquetWriter writing to w
+func NewParquetWriter(w io.Writer) (*ParquetWriter, error) {
+	props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy))
+	pq, err := pqarrow.NewFileWriter(ArrowSchema, w, props, pqarrow.DefaultWriterProps())
+	if err != nil {
+		return nil, err
+	}
+	return &ParquetWriter{buf: newArrowRows(), pq: pq}, nil
+}
+
+func (p *ParquetWriter) Write(r *model.Result) error {
+	for _, swap := range r.AllSwaps() {
+		p.buf.append(swap)
+	}
+	if p.buf.rows >= parquetRowGroupRows {
+		return p.flush()
+	}
+	return nil
+}
+
+// flush writes the buffered rows as one row group
+func (p *ParquetWriter) flush() error {
+	if p.buf.rows == 0 {
+		return nil
+	}
+	record := p.buf.record()
+	defer record.Release()
+	return p.pq.Write(record)
+}
+
+// Close writes any buffered rows and the file footer
+func (p *ParquetWriter) Close() error {
+	defer p.buf.builder.Release()
+	if err := p.flush(); err != nil {
+		return err
+	}
+	return p.pq.Close()
+}
@@ -0,0 +1,36 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// WriteTable writes a complete table of pre-formatted cells, sizing each
+// column to its widest cell and right-aligning the numeric column indexes
+func WriteTable(w io.Writer, header []string, rows [][]string, numeric ...int) error {
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	isNumeric := make([]bool, len(header))
+	for _, i := range numeric {
+		isNumeric[i] = true
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, columnGap, ' ', 0)
+	for _, row := range append([][]string{header}, rows...) {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = pad(cell, widths[i], isNumeric[i])
+		}
+		if _, err := fmt.Fprintln(tw, strings.Join(cells, "\t")); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
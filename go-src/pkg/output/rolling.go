@@ -0,0 +1,159 @@
+package output
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// RollingWriter writes results to a file, gzipped if its path ends in .gz,
+// moving on to a new numbered file (swaps_001.ndjson, swaps_002.ndjson, ...)
+// once the current one reaches a size limit. Each file gets its own format
+// writer, so every part is complete on its own: a CSV part has its header
+// and a parquet part its footer. Files are only split between results, and
+// arrow and parquet are only counted as they flush their batches, so their
+// parts can overshoot the limit by up to a batch. Each part is written with
+// an AtomicWriter and committed once finished, so a crash leaves at most the
+// part in progress as a .tmp file.
+type RollingWriter struct {
+	path     string
+	maxBytes int64
+	newInner func(w io.Writer) (Writer, error)
+
+	part    int
+	file    *AtomicWriter
+	buf     *bufio.Writer
+	zw      *gzip.Writer
+	counter *countingWriter
+	inner   Writer
+}
+
+// NewRollingWriter returns a RollingWriter on path, building each file's
+// writer with newInner. maxBytes counts bytes before compression; with 0
+// nothing is split and path is written as given.
+func NewRollingWriter(path string, maxBytes int64, newInner func(w io.Writer) (Writer, error)) *RollingWriter {
+	return &RollingWriter{path: path, maxBytes: maxBytes, newInner: newInner}
+}
+
+func (r *RollingWriter) Write(result *model.Result) error {
+	if r.inner == nil {
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+	if err := r.inner.Write(result); err != nil {
+		return err
+	}
+	if r.maxBytes > 0 && r.counter.n >= r.maxBytes {
+		return r.closeFile()
+	}
+	return nil
+}
+
+func (r *RollingWriter) Close() error {
+	if r.inner == nil {
+		return nil
+	}
+	return r.closeFile()
+}
+
+// PartPath is the name of the nth file written, counting from 1
+func (r *RollingWriter) PartPath(n int) string {
+	if r.maxBytes <= 0 {
+		return r.path
+	}
+	dir, name := filepath.Split(r.path)
+	suffix := ""
+	if trimmed, ok := strings.CutSuffix(name, ".gz"); ok {
+		name, suffix = trimmed, ".gz"
+	}
+	ext := filepath.Ext(name)
+	return fmt.Sprintf("%s%s_%03d%s%s", dir, strings.TrimSuffix(name, ext), n, ext, suffix)
+}
+
+// Parts is how many files have been started
+func (r *RollingWriter) Parts() int {
+	return r.part
+}
+
+func (r *RollingWriter) open() error {
+	r.part++
+	f, err := NewAtomicWriter(r.PartPath(r.part))
+	if err != nil {
+		return err
+	}
+	r.file = f
+	r.buf = bufio.NewWriter(f)
+	w := io.Writer(r.buf)
+	if strings.HasSuffix(r.path, ".gz") {
+		r.zw = gzip.NewWriter(r.buf)
+		w = r.zw
+	}
+	r.counter = &countingWriter{w: w}
+	inner, err := r.newInner(r.counter)
+	if err != nil {
+		f.Abort()
+		return err
+	}
+	r.inner = inner
+	return nil
+}
+
+// closeFile finishes and commits the current file, so the next Write
+// starts another. A file that couldn't be finished is removed instead.
+func (r *RollingWriter) closeFile() error {
+	err := r.inner.Close()
+	if r.zw != nil && err == nil {
+		err = r.zw.Close()
+	}
+	if err == nil {
+		err = r.buf.Flush()
+	}
+	if err == nil {
+		err = r.file.Commit()
+	} else {
+		r.file.Abort()
+	}
+	r.inner, r.zw = nil, nil
+	return err
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ParseSize reads a byte count with an optional KB, MB or GB suffix (powers
+// of 1024), e.g. "100MB"
+func ParseSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		scale  int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}}
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	scale := int64(1)
+	for _, u := range units {
+		if trimmed, ok := strings.CutSuffix(upper, u.suffix); ok {
+			upper, scale = strings.TrimSpace(trimmed), u.scale
+			break
+		}
+	}
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (want e.g. 500KB, 100MB or 2GB)", s)
+	}
+	return n * scale, nil
+}
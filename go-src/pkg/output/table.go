@@ -0,0 +1,197 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	solana "github.com/gagliardetto/solana-go"
+	"golang.org/x/term"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/format"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+)
+
+const (
+	columnGap   = 2
+	amountWidth = 16 // just under a million SOL to 9 decimal places
+)
+
+type column struct {
+	header  string
+	width   int
+	numeric bool
+	value   func(s *model.SwapData) string
+}
+
+// columns in display order. Column widths are fixed up front so rows can be
+// flushed as they arrive without the columns drifting between rows.
+var columns = []column{
+	{header: "Signature", width: 13, value: func(s *model.SwapData) string { return Truncate(s.Signature.String()) }},
+	{header: "Time", width: 19, value: func(s *model.SwapData) string { return formatTime(s.BlockTime) }},
+	{header: "DEX", width: 16, value: func(s *model.SwapData) string { return strings.TrimSpace(s.Dex + " " + s.DexVersion) }},
+	{header: "In", width: amountWidth + 1 + 13, numeric: true, value: func(s *model.SwapData) string { return formatLeg(s.AmountInUI, s.TokenInDecimals, s.TokenInMint) }},
+	{header: "Out", width: amountWidth + 1 + 13, numeric: true, value: func(s *model.SwapData) string { return formatLeg(s.AmountOutUI, s.TokenOutDecimals, s.TokenOutMint) }},
+	{header: "Fee SOL", width: 11, numeric: true, value: func(s *model.SwapData) string { return strconv.FormatFloat(s.FeeSOL(), 'f', 9, 64) }},
+}
+
+// dropOrder is the order columns are hidden in when the terminal is too narrow
+var dropOrder = []string{"Time", "Wallet", "DEX", "Fee SOL"}
+
+type tableWriter struct {
+	tw        *tabwriter.Writer
+	columns   []column
+	opts      Options
+	highlight *color.Color
+	header    bool
+}
+
+func newTableWriter(w io.Writer, opts Options) *tableWriter {
+	all := columns
+	if opts.WalletName != nil {
+		wallet := column{header: "Wallet", width: 20, value: func(s *model.SwapData) string { return opts.WalletName(s.Signer) }}
+		all = append([]column{all[0], wallet}, all[1:]...)
+	}
+	return &tableWriter{
+		tw:        tabwriter.NewWriter(w, 0, 0, columnGap, ' ', 0),
+		columns:   fitColumns(all, terminalWidth(w)),
+		opts:      opts,
+		highlight: color.New(color.FgYellow, color.Bold),
+	}
+}
+
+func (t *tableWriter) Write(r *model.Result) error {
+	if !t.header {
+		t.header = true
+		cells := make([]string, len(t.columns))
+		for i, c := range t.columns {
+			cells[i] = pad(c.header, c.width, c.numeric)
+		}
+		if err := t.writeRow(cells, nil); err != nil {
+			return err
+		}
+	}
+	for _, swap := range r.AllSwaps() {
+		cells := make([]string, len(t.columns))
+		for i, c := range t.columns {
+			cells[i] = pad(c.value(swap), c.width, c.numeric)
+		}
+		var highlight *color.Color
+		if t.isLarge(swap) {
+			highlight = t.highlight
+		}
+		if err := t.writeRow(cells, highlight); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeRow writes and flushes a single row. Every cell is already padded to
+// its column width, so the colour codes wrapped around a highlighted row
+// don't affect the alignment of the next one.
+func (t *tableWriter) writeRow(cells []string, highlight *color.Color) error {
+	line := strings.Join(cells, "\t")
+	if highlight != nil {
+		line = highlight.Sprint(line)
+	}
+	if _, err := fmt.Fprintln(t.tw, line); err != nil {
+		return err
+	}
+	return t.tw.Flush()
+}
+
+func (t *tableWriter) Close() error {
+	return t.tw.Flush()
+}
+
+func (t *tableWriter) isLarge(s *model.SwapData) bool {
+	if t.opts.LargeSwapSOL <= 0 {
+		return false
+	}
+	return (s.TokenInMint == solana.SolMint && s.AmountInUI >= t.opts.LargeSwapSOL) ||
+		(s.TokenOutMint == solana.SolMint && s.AmountOutUI >= t.opts.LargeSwapSOL)
+}
+
+// fitColumns drops low priority columns until the table fits in width.
+// A width of 0 means unknown (e.g. output is piped) and keeps every column.
+func fitColumns(all []column, width int) []column {
+	visible := append([]column(nil), all...)
+	for _, name := range dropOrder {
+		if width <= 0 || tableWidth(visible) <= width {
+			break
+		}
+		for i, c := range visible {
+			if c.header == name {
+				visible = append(visible[:i], visible[i+1:]...)
+				break
+			}
+		}
+	}
+	return visible
+}
+
+func tableWidth(cols []column) int {
+	width := 0
+	for _, c := range cols {
+		width += c.width
+	}
+	return width + columnGap*(len(cols)-1)
+}
+
+// terminalWidth returns the width of w if it is a terminal, otherwise 0
+func terminalWidth(w io.Writer) int {
+	f, ok := w.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return 0
+	}
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// Truncate shortens a base58 key or signature to its first 8 and last 4 characters
+func Truncate(s string) string {
+	if len(s) <= 13 {
+		return s
+	}
+	return s[:8] + "…" + s[len(s)-4:]
+}
+
+// pad fits s to exactly width characters, right-aligning numeric columns
+func pad(s string, width int, numeric bool) string {
+	if utf8.RuneCountInString(s) > width {
+		s = string([]rune(s)[:width-1]) + "…"
+	}
+	if numeric {
+		return fmt.Sprintf("%*s", width, s)
+	}
+	return fmt.Sprintf("%-*s", width, s)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.DateTime)
+}
+
+// formatLeg shows an amount with format.HumanNumber, then the token's symbol
+// or, for tokens without one, its mint
+func formatLeg(amount float64, decimals uint8, mint solana.PublicKey) string {
+	symbol := tokenmetadata.Symbol(mint)
+	label := symbol
+	if label == "" {
+		label = Truncate(mint.String())
+	}
+	return fmt.Sprintf("%*s %s", amountWidth, format.HumanNumber(amount, decimals, symbol), label)
+}
@@ -0,0 +1,65 @@
+// Package pagination provides resumable cursors for paging through
+// getSignaturesForAddress.
+package pagination
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	solana "github.com/gagliardetto/solana-go"
+)
+
+// Cursor marks a position in an address's signature history, which runs
+// newest first. Paging resumes with the signatures older than Before; the
+// zero Cursor starts at the newest.
+type Cursor struct {
+	Before *solana.Signature
+}
+
+// After returns the cursor that resumes after sig
+func After(sig solana.Signature) Cursor {
+	return Cursor{Before: &sig}
+}
+
+// IsStart reports whether c starts at the newest signature
+func (c Cursor) IsStart() bool {
+	return c.Before == nil
+}
+
+// String encodes c as URL-safe base64, or "" for the start
+func (c Cursor) String() string {
+	if c.Before == nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(c.Before[:])
+}
+
+// Parse decodes a cursor written by String
+func Parse(s string) (Cursor, error) {
+	if s == "" {
+		return Cursor{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if len(raw) != len(solana.Signature{}) {
+		return Cursor{}, fmt.Errorf("invalid cursor: %d bytes, want %d", len(raw), len(solana.Signature{}))
+	}
+	var sig solana.Signature
+	copy(sig[:], raw)
+	return After(sig), nil
+}
+
+func (c Cursor) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Cursor) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
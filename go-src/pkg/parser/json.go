@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ParseJSON is ParseSwap for callers outside Go, such as the WASM and C
+// library builds, which pass JSON both ways. txJSON may be the bare
+// getTransaction result or the whole JSON-RPC response around it. The
+// combined record is returned as JSON, or {"error": "..."} if the
+// transaction doesn't parse. A panic in a parser is reported the same
+// way, since a WASM or C host can't recover from one.
+func ParseJSON(txJSON []byte) (out []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			out = ErrorJSON(fmt.Errorf("parsing transaction: panic: %v", r))
+		}
+	}()
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(txJSON, &envelope); err == nil && len(envelope.Result) > 0 {
+		txJSON = envelope.Result
+	}
+	var tx rpc.GetTransactionResult
+	if err := json.Unmarshal(txJSON, &tx); err != nil {
+		return ErrorJSON(fmt.Errorf("decoding transaction: %w", err))
+	}
+	result, err := ParseSwap(&tx)
+	if err != nil {
+		return ErrorJSON(err)
+	}
+	out, err = json.Marshal(result)
+	if err != nil {
+		return ErrorJSON(err)
+	}
+	return out
+}
+
+// ErrorJSON is how ParseJSON reports an error: {"error": "..."}
+func ErrorJSON(err error) []byte {
+	out, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return out
+}
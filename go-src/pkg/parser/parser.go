@@ -0,0 +1,527 @@
+// Package parser turns a fetched transaction into getswaps' combined swap
+// and transaction record, independent of how the transaction was fetched.
+package parser
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	solanaswapgo "github.com/MaybeItsAdam/solanaswap-go/solanaswap-go"
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/dedup"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/governance"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/instructions"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/lstake"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/mev"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/multisig"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/openbook"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/pool"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/pumpfun"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/registry"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/sanctum"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/stake"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// ParseSwap runs a fetched transaction through solanaswapgo, or the plugin
+// for a program it invokes, and combines the parsed transaction and swap
+// data into a single result
+func ParseSwap(tx *rpc.GetTransactionResult) (*model.Result, error) {
+	txData, err := newTransactionData(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// A swap executed from a Squads vault is parsed as the vault's own
+	// instructions
+	swapTx := tx
+	if exec, err := multisig.FindExecution(tx); err == nil {
+		if swapTx, err = multisig.Unwrap(tx, exec); err != nil {
+			return nil, fmt.Errorf("Error unwrapping Squads vault transaction: %s", err)
+		}
+		txData.SquadsVaultAddress = &exec.Vault
+	} else if !errors.Is(err, multisig.ErrNoExecution) {
+		return nil, fmt.Errorf("Error finding Squads vault transaction: %s", err)
+	}
+
+	var swap *model.SwapData
+	var swaps []*model.SwapData
+	if p, ok := registry.PluginFor(swapTx); ok {
+		swap, err = pluginSwap(p, swapTx, txData)
+	} else if swap, err = sanctumSwap(swapTx, txData); errors.Is(err, sanctum.ErrNoSanctumSwap) {
+		if swap, err = openBookSwap(swapTx, txData); errors.Is(err, openbook.ErrNoTakeOrder) {
+			swaps, err = solanaswapgoSwaps(swapTx, txData)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if swaps == nil {
+		swaps = []*model.SwapData{swap}
+	}
+	for i, swap := range swaps {
+		swap.HopIndex = i
+		setSubmission(tx, swap)
+	}
+
+	// A lone swap not parsed from its instruction takes the transaction's pool
+	if len(swaps) == 1 && swaps[0].PoolAddress == nil {
+		if address, ok := pool.Find(swapTx); ok {
+			swaps[0].PoolAddress = &address
+		}
+	}
+	if trades, err := pumpfun.ParseTrades(swapTx); err == nil {
+		for _, swap := range swaps {
+			i := slices.IndexFunc(trades, func(t *pumpfun.Trade) bool { return t.Made(swap) })
+			if i < 0 && len(swaps) == 1 && len(trades) == 1 {
+				i = 0
+			}
+			if i < 0 {
+				continue
+			}
+			trade := trades[i]
+			_, swap.PreSwapMarketCapSOL = pumpfun.ComputePumpFunPrice(trade.PreReserves())
+			_, swap.PostSwapMarketCapSOL = pumpfun.ComputePumpFunPrice(trade.VirtualSOLReserves, trade.VirtualTokenReserves)
+			swap.MarketImpactBps = trade.MarketImpactBps()
+		}
+	}
+
+	// The rest describe the transaction as a whole, so they go on its first swap
+	swap = swaps[0]
+	if creations, err := instructions.DetectTokenAccountCreations(tx); err == nil {
+		for _, c := range creations {
+			swap.TokenAccountRentLamports += c.RentLamports
+		}
+	}
+	if tip, err := mev.JitoTip(tx); err == nil {
+		swap.JitoTipLamports = tip
+	}
+
+	if txData.StakeEvents, err = stake.ParseStakeInstruction(tx); err != nil {
+		return nil, fmt.Errorf("Error parsing stake instructions: %s", err)
+	}
+	if vote, err := governance.ParseGovernanceVote(tx); err == nil {
+		txData.GovernanceEvents = []*governance.GovernanceVote{vote}
+	} else if !errors.Is(err, governance.ErrNoVote) {
+		return nil, fmt.Errorf("Error parsing governance vote: %s", err)
+	}
+	if event, err := lstake.ParseMarinade(tx); err == nil {
+		txData.LiquidStakeEvents = []*lstake.LiquidStakeEvent{event}
+	} else if !errors.Is(err, lstake.ErrNoLiquidStake) {
+		return nil, fmt.Errorf("Error parsing liquid staking instructions: %s", err)
+	}
+
+	result := &model.Result{SwapData: swap, TransactionData: txData, RawTransaction: tx}
+	if len(swaps) > 1 {
+		result.Swaps = swaps
+	}
+	return result, nil
+}
+
+// solanaswapgoSwaps parses the transaction's swaps with the built-in
+// solanaswapgo parser. ProcessSwapData folds every swap event in a
+// transaction into one trade, from the first event's input to the last
+// one's output, which is only right for the hops of a single route. So each
+// event is processed on its own, and consecutive events are joined back
+// into one swap only while each spends exactly what the one before it
+// bought; anything else, such as two separate swaps bundled for atomic
+// execution, comes back as a swap of its own.
+func solanaswapgoSwaps(tx *rpc.GetTransactionResult, txData *model.TransactionData) ([]*model.SwapData, error) {
+	// Initialize the transaction parser using solanaswapgo
+	parser, err := solanaswapgo.NewTransactionParser(tx)
+	if err != nil {
+		return nil, fmt.Errorf("Error initializing transaction parser: %s", err)
+	}
+
+	// Parse the transaction to extract basic data
+	transactionData, err := parser.ParseTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing transaction: %s", err)
+	}
+
+	// Process and extract swap-specific data from each swap event. Some
+	// events are processed from the transaction's balances rather than the
+	// event itself, so the same trade can come back twice.
+	var legs []*solanaswapgo.SwapInfo
+	for _, event := range transactionData {
+		leg, err := parser.ProcessSwapData([]solanaswapgo.SwapData{event})
+		if err != nil {
+			return nil, fmt.Errorf("Error processing swap data: %s", err)
+		}
+		if len(legs) > 0 && sameLeg(leg, legs[len(legs)-1]) {
+			continue
+		}
+		legs = append(legs, leg)
+	}
+	if len(legs) == 0 {
+		// Leave reporting a transaction without swaps to the parser
+		leg, err := parser.ProcessSwapData(transactionData)
+		if err != nil {
+			return nil, fmt.Errorf("Error processing swap data: %s", err)
+		}
+		legs = append(legs, leg)
+	}
+	txData.Instructions = transactionData
+	return swapsFromLegs(tx, txData, legs), nil
+}
+
+// swapsFromLegs builds the transaction's swaps from its processed swap
+// events, joining the hops of each route into one swap
+func swapsFromLegs(tx *rpc.GetTransactionResult, txData *model.TransactionData, legs []*solanaswapgo.SwapInfo) []*model.SwapData {
+	legs, calls := orderLegs(tx, legs)
+
+	var swaps []*model.SwapData
+	var routes [][]*txutil.Instruction
+	for i, leg := range legs {
+		if i > 0 && leg.TokenInMint.Equals(legs[i-1].TokenOutMint) && leg.TokenInAmount == legs[i-1].TokenOutAmount {
+			swap := swaps[len(swaps)-1]
+			swap.TokenOutMint = leg.TokenOutMint
+			swap.TokenOutAmount = leg.TokenOutAmount
+			swap.TokenOutDecimals = leg.TokenOutDecimals
+			swap.AmountOutUI = model.UIAmount(leg.TokenOutAmount, leg.TokenOutDecimals)
+			routes[len(routes)-1] = append(routes[len(routes)-1], calls[i])
+			continue
+		}
+		swap := newSwapData(txData)
+		swap.TokenInMint = leg.TokenInMint
+		swap.TokenInAmount = leg.TokenInAmount
+		swap.TokenInDecimals = leg.TokenInDecimals
+		swap.AmountInUI = model.UIAmount(leg.TokenInAmount, leg.TokenInDecimals)
+		swap.TokenOutMint = leg.TokenOutMint
+		swap.TokenOutAmount = leg.TokenOutAmount
+		swap.TokenOutDecimals = leg.TokenOutDecimals
+		swap.AmountOutUI = model.UIAmount(leg.TokenOutAmount, leg.TokenOutDecimals)
+		swaps = append(swaps, swap)
+		routes = append(routes, []*txutil.Instruction{calls[i]})
+	}
+
+	for i, swap := range swaps {
+		// Record which known program (and which deployment of it) handled the swap
+		if info, programID, ok := routeProgram(tx, routes[i]); ok {
+			swap.Dex = info.Name
+			swap.DexVersion = info.Version
+			swap.DexType = string(info.Type)
+			swap.ProgramID = &programID
+		}
+		// A route is credited to the pool of its first hop
+		if first := routes[i][0]; first != nil {
+			if address, ok := pool.FromInstruction(*first); ok {
+				swap.PoolAddress = &address
+			}
+		}
+	}
+	return swaps
+}
+
+// orderLegs pairs each swap event with its DEX instruction and returns both
+// in execution order, (Index, InnerIndex) of the instruction, so repeated
+// parses of a transaction agree however its meta.InnerInstructions are
+// listed. Events whose instructions can't be told apart keep their order,
+// with nil instructions.
+func orderLegs(tx *rpc.GetTransactionResult, legs []*solanaswapgo.SwapInfo) ([]*solanaswapgo.SwapInfo, []*txutil.Instruction) {
+	calls := legInstructions(tx, legs)
+	if slices.Contains(calls, nil) {
+		return legs, calls
+	}
+	order := make([]int, len(legs))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Or(cmp.Compare(calls[a].Index, calls[b].Index), cmp.Compare(calls[a].InnerIndex, calls[b].InnerIndex))
+	})
+	sortedLegs := make([]*solanaswapgo.SwapInfo, len(legs))
+	sortedCalls := make([]*txutil.Instruction, len(calls))
+	for i, j := range order {
+		sortedLegs[i], sortedCalls[i] = legs[j], calls[j]
+	}
+	return sortedLegs, sortedCalls
+}
+
+// sameLeg reports whether two processed swap events traded the same amounts
+func sameLeg(a, b *solanaswapgo.SwapInfo) bool {
+	return a.TokenInMint.Equals(b.TokenInMint) && a.TokenInAmount == b.TokenInAmount &&
+		a.TokenOutMint.Equals(b.TokenOutMint) && a.TokenOutAmount == b.TokenOutAmount
+}
+
+// solanaswapgoSwap parses the transaction as a single swap with the
+// built-in solanaswapgo parser.
+//
+// Deprecated: a transaction can hold several independent swaps, and this
+// returns only the first; use solanaswapgoSwaps.
+func solanaswapgoSwap(tx *rpc.GetTransactionResult, txData *model.TransactionData) (*model.SwapData, error) {
+	swaps, err := solanaswapgoSwaps(tx, txData)
+	if err != nil {
+		return nil, err
+	}
+	return swaps[0], nil
+}
+
+// legInstructions pairs each swap event with the DEX instruction that
+// made it: a call to a program the registry knows, other than an
+// aggregator, that isn't an Anchor event self-invocation. Each event takes
+// the first call not yet paired whose accounts hold the most of its two
+// mints, so events that came back out of execution order still find
+// their own hop. If the counts disagree the events can't be told apart,
+// and every entry is nil.
+func legInstructions(tx *rpc.GetTransactionResult, legs []*solanaswapgo.SwapInfo) []*txutil.Instruction {
+	calls := make([]*txutil.Instruction, len(legs))
+	instructions, err := txutil.Instructions(tx)
+	if err != nil {
+		return calls
+	}
+	var dexes []*txutil.Instruction
+	for i := range instructions {
+		ix := &instructions[i]
+		info, ok := registry.Default().Lookup(ix.ProgramID)
+		if !ok || info.Type == registry.TypeAggregator || bytes.HasPrefix(ix.Data, txutil.AnchorEventTag) {
+			continue
+		}
+		dexes = append(dexes, ix)
+	}
+	if len(dexes) != len(legs) {
+		return calls
+	}
+
+	mints := accountMints(tx)
+	holds := func(ix *txutil.Instruction, mint solana.PublicKey) int {
+		for _, account := range ix.Accounts {
+			if account.Equals(mint) || mints[account].Equals(mint) {
+				return 1
+			}
+		}
+		return 0
+	}
+	taken := make([]bool, len(dexes))
+	for i, leg := range legs {
+		best, bestScore := -1, -1
+		for j, ix := range dexes {
+			if taken[j] {
+				continue
+			}
+			if score := holds(ix, leg.TokenInMint) + holds(ix, leg.TokenOutMint); score > bestScore {
+				best, bestScore = j, score
+			}
+		}
+		taken[best] = true
+		calls[i] = dexes[best]
+	}
+	return calls
+}
+
+// accountMints maps each token account in the transaction's balances to
+// its mint
+func accountMints(tx *rpc.GetTransactionResult) map[solana.PublicKey]solana.PublicKey {
+	mints := make(map[solana.PublicKey]solana.PublicKey)
+	keys, err := txutil.AccountKeys(tx)
+	if err != nil || tx.Meta == nil {
+		return mints
+	}
+	for _, b := range slices.Concat(tx.Meta.PreTokenBalances, tx.Meta.PostTokenBalances) {
+		if int(b.AccountIndex) < len(keys) {
+			mints[keys[b.AccountIndex]] = b.Mint
+		}
+	}
+	return mints
+}
+
+// routeProgram picks the program a swap is credited to from the DEX
+// instructions of its legs. A single hop is its DEX's; a route through
+// several is the known program of the top-level instruction that invoked
+// them, such as an aggregator. Without its legs' instructions the swap
+// falls back to the first known program in the transaction.
+func routeProgram(tx *rpc.GetTransactionResult, route []*txutil.Instruction) (registry.ProgramInfo, solana.PublicKey, bool) {
+	if slices.Contains(route, nil) {
+		return registry.DetectProtocol(tx)
+	}
+	program := route[0].ProgramID
+	if len(route) > 1 {
+		if decoded, err := txutil.Decode(tx); err == nil {
+			top := decoded.Message.Instructions[route[0].Index]
+			if id, err := decoded.Message.Program(top.ProgramIDIndex); err == nil {
+				if _, ok := registry.Default().Lookup(id); ok {
+					program = id
+				}
+			}
+		}
+	}
+	info, ok := registry.Default().Lookup(program)
+	return info, program, ok
+}
+
+// pluginSwap parses the swap with a parser plugin. The plugin supplies the
+// legs; the transaction-level fields are filled in the same way as for
+// built-in parsing.
+func pluginSwap(p *registry.Plugin, tx *rpc.GetTransactionResult, txData *model.TransactionData) (*model.SwapData, error) {
+	parsed, err := p.ParseSwap(tx)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing swap with plugin %s: %s", p.Name, err)
+	}
+	if parsed == nil {
+		return nil, fmt.Errorf("plugin %s found no swap", p.Name)
+	}
+
+	swap := newSwapData(txData)
+	swap.TokenInMint = parsed.TokenInMint
+	swap.TokenInAmount = parsed.TokenInAmount
+	swap.TokenInDecimals = parsed.TokenInDecimals
+	swap.AmountInUI = model.UIAmount(parsed.TokenInAmount, parsed.TokenInDecimals)
+	swap.TokenOutMint = parsed.TokenOutMint
+	swap.TokenOutAmount = parsed.TokenOutAmount
+	swap.TokenOutDecimals = parsed.TokenOutDecimals
+	swap.AmountOutUI = model.UIAmount(parsed.TokenOutAmount, parsed.TokenOutDecimals)
+
+	swap.Dex, swap.DexVersion, swap.DexType = parsed.Dex, parsed.DexVersion, parsed.DexType
+	if swap.Dex == "" {
+		swap.Dex = p.Name
+	}
+	programID := p.ProgramID
+	swap.ProgramID = &programID
+	swap.ParserSource = "plugin:" + p.Name
+	return swap, nil
+}
+
+// sanctumSwap parses an LST swap through the Sanctum Router, which
+// solanaswapgo doesn't know, or returns sanctum.ErrNoSanctumSwap
+func sanctumSwap(tx *rpc.GetTransactionResult, txData *model.TransactionData) (*model.SwapData, error) {
+	parsed, err := sanctum.ParseSanctumSwap(tx)
+	if errors.Is(err, sanctum.ErrNoSanctumSwap) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing Sanctum swap: %s", err)
+	}
+
+	swap := newSwapData(txData)
+	swap.TokenInMint = parsed.TokenInMint
+	swap.TokenInAmount = parsed.TokenInAmount
+	swap.TokenInDecimals = parsed.TokenInDecimals
+	swap.AmountInUI = parsed.AmountInUI
+	swap.TokenOutMint = parsed.TokenOutMint
+	swap.TokenOutAmount = parsed.TokenOutAmount
+	swap.TokenOutDecimals = parsed.TokenOutDecimals
+	swap.AmountOutUI = parsed.AmountOutUI
+	swap.IsLSTSwap, swap.LSTProtocol = parsed.IsLSTSwap, parsed.LSTProtocol
+
+	if info, ok := registry.Default().Lookup(sanctum.RouterProgramID); ok {
+		swap.Dex, swap.DexVersion, swap.DexType = info.Name, info.Version, string(info.Type)
+	}
+	programID := sanctum.RouterProgramID
+	swap.ProgramID = &programID
+	return swap, nil
+}
+
+// openBookSwap parses a take order placed directly on an OpenBook V2
+// market, which solanaswapgo doesn't know, or returns
+// openbook.ErrNoTakeOrder
+func openBookSwap(tx *rpc.GetTransactionResult, txData *model.TransactionData) (*model.SwapData, error) {
+	parsed, err := openbook.ParseOpenBookV2(tx)
+	if errors.Is(err, openbook.ErrNoTakeOrder) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing OpenBook take order: %s", err)
+	}
+
+	swap := newSwapData(txData)
+	swap.TokenInMint = parsed.TokenInMint
+	swap.TokenInAmount = parsed.TokenInAmount
+	swap.TokenInDecimals = parsed.TokenInDecimals
+	swap.AmountInUI = parsed.AmountInUI
+	swap.TokenOutMint = parsed.TokenOutMint
+	swap.TokenOutAmount = parsed.TokenOutAmount
+	swap.TokenOutDecimals = parsed.TokenOutDecimals
+	swap.AmountOutUI = parsed.AmountOutUI
+	swap.PoolAddress = parsed.PoolAddress
+	swap.OrderType, swap.MaxBaseLots, swap.MaxQuoteLots = parsed.OrderType, parsed.MaxBaseLots, parsed.MaxQuoteLots
+
+	if info, ok := registry.Default().Lookup(openbook.ProgramIDV2); ok {
+		swap.Dex, swap.DexVersion, swap.DexType = info.Name, info.Version, string(info.Type)
+	}
+	programID := openbook.ProgramIDV2
+	swap.ProgramID = &programID
+	return swap, nil
+}
+
+// FailedResult describes a failed transaction, which has no swap legs but
+// still paid fees
+func FailedResult(tx *rpc.GetTransactionResult) (*model.Result, error) {
+	txData, err := newTransactionData(tx)
+	if err != nil {
+		return nil, err
+	}
+	swap := newSwapData(txData)
+	swap.Failed = true
+	setSubmission(tx, swap)
+	return &model.Result{SwapData: swap, TransactionData: txData}, nil
+}
+
+// newSwapData copies the transaction-level fields shared with the swap record
+func newSwapData(txData *model.TransactionData) *model.SwapData {
+	return &model.SwapData{
+		Signature:           txData.Signature,
+		Slot:                txData.Slot,
+		BlockTime:           txData.BlockTime,
+		Signer:              txData.Signer,
+		FeeLamports:         txData.FeeLamports,
+		PriorityFeeLamports: txData.PriorityFeeLamports,
+	}
+}
+
+// setSubmission records what identifies the swap's transaction apart from
+// its signature, for spotting resubmissions
+func setSubmission(tx *rpc.GetTransactionResult, swap *model.SwapData) {
+	decoded, err := txutil.Decode(tx)
+	if err != nil {
+		return
+	}
+	blockhash := decoded.Message.RecentBlockhash
+	swap.RecentBlockhash = &blockhash
+	swap.InstructionsHash = dedup.InstructionsHash(decoded)
+}
+
+// newTransactionData fills in the transaction-level fields of a result
+func newTransactionData(tx *rpc.GetTransactionResult) (*model.TransactionData, error) {
+	decoded, err := txutil.Decode(tx)
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded.Signatures) == 0 || len(decoded.Message.AccountKeys) == 0 {
+		return nil, errors.New("transaction has no signatures")
+	}
+
+	// Signatures are in the same order as the signer keys, which lead the
+	// account list
+	signers := min(len(decoded.Signatures), len(decoded.Message.AccountKeys))
+	txData := &model.TransactionData{
+		Signature: decoded.Signatures[0],
+		Slot:      tx.Slot,
+		// The fee payer is always the first account
+		Signer:      decoded.Message.AccountKeys[0],
+		AllSigners:  append([]solana.PublicKey(nil), decoded.Message.AccountKeys[:signers]...),
+		MaxCPIDepth: instructions.BuildCPITree(tx).MaxDepth(),
+	}
+	if pda, ok := multisig.FindMultisig(tx); ok {
+		txData.MultisigPDA = &pda
+	}
+	if tx.BlockTime != nil {
+		txData.BlockTime = tx.BlockTime.Time().UTC()
+	}
+	if tx.Meta != nil {
+		txData.FeeLamports = tx.Meta.Fee
+		if baseFee := model.BaseFeeLamportsPerSignature * uint64(len(decoded.Signatures)); tx.Meta.Fee > baseFee {
+			txData.PriorityFeeLamports = tx.Meta.Fee - baseFee
+		}
+		if tx.Meta.ComputeUnitsConsumed != nil {
+			txData.ComputeUnitsConsumed = *tx.Meta.ComputeUnitsConsumed
+		}
+		txData.ProgramErrors = instructions.ExtractProgramErrors(tx.Meta.LogMessages)
+	}
+	return txData, nil
+}
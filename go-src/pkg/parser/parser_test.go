@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"testing"
+
+	solanaswapgo "github.com/MaybeItsAdam/solanaswap-go/solanaswap-go"
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+var (
+	usdcMint = solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	usdtMint = solana.MustPublicKeyFromBase58("Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB")
+	jupMint  = solana.MustPublicKeyFromBase58("JUPyiwrYJFskUPiHa7hkeR8VUtAeFoSYbKedZNsDvCN")
+
+	raydiumAMM = solana.MustPublicKeyFromBase58("675kPX9MHTjS2zt1qfr1NYHuzeLXfQM9H24wFSUt1Mp8")
+	whirlpool  = solana.MustPublicKeyFromBase58("whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc")
+	dlmm       = solana.MustPublicKeyFromBase58("LBUZKhRxPF3XUpBCjp4YzTKgLccjZhTSDM9YuVaPwxo")
+	jupiter    = solana.MustPublicKeyFromBase58("JUP6LkbZbjS1jKKwapdHNy74zcZ3tLUZoi5QNyVTaV4")
+)
+
+// loadFixture reads a saved getTransaction response from the replay fixtures
+func loadFixture(t *testing.T, name string) *rpc.GetTransactionResult {
+	t.Helper()
+	data, err := os.ReadFile("../../cmd/getswaps/testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp struct {
+		Result *rpc.GetTransactionResult `json:"result"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Result
+}
+
+// TestThreeHopRouteOrder checks that a Jupiter route's hops are put back in
+// instruction order, and joined into one swap, whatever order the inner
+// instruction groups and the swap events come in
+func TestThreeHopRouteOrder(t *testing.T) {
+	raydium := &solanaswapgo.SwapInfo{TokenInMint: solana.SolMint, TokenInAmount: 1_000_000_000, TokenInDecimals: 9, TokenOutMint: usdcMint, TokenOutAmount: 150_120_450, TokenOutDecimals: 6}
+	orca := &solanaswapgo.SwapInfo{TokenInMint: usdcMint, TokenInAmount: 150_120_450, TokenInDecimals: 6, TokenOutMint: usdtMint, TokenOutAmount: 150_087_311, TokenOutDecimals: 6}
+	meteora := &solanaswapgo.SwapInfo{TokenInMint: usdtMint, TokenInAmount: 150_087_311, TokenInDecimals: 6, TokenOutMint: jupMint, TokenOutAmount: 183_402_117, TokenOutDecimals: 6}
+
+	for _, reverse := range []bool{false, true} {
+		tx := loadFixture(t, "jupiter_3hop_route.json")
+		if reverse {
+			slices.Reverse(tx.Meta.InnerInstructions)
+		}
+		legs := []*solanaswapgo.SwapInfo{meteora, raydium, orca}
+
+		sorted, calls := orderLegs(tx, legs)
+		wantPrograms := []solana.PublicKey{raydiumAMM, whirlpool, dlmm}
+		for i, call := range calls {
+			if call == nil {
+				t.Fatalf("reverse=%v: hop %d has no instruction", reverse, i)
+			}
+			if !call.ProgramID.Equals(wantPrograms[i]) {
+				t.Errorf("reverse=%v: hop %d is on %s, want %s", reverse, i, call.ProgramID, wantPrograms[i])
+			}
+		}
+		if !slices.Equal(sorted, []*solanaswapgo.SwapInfo{raydium, orca, meteora}) {
+			t.Fatalf("reverse=%v: hops out of order", reverse)
+		}
+		for i, mint := range []solana.PublicKey{usdcMint, usdtMint} {
+			if !sorted[i].TokenOutMint.Equals(mint) || !sorted[i+1].TokenInMint.Equals(mint) {
+				t.Errorf("reverse=%v: intermediate mint %d is %s -> %s, want %s", reverse, i, sorted[i].TokenOutMint, sorted[i+1].TokenInMint, mint)
+			}
+		}
+
+		txData, err := newTransactionData(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		swaps := swapsFromLegs(tx, txData, legs)
+		if len(swaps) != 1 {
+			t.Fatalf("reverse=%v: got %d swaps, want the route as one", reverse, len(swaps))
+		}
+		s := swaps[0]
+		if !s.TokenInMint.Equals(solana.SolMint) || s.TokenInAmount != 1_000_000_000 {
+			t.Errorf("reverse=%v: in %d of %s, want 1000000000 of wSOL", reverse, s.TokenInAmount, s.TokenInMint)
+		}
+		if !s.TokenOutMint.Equals(jupMint) || s.TokenOutAmount != 183_402_117 {
+			t.Errorf("reverse=%v: out %d of %s, want 183402117 of JUP", reverse, s.TokenOutAmount, s.TokenOutMint)
+		}
+		if s.ProgramID == nil || !s.ProgramID.Equals(jupiter) {
+			t.Errorf("reverse=%v: credited to %v, want Jupiter", reverse, s.ProgramID)
+		}
+	}
+}
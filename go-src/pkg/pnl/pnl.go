@@ -0,0 +1,135 @@
+// Package pnl scores wallets' closed trades with the usual trader
+// performance metrics.
+package pnl
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/portfolio"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+)
+
+// Trade is a closed position: a sale of a token matched against the lots
+// the same wallet bought it in
+type Trade struct {
+	Wallet    solana.PublicKey `json:"wallet"`
+	Signature solana.Signature `json:"signature"`
+	Mint      solana.PublicKey `json:"mint"`
+	Symbol    string           `json:"symbol,omitempty"`
+	Quantity  float64          `json:"quantity"`
+
+	CostUSD     float64 `json:"cost_usd"`
+	ProceedsUSD float64 `json:"proceeds_usd"`
+	PnL         float64 `json:"pnl"`
+}
+
+// Trades returns the closed trades in a wallet's ledger, oldest first. Only
+// the part of each sale matched to a purchase in the ledger counts, and
+// stablecoins are left out since at a fixed $1 they never gain or lose.
+func Trades(wallet solana.PublicKey, ledger *portfolio.Ledger) []Trade {
+	var trades []Trade
+	for _, d := range ledger.Disposals() {
+		matched := d.Quantity - d.UnmatchedQuantity
+		if matched <= 0 || tokenmetadata.IsStablecoin(d.Mint) {
+			continue
+		}
+		// The unmatched part's cost is taken to equal its proceeds
+		unmatchedProceeds := d.ProceedsUSD * d.UnmatchedQuantity / d.Quantity
+		trades = append(trades, Trade{
+			Wallet:      wallet,
+			Signature:   d.Signature,
+			Mint:        d.Mint,
+			Symbol:      d.Symbol,
+			Quantity:    matched,
+			CostUSD:     d.CostBasisUSD - unmatchedProceeds,
+			ProceedsUSD: d.ProceedsUSD - unmatchedProceeds,
+			PnL:         d.RealizedPnL,
+		})
+	}
+	return trades
+}
+
+// TradeMetrics summarises a set of closed trades
+type TradeMetrics struct {
+	TotalTrades   int `json:"total_trades"`
+	WinningTrades int `json:"winning_trades"`
+	LosingTrades  int `json:"losing_trades"`
+
+	// GrossLoss is positive: the sum of the losing trades' losses
+	GrossProfit float64 `json:"gross_profit"`
+	GrossLoss   float64 `json:"gross_loss"`
+	NetPnL      float64 `json:"net_pnl"`
+
+	// GrossProfit / GrossLoss; nil when there were no losses to divide by
+	ProfitFactor *float64 `json:"profit_factor"`
+	// WinningTrades / TotalTrades, 0 with no trades
+	WinRate float64 `json:"win_rate"`
+}
+
+// ComputeTradeMetrics scores trades. A trade that broke even exactly is
+// neither a win nor a loss but still counts towards the total.
+func ComputeTradeMetrics(trades []Trade) *TradeMetrics {
+	m := &TradeMetrics{TotalTrades: len(trades)}
+	for _, t := range trades {
+		switch {
+		case t.PnL > 0:
+			m.WinningTrades++
+			m.GrossProfit += t.PnL
+		case t.PnL < 0:
+			m.LosingTrades++
+			m.GrossLoss -= t.PnL
+		}
+	}
+	m.NetPnL = m.GrossProfit - m.GrossLoss
+	if m.GrossLoss > 0 {
+		factor := m.GrossProfit / m.GrossLoss
+		m.ProfitFactor = &factor
+	}
+	if m.TotalTrades > 0 {
+		m.WinRate = float64(m.WinningTrades) / float64(m.TotalTrades)
+	}
+	return m
+}
+
+// WalletMetrics is one wallet's TradeMetrics
+type WalletMetrics struct {
+	Wallet solana.PublicKey `json:"wallet"`
+	*TradeMetrics
+}
+
+// ByWallet replays each signer's swaps through a cost-basis ledger matching
+// sales with method, and scores the trades it closed. swaps must be priced
+// and oldest first. Wallets are ordered by net PnL, best first; those that
+// closed no trades are left out.
+func ByWallet(swaps []*model.SwapData, method portfolio.Method) []WalletMetrics {
+	ledgers := make(map[solana.PublicKey]*portfolio.Ledger)
+	for _, s := range swaps {
+		ledger, ok := ledgers[s.Signer]
+		if !ok {
+			ledger = portfolio.NewLedger(method)
+			ledgers[s.Signer] = ledger
+		}
+		ledger.Add(s)
+	}
+
+	var wallets []WalletMetrics
+	for wallet, ledger := range ledgers {
+		trades := Trades(wallet, ledger)
+		if len(trades) == 0 {
+			continue
+		}
+		wallets = append(wallets, WalletMetrics{Wallet: wallet, TradeMetrics: ComputeTradeMetrics(trades)})
+	}
+	slices.SortFunc(wallets, func(a, b WalletMetrics) int {
+		return cmp.Or(
+			cmp.Compare(b.NetPnL, a.NetPnL),
+			strings.Compare(a.Wallet.String(), b.Wallet.String()),
+		)
+	})
+	return wallets
+}
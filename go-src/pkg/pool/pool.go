@@ -0,0 +1,337 @@
+// Package pool finds the pool a swap traded against and reads how much
+// liquidity it holds.
+package pool
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"sync"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// Programs whose pools can be found and decoded
+var (
+	RaydiumAMMProgramID    = solana.MustPublicKeyFromBase58("675kPX9MHTjS2zt1qfr1NYHuzeLXfQM9H24wFSUt1Mp8")
+	RaydiumCPMMProgramID   = solana.MustPublicKeyFromBase58("CPMMoo8L3F4NbTegBCKVNunggL7H1ZpdTHKxQB5qKP1C")
+	RaydiumCLMMProgramID   = solana.MustPublicKeyFromBase58("CAMMCzo5YL8w4VFF8KVHrK22GGUsp5VTaW7grrKgrWqK")
+	OrcaWhirlpoolProgramID = solana.MustPublicKeyFromBase58("whirLbMiicVdio4qvUfM5KAg6Ct8VwpYzGff3uctyCc")
+)
+
+// ErrUnsupported is returned for pools owned by a program this package
+// can't decode
+var ErrUnsupported = errors.New("unsupported pool program")
+
+// swapInstruction identifies one program's swap instruction and where the
+// pool is among its accounts
+type swapInstruction struct {
+	program     solana.PublicKey
+	prefix      []byte
+	poolAccount int
+}
+
+var swapInstructions = []swapInstruction{
+	// swap_base_in and swap_base_out: token program, amm, ...
+	{RaydiumAMMProgramID, []byte{9}, 1},
+	{RaydiumAMMProgramID, []byte{11}, 1},
+	// payer, authority, amm config, pool state, ...
+	{RaydiumCPMMProgramID, txutil.AnchorDiscriminator("swap_base_input"), 3},
+	{RaydiumCPMMProgramID, txutil.AnchorDiscriminator("swap_base_output"), 3},
+	// payer, amm config, pool state, ...
+	{RaydiumCLMMProgramID, txutil.AnchorDiscriminator("swap"), 2},
+	{RaydiumCLMMProgramID, txutil.AnchorDiscriminator("swap_v2"), 2},
+	// token program, token authority, whirlpool, ...
+	{OrcaWhirlpoolProgramID, txutil.AnchorDiscriminator("swap"), 2},
+	// token program a, token program b, memo program, token authority, whirlpool, ...
+	{OrcaWhirlpoolProgramID, txutil.AnchorDiscriminator("swap_v2"), 4},
+}
+
+// Find returns the pool of the first swap instruction, top-level or inner,
+// on a supported program
+func Find(tx *rpc.GetTransactionResult) (solana.PublicKey, bool) {
+	ixs, err := txutil.Instructions(tx)
+	if err != nil {
+		return solana.PublicKey{}, false
+	}
+	for _, ix := range ixs {
+		if address, ok := FromInstruction(ix); ok {
+			return address, true
+		}
+	}
+	return solana.PublicKey{}, false
+}
+
+// FromInstruction returns the pool a swap instruction on a supported
+// program trades against
+func FromInstruction(ix txutil.Instruction) (solana.PublicKey, bool) {
+	for _, s := range swapInstructions {
+		if ix.ProgramID.Equals(s.program) && bytes.HasPrefix(ix.Data, s.prefix) && s.poolAccount < len(ix.Accounts) {
+			return ix.Accounts[s.poolAccount], true
+		}
+	}
+	return solana.PublicKey{}, false
+}
+
+// State is what a pool holds
+type State struct {
+	Address   solana.PublicKey
+	ProgramID solana.PublicKey
+	MintA     solana.PublicKey
+	MintB     solana.PublicKey
+
+	// Reserve token accounts; zero for CLMMs, whose liquidity is read from
+	// the pool itself
+	VaultA solana.PublicKey
+	VaultB solana.PublicKey
+
+	// Raw token amounts: the vault balances for AMMs, or for CLMMs the
+	// virtual reserves backing the active tick's liquidity
+	ReserveA float64
+	ReserveB float64
+}
+
+// Account offsets of the fields State is read from
+const (
+	// Raydium AMM v4 AmmInfo
+	ammCoinVaultOffset = 336
+	ammPCVaultOffset   = 368
+	ammCoinMintOffset  = 400
+	ammPCMintOffset    = 432
+
+	// Raydium CPMM PoolState
+	cpmmVault0Offset = 72
+	cpmmVault1Offset = 104
+	cpmmMint0Offset  = 168
+	cpmmMint1Offset  = 200
+
+	// Raydium CLMM PoolState
+	clmmMint0Offset     = 73
+	clmmMint1Offset     = 105
+	clmmLiquidityOffset = 237
+	clmmSqrtPriceOffset = 253
+
+	// Whirlpool
+	whirlpoolLiquidityOffset = 49
+	whirlpoolSqrtPriceOffset = 65
+	whirlpoolMintAOffset     = 101
+	whirlpoolMintBOffset     = 181
+
+	// SPL token account amount
+	tokenAmountOffset = 64
+)
+
+// Cache fetches pool states, keeping every result for the life of the
+// cache so each pool is read at most once per run
+type Cache struct {
+	mu    sync.Mutex
+	cache map[solana.PublicKey]result
+}
+
+type result struct {
+	state *State
+	err   error
+}
+
+// NewCache returns an empty cache
+func NewCache() *Cache {
+	return &Cache{cache: make(map[solana.PublicKey]result)}
+}
+
+// State returns the pool's state, fetching it on first use
+func (c *Cache) State(ctx context.Context, rpcClient *rpc.Client, address solana.PublicKey) (*State, error) {
+	c.mu.Lock()
+	cached, ok := c.cache[address]
+	c.mu.Unlock()
+	if ok {
+		return cached.state, cached.err
+	}
+
+	state, err := fetch(ctx, rpcClient, address)
+	// Don't cache failures that were only the caller giving up
+	if ctx.Err() == nil {
+		c.mu.Lock()
+		c.cache[address] = result{state: state, err: err}
+		c.mu.Unlock()
+	}
+	return state, err
+}
+
+// fetch reads and decodes a pool account, and for AMMs its vaults
+func fetch(ctx context.Context, rpcClient *rpc.Client, address solana.PublicKey) (*State, error) {
+	account, err := rpcClient.GetAccountInfo(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("fetching pool %s: %w", address, err)
+	}
+	if account.Value == nil || account.Value.Data == nil {
+		return nil, fmt.Errorf("pool %s is empty", address)
+	}
+	data := account.Value.Data.GetBinary()
+	state := &State{Address: address, ProgramID: account.Value.Owner}
+	key := func(offset int) solana.PublicKey {
+		return solana.PublicKeyFromBytes(data[offset : offset+32])
+	}
+
+	switch owner := account.Value.Owner; {
+	case owner.Equals(RaydiumAMMProgramID) && len(data) >= ammPCMintOffset+32:
+		state.VaultA, state.VaultB = key(ammCoinVaultOffset), key(ammPCVaultOffset)
+		state.MintA, state.MintB = key(ammCoinMintOffset), key(ammPCMintOffset)
+	case owner.Equals(RaydiumCPMMProgramID) && len(data) >= cpmmMint1Offset+32:
+		state.VaultA, state.VaultB = key(cpmmVault0Offset), key(cpmmVault1Offset)
+		state.MintA, state.MintB = key(cpmmMint0Offset), key(cpmmMint1Offset)
+	case owner.Equals(RaydiumCLMMProgramID) && len(data) >= clmmSqrtPriceOffset+16:
+		state.MintA, state.MintB = key(clmmMint0Offset), key(clmmMint1Offset)
+		state.ReserveA, state.ReserveB = virtualReserves(data[clmmLiquidityOffset:], data[clmmSqrtPriceOffset:])
+		return state, nil
+	case owner.Equals(OrcaWhirlpoolProgramID) && len(data) >= whirlpoolMintBOffset+32:
+		state.MintA, state.MintB = key(whirlpoolMintAOffset), key(whirlpoolMintBOffset)
+		state.ReserveA, state.ReserveB = virtualReserves(data[whirlpoolLiquidityOffset:], data[whirlpoolSqrtPriceOffset:])
+		return state, nil
+	default:
+		return nil, fmt.Errorf("pool %s: %w %s", address, ErrUnsupported, owner)
+	}
+
+	vaults, err := rpcClient.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{state.VaultA, state.VaultB}, &rpc.GetMultipleAccountsOpts{Encoding: solana.EncodingBase64})
+	if err != nil {
+		return nil, fmt.Errorf("fetching vaults of pool %s: %w", address, err)
+	}
+	reserve := func(i int) (float64, error) {
+		if i >= len(vaults.Value) || vaults.Value[i] == nil || vaults.Value[i].Data == nil {
+			return 0, fmt.Errorf("vault %d of pool %s is empty", i, address)
+		}
+		vault := vaults.Value[i].Data.GetBinary()
+		if len(vault) < tokenAmountOffset+8 {
+			return 0, fmt.Errorf("vault %d of pool %s is not a token account", i, address)
+		}
+		return float64(binary.LittleEndian.Uint64(vault[tokenAmountOffset:])), nil
+	}
+	if state.ReserveA, err = reserve(0); err != nil {
+		return nil, err
+	}
+	if state.ReserveB, err = reserve(1); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// virtualReserves converts a CLMM's active liquidity L and Q64.64 square
+// root price into the constant-product reserves with the same depth at the
+// current price: L/sqrt(P) of token A and L*sqrt(P) of token B
+func virtualReserves(liquidity, sqrtPriceX64 []byte) (a, b float64) {
+	l, _ := u128(liquidity).Float64()
+	sqrtPrice, _ := u128(sqrtPriceX64).Float64()
+	sqrtPrice = math.Ldexp(sqrtPrice, -64)
+	if sqrtPrice == 0 {
+		return 0, 0
+	}
+	return l / sqrtPrice, l * sqrtPrice
+}
+
+// u128 decodes a little-endian u128
+func u128(data []byte) *big.Float {
+	n := new(big.Int).SetUint64(binary.LittleEndian.Uint64(data[8:16]))
+	n.Lsh(n, 64).Or(n, new(big.Int).SetUint64(binary.LittleEndian.Uint64(data[:8])))
+	return new(big.Float).SetInt(n)
+}
+
+// AtSwap returns the pool's reserves as the swap left them where the
+// transaction shows them: an AMM's vaults are among its token balances.
+// Otherwise, and for CLMMs, the fetched state is used.
+func (s *State) AtSwap(tx *rpc.GetTransactionResult) (reserveA, reserveB float64) {
+	reserveA, reserveB = s.ReserveA, s.ReserveB
+	if tx.Meta == nil || s.VaultA.IsZero() {
+		return reserveA, reserveB
+	}
+	keys, err := txutil.AccountKeys(tx)
+	if err != nil {
+		return reserveA, reserveB
+	}
+	for _, b := range tx.Meta.PostTokenBalances {
+		if int(b.AccountIndex) >= len(keys) || b.UiTokenAmount == nil {
+			continue
+		}
+		amount, err := strconv.ParseUint(b.UiTokenAmount.Amount, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch keys[b.AccountIndex] {
+		case s.VaultA:
+			reserveA = float64(amount)
+		case s.VaultB:
+			reserveB = float64(amount)
+		}
+	}
+	return reserveA, reserveB
+}
+
+// LiquidityUSD values a pool's reserves at the prices the swap implies:
+// the swap's own USD values if it has been priced, else stablecoins at $1
+// and the other token at the swap's rate against it. It reports false if
+// the pool isn't the swap's pair or neither leg could be priced.
+func LiquidityUSD(s *State, swap *model.SwapData, reserveA, reserveB float64) (float64, bool) {
+	if swap.AmountInUI <= 0 || swap.AmountOutUI <= 0 {
+		return 0, false
+	}
+	var priceIn, priceOut float64
+	switch {
+	case swap.ValueInUSD > 0:
+		priceIn = swap.ValueInUSD / swap.AmountInUI
+		priceOut = swap.ValueInUSD / swap.AmountOutUI
+	case tokenmetadata.IsStablecoin(swap.TokenInMint):
+		priceIn = 1
+		priceOut = swap.AmountInUI / swap.AmountOutUI
+	case tokenmetadata.IsStablecoin(swap.TokenOutMint):
+		priceOut = 1
+		priceIn = swap.AmountOutUI / swap.AmountInUI
+	default:
+		return 0, false
+	}
+	value := func(reserve float64, decimals uint8, price float64) float64 {
+		return reserve / math.Pow10(int(decimals)) * price
+	}
+
+	switch {
+	case s.MintA.Equals(swap.TokenInMint) && s.MintB.Equals(swap.TokenOutMint):
+		return value(reserveA, swap.TokenInDecimals, priceIn) + value(reserveB, swap.TokenOutDecimals, priceOut), true
+	case s.MintA.Equals(swap.TokenOutMint) && s.MintB.Equals(swap.TokenInMint):
+		return value(reserveA, swap.TokenOutDecimals, priceOut) + value(reserveB, swap.TokenInDecimals, priceIn), true
+	default:
+		return 0, false
+	}
+}
+
+// MarketImpactBps estimates the swap's price impact on the pool, in basis
+// points, with the constant-product formula
+// impact = 2*amountIn / (2*reserveIn + amountIn). AMM reserves are as
+// AtSwap returns them, after the swap, so the input is taken back out;
+// CLMMs use their virtual reserves, which only hold within the active
+// tick. It reports false if the pool isn't the swap's pair.
+func MarketImpactBps(s *State, swap *model.SwapData, reserveA, reserveB float64) (int, bool) {
+	var reserveIn float64
+	switch {
+	case s.MintA.Equals(swap.TokenInMint) && s.MintB.Equals(swap.TokenOutMint):
+		reserveIn = reserveA
+	case s.MintA.Equals(swap.TokenOutMint) && s.MintB.Equals(swap.TokenInMint):
+		reserveIn = reserveB
+	default:
+		return 0, false
+	}
+	amountIn := float64(swap.TokenInAmount)
+	if !s.VaultA.IsZero() {
+		reserveIn -= amountIn
+	}
+	if reserveIn <= 0 || amountIn <= 0 {
+		return 0, false
+	}
+	return int(math.Round(2 * amountIn / (2*reserveIn + amountIn) * 10_000)), true
+}
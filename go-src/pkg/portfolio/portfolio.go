@@ -0,0 +1,287 @@
+// Package portfolio builds a cost-basis ledger from a wallet's swaps.
+package portfolio
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+)
+
+// Method chooses which lots a sale is matched against
+type Method string
+
+const (
+	// Oldest lots first
+	FIFO Method = "fifo"
+	// Newest lots first
+	LIFO Method = "lifo"
+	// Most expensive lots first, which minimises realized gains
+	HIFO Method = "hifo"
+)
+
+// Methods lists the values accepted by ParseMethod
+var Methods = []Method{FIFO, LIFO, HIFO}
+
+// ParseMethod parses a cost-basis method name
+func ParseMethod(s string) (Method, error) {
+	m := Method(s)
+	if !slices.Contains(Methods, m) {
+		return "", fmt.Errorf("unknown cost-basis method %q (want one of %v)", s, Methods)
+	}
+	return m, nil
+}
+
+// lot is a quantity of a token bought in one swap
+type lot struct {
+	quantity    float64
+	unitCostUSD float64
+	acquired    time.Time
+}
+
+// Disposal is the realized gain or loss on the input leg of one swap
+type Disposal struct {
+	Signature solana.Signature `json:"signature"`
+	Time      time.Time        `json:"time"`
+	Mint      solana.PublicKey `json:"mint"`
+	Symbol    string           `json:"symbol,omitempty"`
+	Quantity  float64          `json:"quantity"`
+
+	ProceedsUSD  float64 `json:"proceeds_usd"`
+	CostBasisUSD float64 `json:"cost_basis_usd"`
+	RealizedPnL  float64 `json:"realized_pnl"`
+
+	// Quantity sold that wasn't bought in the ledger's history (e.g. it was
+	// transferred in, or bought before the scan window). Its cost basis is
+	// unknown, so it is taken to equal its share of the proceeds and
+	// realizes nothing.
+	UnmatchedQuantity float64 `json:"unmatched_quantity,omitempty"`
+}
+
+// Holding is the ledger's position in one token
+type Holding struct {
+	Mint   solana.PublicKey `json:"mint"`
+	Symbol string           `json:"symbol,omitempty"`
+
+	CurrentQuantity  float64 `json:"current_quantity"`
+	AverageCostBasis float64 `json:"average_cost_basis"`
+	CostBasisUSD     float64 `json:"cost_basis_usd"`
+
+	// Zero, and UnrealizedPnL unset, if the token has no current price
+	CurrentPriceUSD float64 `json:"current_price_usd"`
+	UnrealizedPnL   float64 `json:"unrealized_pnl"`
+	RealizedPnL     float64 `json:"realized_pnl"`
+}
+
+// Ledger tracks the open lots of each token across a wallet's swaps. Swaps
+// must be added oldest first. Each swap has a single USD value (see
+// SwapValue) that is both the proceeds of the token sent and the cost of the
+// token received.
+type Ledger struct {
+	Method Method
+
+	lots      map[solana.PublicKey][]lot
+	realized  map[solana.PublicKey]float64
+	swaps     []*model.SwapData
+	disposals []Disposal
+}
+
+// NewLedger returns an empty ledger matching sales with method
+func NewLedger(method Method) *Ledger {
+	return &Ledger{
+		Method:   method,
+		lots:     make(map[solana.PublicKey][]lot),
+		realized: make(map[solana.PublicKey]float64),
+	}
+}
+
+// Add records a swap. Failed swaps are ignored.
+func (l *Ledger) Add(s *model.SwapData) {
+	if s.Failed {
+		return
+	}
+	l.swaps = append(l.swaps, s)
+
+	value := SwapValue(s)
+	if s.AmountInUI > 0 {
+		l.dispose(s, value)
+	}
+	if s.AmountOutUI > 0 {
+		l.lots[s.TokenOutMint] = append(l.lots[s.TokenOutMint], lot{
+			quantity:    s.AmountOutUI,
+			unitCostUSD: value / s.AmountOutUI,
+			acquired:    s.BlockTime,
+		})
+	}
+}
+
+// SwapValue is the USD value of a swap, taken from its most reliably priced
+// leg: a stablecoin, then SOL, then whichever leg was priced, preferring the
+// received one. Pricing the other leg at the same value means a token's
+// cost and proceeds reflect what the wallet actually paid and got for it.
+func SwapValue(s *model.SwapData) float64 {
+	switch {
+	case tokenmetadata.IsStablecoin(s.TokenInMint) && s.ValueInUSD > 0:
+		return s.ValueInUSD
+	case tokenmetadata.IsStablecoin(s.TokenOutMint) && s.ValueOutUSD > 0:
+		return s.ValueOutUSD
+	case s.TokenInMint.Equals(solana.SolMint) && s.ValueInUSD > 0:
+		return s.ValueInUSD
+	case s.TokenOutMint.Equals(solana.SolMint) && s.ValueOutUSD > 0:
+		return s.ValueOutUSD
+	case s.ValueOutUSD > 0:
+		return s.ValueOutUSD
+	default:
+		return s.ValueInUSD
+	}
+}
+
+// dispose matches the input leg of s against open lots
+func (l *Ledger) dispose(s *model.SwapData, proceeds float64) {
+	d := Disposal{
+		Signature:   s.Signature,
+		Time:        s.BlockTime,
+		Mint:        s.TokenInMint,
+		Symbol:      tokenmetadata.Symbol(s.TokenInMint),
+		Quantity:    s.AmountInUI,
+		ProceedsUSD: proceeds,
+	}
+
+	remaining := s.AmountInUI
+	lots := l.lots[s.TokenInMint]
+	for remaining > 0 && len(lots) > 0 {
+		i := l.next(lots)
+		used := min(remaining, lots[i].quantity)
+		d.CostBasisUSD += used * lots[i].unitCostUSD
+		remaining -= used
+		lots[i].quantity -= used
+		if lots[i].quantity <= 0 {
+			lots = slices.Delete(lots, i, i+1)
+		}
+	}
+	l.lots[s.TokenInMint] = lots
+	if remaining > 0 {
+		d.UnmatchedQuantity = remaining
+		d.CostBasisUSD += proceeds * remaining / s.AmountInUI
+	}
+
+	d.RealizedPnL = d.ProceedsUSD - d.CostBasisUSD
+	l.realized[s.TokenInMint] += d.RealizedPnL
+	l.disposals = append(l.disposals, d)
+}
+
+// next returns the index of the lot the method sells from next. lots are in
+// acquisition order.
+func (l *Ledger) next(lots []lot) int {
+	switch l.Method {
+	case LIFO:
+		return len(lots) - 1
+	case HIFO:
+		best := 0
+		for i, lt := range lots {
+			if lt.unitCostUSD > lots[best].unitCostUSD {
+				best = i
+			}
+		}
+		return best
+	default:
+		return 0
+	}
+}
+
+// Swaps returns the swaps added so far, oldest first
+func (l *Ledger) Swaps() []*model.SwapData {
+	return l.swaps
+}
+
+// Disposals returns the realized gain or loss of every sale, oldest first
+func (l *Ledger) Disposals() []Disposal {
+	return l.disposals
+}
+
+// Holdings returns every token with an open position or realized PnL,
+// valuing open positions at prices (USD per whole token)
+func (l *Ledger) Holdings(prices map[solana.PublicKey]float64) []*Holding {
+	mints := make(map[solana.PublicKey]struct{})
+	for mint := range l.lots {
+		mints[mint] = struct{}{}
+	}
+	for mint := range l.realized {
+		mints[mint] = struct{}{}
+	}
+
+	holdings := make([]*Holding, 0, len(mints))
+	for mint := range mints {
+		h := &Holding{
+			Mint:        mint,
+			Symbol:      tokenmetadata.Symbol(mint),
+			RealizedPnL: l.realized[mint],
+		}
+		for _, lt := range l.lots[mint] {
+			h.CurrentQuantity += lt.quantity
+			h.CostBasisUSD += lt.quantity * lt.unitCostUSD
+		}
+		if h.CurrentQuantity > 0 {
+			h.AverageCostBasis = h.CostBasisUSD / h.CurrentQuantity
+		}
+		if p, ok := prices[mint]; ok {
+			h.CurrentPriceUSD = p
+			h.UnrealizedPnL = h.CurrentQuantity*p - h.CostBasisUSD
+		}
+		holdings = append(holdings, h)
+	}
+	slices.SortFunc(holdings, func(a, b *Holding) int {
+		return cmp.Or(
+			cmp.Compare(b.CostBasisUSD, a.CostBasisUSD),
+			cmp.Compare(a.Mint.String(), b.Mint.String()),
+		)
+	})
+	return holdings
+}
+
+// LatestPrices returns each token's unit price in the most recent priced
+// swap it appears in. swaps must be oldest first.
+func LatestPrices(swaps []*model.SwapData) map[solana.PublicKey]float64 {
+	prices := make(map[solana.PublicKey]float64)
+	for _, s := range swaps {
+		if s.ValueInUSD > 0 && s.AmountInUI > 0 {
+			prices[s.TokenInMint] = s.ValueInUSD / s.AmountInUI
+		}
+		if s.ValueOutUSD > 0 && s.AmountOutUI > 0 {
+			prices[s.TokenOutMint] = s.ValueOutUSD / s.AmountOutUI
+		}
+	}
+	return prices
+}
+
+// Report is the JSON output of the portfolio command
+type Report struct {
+	Wallet        solana.PublicKey `json:"wallet"`
+	Method        Method           `json:"cost_basis_method"`
+	Swaps         int              `json:"swaps"`
+	RealizedPnL   float64          `json:"realized_pnl"`
+	UnrealizedPnL float64          `json:"unrealized_pnl"`
+	Holdings      []*Holding       `json:"holdings"`
+	Disposals     []Disposal       `json:"disposals"`
+}
+
+// Report summarises the ledger for wallet, valuing holdings at prices
+func (l *Ledger) Report(wallet solana.PublicKey, prices map[solana.PublicKey]float64) *Report {
+	r := &Report{
+		Wallet:    wallet,
+		Method:    l.Method,
+		Swaps:     len(l.swaps),
+		Holdings:  l.Holdings(prices),
+		Disposals: l.disposals,
+	}
+	for _, h := range r.Holdings {
+		r.RealizedPnL += h.RealizedPnL
+		r.UnrealizedPnL += h.UnrealizedPnL
+	}
+	return r
+}
@@ -0,0 +1,177 @@
+// Package oracle reads token prices from Pyth's on-chain price feeds.
+package oracle
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+)
+
+// PushOracleProgramID owns Pyth's sponsored price feed accounts, one per
+// feed ID at a PDA of (shard, feed ID)
+var PushOracleProgramID = solana.MustPublicKeyFromBase58("pythWSnswVUd12oZpeFP8e9CVaEqJg25g1Vtc2biRsT")
+
+// feedIDs maps mints to their Pyth USD price feed IDs
+var feedIDs = map[solana.PublicKey]string{
+	solana.SolMint:     "ef0d8b6fda2ceba41da15d4095d1da392a0d2f8ed0c6c7bc0f4cfac8c280b56d",
+	tokenmetadata.USDC: "eaa020c61cc479712813461ce153894a96a6c00b21ed0cfc2798d1f9a9e9c94a",
+	tokenmetadata.USDT: "2b89b9dc8fdf9f34709a5b106b472f0f39bb6ca9ce04b0fd7f2e971688e2e53b",
+	solana.MustPublicKeyFromBase58("JUPyiwrYJFskUPiHa7hkeR8VUtAeFoSYbKedZNsDvCN"):  "0a0408d619e9380abad35060f9192039ed5042fa6f82301d0e48bb52be830996",
+	solana.MustPublicKeyFromBase58("DezXAZ8z7PnrnRJjz3wXBoRgixCa6xjnB7YaB1pPB263"): "72b021217ca3fe68922a19aaf990109cb9d84e9ad004b4d2025ad6f529314419",
+}
+
+// ErrNoFeed is returned for mints without a known price feed
+var ErrNoFeed = errors.New("no Pyth price feed for mint")
+
+// FeedAccount returns the sponsored price feed account for a mint
+func FeedAccount(mint solana.PublicKey) (solana.PublicKey, error) {
+	id, ok := feedIDs[mint]
+	if !ok {
+		return solana.PublicKey{}, ErrNoFeed
+	}
+	feedID, err := hex.DecodeString(id)
+	if err != nil {
+		return solana.PublicKey{}, err
+	}
+	// Sponsored feeds are all on shard 0
+	account, _, err := solana.FindProgramAddress([][]byte{{0, 0}, feedID}, PushOracleProgramID)
+	return account, err
+}
+
+// Price is one feed's latest update, in USD
+type Price struct {
+	Price float64 `json:"price"`
+	// Confidence interval around Price
+	Conf float64 `json:"conf"`
+	// Exponentially weighted moving average Pyth keeps alongside the
+	// price, its on-chain TWAP
+	EMAPrice    float64   `json:"ema_price"`
+	PublishTime time.Time `json:"publish_time"`
+}
+
+// MaxStaleness is how far apart a swap and a price update can be for the
+// update to count as the price at the swap
+const MaxStaleness = time.Minute
+
+// At returns the price if it was published within MaxStaleness of t
+func (p Price) At(t time.Time) (float64, bool) {
+	gap := p.PublishTime.Sub(t)
+	if gap < -MaxStaleness || gap > MaxStaleness {
+		return 0, false
+	}
+	return p.Price, true
+}
+
+// PriceUpdateV2 layout: discriminator, write authority, then a verification
+// level enum whose Partial variant carries a signature count
+const (
+	verificationLevelOffset = 8 + 32
+	verificationPartial     = 0
+	// feed ID, price, conf, exponent, publish time, previous publish time,
+	// EMA price, EMA conf
+	priceMessageLen = 32 + 8 + 8 + 4 + 8 + 8 + 8 + 8
+)
+
+// decode reads the price message from a PriceUpdateV2 account
+func decode(data []byte) (Price, error) {
+	if len(data) <= verificationLevelOffset {
+		return Price{}, errors.New("price update account too short")
+	}
+	offset := verificationLevelOffset + 1
+	if data[verificationLevelOffset] == verificationPartial {
+		offset++
+	}
+	if len(data) < offset+priceMessageLen {
+		return Price{}, errors.New("price update account too short")
+	}
+	msg := data[offset+32:]
+	price := int64(binary.LittleEndian.Uint64(msg[0:8]))
+	conf := binary.LittleEndian.Uint64(msg[8:16])
+	exponent := int32(binary.LittleEndian.Uint32(msg[16:20]))
+	publishTime := int64(binary.LittleEndian.Uint64(msg[20:28]))
+	emaPrice := int64(binary.LittleEndian.Uint64(msg[36:44]))
+
+	scale := math.Pow10(int(exponent))
+	return Price{
+		Price:       float64(price) * scale,
+		Conf:        float64(conf) * scale,
+		EMAPrice:    float64(emaPrice) * scale,
+		PublishTime: time.Unix(publishTime, 0).UTC(),
+	}, nil
+}
+
+// Fetcher reads prices for mints, reusing each feed's last read for
+// CacheFor so a stream of swaps doesn't fetch the same feed every time
+type Fetcher struct {
+	CacheFor time.Duration
+
+	mu    sync.Mutex
+	cache map[solana.PublicKey]cached
+}
+
+type cached struct {
+	price   Price
+	fetched time.Time
+}
+
+// NewFetcher returns a Fetcher caching reads for 2 seconds, about Pyth's
+// update interval
+func NewFetcher() *Fetcher {
+	return &Fetcher{CacheFor: 2 * time.Second, cache: make(map[solana.PublicKey]cached)}
+}
+
+// Prices returns the current price of each mint that has a feed, reading
+// every uncached feed in one GetMultipleAccounts call. Mints without a
+// feed, or whose feed account is missing, are left out.
+func (f *Fetcher) Prices(ctx context.Context, rpcClient *rpc.Client, mints ...solana.PublicKey) (map[solana.PublicKey]Price, error) {
+	prices := make(map[solana.PublicKey]Price, len(mints))
+	var missing []solana.PublicKey
+	var accounts []solana.PublicKey
+	f.mu.Lock()
+	for _, mint := range mints {
+		if c, ok := f.cache[mint]; ok && time.Since(c.fetched) < f.CacheFor {
+			prices[mint] = c.price
+			continue
+		}
+		account, err := FeedAccount(mint)
+		if err != nil {
+			continue
+		}
+		missing = append(missing, mint)
+		accounts = append(accounts, account)
+	}
+	f.mu.Unlock()
+	if len(accounts) == 0 {
+		return prices, nil
+	}
+
+	result, err := rpcClient.GetMultipleAccountsWithOpts(ctx, accounts, &rpc.GetMultipleAccountsOpts{Encoding: solana.EncodingBase64})
+	if err != nil {
+		return nil, fmt.Errorf("fetching price feeds: %w", err)
+	}
+	now := time.Now()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, mint := range missing {
+		if i >= len(result.Value) || result.Value[i] == nil || result.Value[i].Data == nil {
+			continue
+		}
+		price, err := decode(result.Value[i].Data.GetBinary())
+		if err != nil {
+			return nil, fmt.Errorf("price feed %s: %w", accounts[i], err)
+		}
+		prices[mint] = price
+		f.cache[mint] = cached{price: price, fetched: now}
+	}
+	return prices, nil
+}
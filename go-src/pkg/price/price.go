@@ -0,0 +1,112 @@
+// Package price fills in USD values on parsed swaps.
+package price
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+)
+
+// Enricher sets VolumeUSD on each swap it can price
+type Enricher interface {
+	Enrich(ctx context.Context, swaps []*model.SwapData) error
+}
+
+// StablecoinEnricher prices swaps using only the batch itself: stablecoins
+// are worth $1, and any other token is worth the median rate it traded at
+// against an already-priced token. Prices propagate outwards from the
+// stablecoins (e.g. USDC -> SOL -> tokens only traded against SOL), so it
+// needs no external API but can't price tokens with no path to a stablecoin.
+type StablecoinEnricher struct{}
+
+// maxPricingRounds bounds how many hops from a stablecoin a price can propagate
+const maxPricingRounds = 3
+
+func (StablecoinEnricher) Enrich(_ context.Context, swaps []*model.SwapData) error {
+	prices := make(map[solana.PublicKey]float64)
+	for _, s := range swaps {
+		for _, mint := range []solana.PublicKey{s.TokenInMint, s.TokenOutMint} {
+			if tokenmetadata.IsStablecoin(mint) {
+				prices[mint] = 1
+			}
+		}
+	}
+
+	for range maxPricingRounds {
+		rates := make(map[solana.PublicKey][]float64)
+		for _, s := range swaps {
+			if s.AmountInUI <= 0 || s.AmountOutUI <= 0 {
+				continue
+			}
+			inPrice, inOK := prices[s.TokenInMint]
+			outPrice, outOK := prices[s.TokenOutMint]
+			switch {
+			case inOK && !outOK:
+				rates[s.TokenOutMint] = append(rates[s.TokenOutMint], s.AmountInUI*inPrice/s.AmountOutUI)
+			case outOK && !inOK:
+				rates[s.TokenInMint] = append(rates[s.TokenInMint], s.AmountOutUI*outPrice/s.AmountInUI)
+			}
+		}
+		if len(rates) == 0 {
+			break
+		}
+		for mint, r := range rates {
+			prices[mint] = Median(r)
+		}
+	}
+
+	for _, s := range swaps {
+		inPrice, inOK := prices[s.TokenInMint]
+		outPrice, outOK := prices[s.TokenOutMint]
+		setValues(s, inPrice, inOK, outPrice, outOK)
+	}
+	return nil
+}
+
+// setValues fills in the USD fields of a swap from its legs' unit prices
+func setValues(s *model.SwapData, inPrice float64, inOK bool, outPrice float64, outOK bool) {
+	if inOK {
+		s.ValueInUSD = s.AmountInUI * inPrice
+	}
+	if outOK {
+		s.ValueOutUSD = s.AmountOutUI * outPrice
+	}
+	if inOK {
+		s.VolumeUSD = s.ValueInUSD
+	} else {
+		s.VolumeUSD = s.ValueOutUSD
+	}
+}
+
+// Median returns the middle value, averaging the two middle ones for an
+// even count
+func Median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// FilterByMinVolume returns the swaps whose VolumeUSD is at least minUSD,
+// largest first. Swaps that couldn't be priced have no volume and are
+// always left out.
+func FilterByMinVolume(swaps []*model.SwapData, minUSD float64) []*model.SwapData {
+	var large []*model.SwapData
+	for _, s := range swaps {
+		if s.VolumeUSD > 0 && s.VolumeUSD >= minUSD {
+			large = append(large, s)
+		}
+	}
+	slices.SortStableFunc(large, func(a, b *model.SwapData) int {
+		return cmp.Compare(b.VolumeUSD, a.VolumeUSD)
+	})
+	return large
+}
@@ -0,0 +1,72 @@
+package pyth
+
+import (
+	"context"
+	"fmt"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+)
+
+// BucketSlots is how close in slots swaps are to share one price lookup,
+// about a minute, since each lookup costs several RPC calls
+const BucketSlots = 150
+
+// HistoricalEnricher prices swaps between SOL and a token other than a
+// stablecoin at Account's SOL/USD price as of the swap's slot, rather than
+// the batch's own median SOL price that price.StablecoinEnricher uses.
+// Both legs are valued at the SOL leg's worth. Other swaps are left as
+// they are, so run it after StablecoinEnricher.
+type HistoricalEnricher struct {
+	RPC     *rpc.Client
+	Account solana.PublicKey
+}
+
+// Enrich leaves swaps whose price couldn't be fetched as they were, only
+// failing if the context ends or no lookup succeeded at all
+func (e HistoricalEnricher) Enrich(ctx context.Context, swaps []*model.SwapData) error {
+	prices := make(map[uint64]float64)
+	failed := make(map[uint64]error)
+	var lastErr error
+	for _, s := range swaps {
+		var solAmount float64
+		var other solana.PublicKey
+		switch {
+		case s.TokenInMint.Equals(solana.SolMint):
+			solAmount, other = s.AmountInUI, s.TokenOutMint
+		case s.TokenOutMint.Equals(solana.SolMint):
+			solAmount, other = s.AmountOutUI, s.TokenInMint
+		default:
+			continue
+		}
+		if solAmount <= 0 || tokenmetadata.IsStablecoin(other) {
+			continue
+		}
+		bucket := s.Slot / BucketSlots
+		p, ok := prices[bucket]
+		if !ok {
+			if _, ok := failed[bucket]; ok {
+				continue
+			}
+			price, err := FetchHistoricalPrice(ctx, e.RPC, e.Account, s.Slot)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				failed[bucket], lastErr = err, err
+				continue
+			}
+			p = price.Price
+			prices[bucket] = p
+		}
+		value := solAmount * p
+		s.ValueInUSD, s.ValueOutUSD, s.VolumeUSD = value, value, value
+	}
+	if len(prices) == 0 && lastErr != nil {
+		return fmt.Errorf("no historical SOL price could be read: %w", lastErr)
+	}
+	return nil
+}
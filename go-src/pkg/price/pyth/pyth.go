@@ -0,0 +1,296 @@
+// Package pyth reads past Pyth prices back out of the transactions that
+// updated a price account.
+package pyth
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/price/oracle"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// LegacyOracleProgramID owns Pyth's original price accounts, which
+// publishers update with their own quotes
+var LegacyOracleProgramID = solana.MustPublicKeyFromBase58("FsJ3A3u2vn5cTVofAjvy6y5kwABJAqYWpe4975bi2epH")
+
+// SOLUSD is the legacy oracle's SOL/USD price account
+var SOLUSD = solana.MustPublicKeyFromBase58("H6ARHf6YXhGYeQfUzQNGk6rDNnLBQKrenN712K4AQJEG")
+
+// PythPrice is a price account's value as of an update transaction
+type PythPrice struct {
+	Price float64 `json:"price"`
+	// Confidence interval around Price
+	Conf        float64   `json:"conf"`
+	PublishTime time.Time `json:"publish_time"`
+
+	// The update it was read from
+	Slot      uint64           `json:"slot"`
+	Signature solana.Signature `json:"signature"`
+}
+
+// updateSearchLimit is how many of the account's transactions before the
+// slot are checked for a price update, since not every transaction to
+// touch it is one
+const updateSearchLimit = 25
+
+// maxSkippedSlots bounds how far back FetchHistoricalPrice steps past
+// skipped slots to find a block to start from
+const maxSkippedSlots = 20
+
+// ErrNoUpdate is returned when no price update was found before the slot
+var ErrNoUpdate = errors.New("no price update found")
+
+// FetchHistoricalPrice returns the price account's value as of slot: the
+// last update to it before the slot's first transaction. RPC nodes only
+// serve an account's current state, so the update transaction is fetched
+// and the price decoded from its instruction instead. For push oracle
+// feeds, like oracle.FeedAccount's, that's the aggregate price Pyth
+// signed. Legacy accounts are updated by each publisher in turn, so for
+// those it's the last publisher's quote, normally within the confidence
+// interval of the aggregate.
+func FetchHistoricalPrice(ctx context.Context, rpcClient *rpc.Client, priceAccount solana.PublicKey, slot uint64) (*PythPrice, error) {
+	info, err := fetchAccountInfo(ctx, rpcClient, priceAccount)
+	if err != nil {
+		return nil, err
+	}
+	anchor, err := firstSignature(ctx, rpcClient, slot)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := updateSearchLimit
+	sigs, err := rpcClient.GetSignaturesForAddressWithOpts(ctx, priceAccount, &rpc.GetSignaturesForAddressOpts{
+		Before:     anchor,
+		Limit:      &limit,
+		Commitment: rpc.CommitmentConfirmed,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing updates to %s: %w", priceAccount, err)
+	}
+	var maxTxVersion uint64 = 0
+	for _, sig := range sigs {
+		if sig.Err != nil {
+			continue
+		}
+		tx, err := rpcClient.GetTransaction(ctx, sig.Signature, &rpc.GetTransactionOpts{
+			Commitment:                     rpc.CommitmentConfirmed,
+			MaxSupportedTransactionVersion: &maxTxVersion,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("fetching update %s: %w", sig.Signature, err)
+		}
+		price, ok, err := info.decodeUpdate(tx, priceAccount)
+		if err != nil {
+			return nil, fmt.Errorf("update %s: %w", sig.Signature, err)
+		}
+		if !ok {
+			continue
+		}
+		price.Slot, price.Signature = tx.Slot, sig.Signature
+		if price.PublishTime.IsZero() && tx.BlockTime != nil {
+			price.PublishTime = tx.BlockTime.Time().UTC()
+		}
+		return price, nil
+	}
+	return nil, fmt.Errorf("%w for %s in the %d transactions before slot %d", ErrNoUpdate, priceAccount, len(sigs), slot)
+}
+
+// firstSignature returns the first transaction signature in slot, or in
+// the closest produced slot before it
+func firstSignature(ctx context.Context, rpcClient *rpc.Client, slot uint64) (solana.Signature, error) {
+	rewards := false
+	var maxTxVersion uint64 = 0
+	var lastErr error
+	for s := slot; s+maxSkippedSlots > slot && s > 0; s-- {
+		block, err := rpcClient.GetBlockWithOpts(ctx, s, &rpc.GetBlockOpts{
+			TransactionDetails:             rpc.TransactionDetailsSignatures,
+			Rewards:                        &rewards,
+			Commitment:                     rpc.CommitmentConfirmed,
+			MaxSupportedTransactionVersion: &maxTxVersion,
+		})
+		if ctx.Err() != nil {
+			return solana.Signature{}, ctx.Err()
+		}
+		if err != nil {
+			// Skipped slots have no block
+			lastErr = err
+			continue
+		}
+		if len(block.Signatures) > 0 {
+			return block.Signatures[0], nil
+		}
+	}
+	if lastErr != nil {
+		return solana.Signature{}, fmt.Errorf("finding a block at or before slot %d: %w", slot, lastErr)
+	}
+	return solana.Signature{}, fmt.Errorf("no transactions in the %d slots up to %d", maxSkippedSlots, slot)
+}
+
+// accountInfo is what decoding a price account's updates needs to know
+type accountInfo struct {
+	owner solana.PublicKey
+	// Legacy accounts keep the exponent in the account rather than in
+	// each update
+	exponent int32
+}
+
+// accountInfos caches fetchAccountInfo for the run; neither the owner nor
+// the exponent of a price account changes
+var accountInfos = struct {
+	mu    sync.Mutex
+	cache map[solana.PublicKey]accountInfo
+}{cache: make(map[solana.PublicKey]accountInfo)}
+
+// Legacy price account layout: magic, version, account type, size, price
+// type, then the exponent
+const (
+	legacyMagic          = 0xa1b2c3d4
+	legacyTypePrice      = 3
+	legacyExponentOffset = 20
+)
+
+func fetchAccountInfo(ctx context.Context, rpcClient *rpc.Client, account solana.PublicKey) (accountInfo, error) {
+	accountInfos.mu.Lock()
+	info, ok := accountInfos.cache[account]
+	accountInfos.mu.Unlock()
+	if ok {
+		return info, nil
+	}
+
+	result, err := rpcClient.GetAccountInfo(ctx, account)
+	if err != nil {
+		return accountInfo{}, fmt.Errorf("fetching price account %s: %w", account, err)
+	}
+	if result.Value == nil || result.Value.Data == nil {
+		return accountInfo{}, fmt.Errorf("price account %s is empty", account)
+	}
+	info = accountInfo{owner: result.Value.Owner}
+	switch {
+	case info.owner.Equals(LegacyOracleProgramID):
+		data := result.Value.Data.GetBinary()
+		if len(data) < legacyExponentOffset+4 ||
+			binary.LittleEndian.Uint32(data[0:4]) != legacyMagic ||
+			binary.LittleEndian.Uint32(data[8:12]) != legacyTypePrice {
+			return accountInfo{}, fmt.Errorf("%s is not a Pyth price account", account)
+		}
+		info.exponent = int32(binary.LittleEndian.Uint32(data[legacyExponentOffset:]))
+	case info.owner.Equals(oracle.PushOracleProgramID):
+	default:
+		return accountInfo{}, fmt.Errorf("%s is owned by %s, not a Pyth oracle program", account, info.owner)
+	}
+
+	accountInfos.mu.Lock()
+	accountInfos.cache[account] = info
+	accountInfos.mu.Unlock()
+	return info, nil
+}
+
+// decodeUpdate returns the price from the last instruction in tx that
+// updated account, if any did
+func (info accountInfo) decodeUpdate(tx *rpc.GetTransactionResult, account solana.PublicKey) (*PythPrice, bool, error) {
+	instructions, err := txutil.Instructions(tx)
+	if err != nil {
+		return nil, false, err
+	}
+	var found *PythPrice
+	for _, ix := range instructions {
+		if !ix.ProgramID.Equals(info.owner) || !containsKey(ix.Accounts, account) {
+			continue
+		}
+		var price *PythPrice
+		var ok bool
+		if info.owner.Equals(LegacyOracleProgramID) {
+			price, ok = decodeLegacyUpdate(ix, account, info.exponent)
+		} else {
+			price, ok = decodePushUpdate(ix)
+		}
+		if ok {
+			found = price
+		}
+	}
+	return found, found != nil, nil
+}
+
+// Legacy upd_price instruction: version, command, status, padding, then
+// price, conf and the publisher's slot. Its accounts are the publisher,
+// the price account and the clock.
+const (
+	legacyCmdUpdPrice            = 7
+	legacyCmdUpdPriceNoFailOnErr = 13
+	legacyUpdPriceLen            = 40
+)
+
+func decodeLegacyUpdate(ix txutil.Instruction, account solana.PublicKey, exponent int32) (*PythPrice, bool) {
+	data := ix.Data
+	if len(data) < legacyUpdPriceLen || len(ix.Accounts) < 2 || !ix.Accounts[1].Equals(account) {
+		return nil, false
+	}
+	switch int32(binary.LittleEndian.Uint32(data[4:8])) {
+	case legacyCmdUpdPrice, legacyCmdUpdPriceNoFailOnErr:
+	default:
+		return nil, false
+	}
+	price := int64(binary.LittleEndian.Uint64(data[16:24]))
+	conf := binary.LittleEndian.Uint64(data[24:32])
+	if price <= 0 {
+		return nil, false
+	}
+	scale := math.Pow10(int(exponent))
+	return &PythPrice{Price: float64(price) * scale, Conf: float64(conf) * scale}, true
+}
+
+// updatePriceFeedDiscriminator starts the push oracle's update_price_feed
+// instruction, which ends with the 32 byte feed ID
+var updatePriceFeedDiscriminator = txutil.AnchorDiscriminator("update_price_feed")
+
+// A price feed message, as Pyth signs it: a zero type byte, the feed ID,
+// then price, conf, exponent and publish time, big-endian
+const (
+	priceFeedMessageType = 0
+	priceFeedMessageLen  = 1 + 32 + 8 + 8 + 4 + 8
+)
+
+func decodePushUpdate(ix txutil.Instruction) (*PythPrice, bool) {
+	data := ix.Data
+	if len(data) < len(updatePriceFeedDiscriminator)+priceFeedMessageLen+32 || !bytes.HasPrefix(data, updatePriceFeedDiscriminator) {
+		return nil, false
+	}
+	feedID := data[len(data)-32:]
+	// The message sits inside the Merkle update ahead of the proof, so find
+	// it by its type byte and feed ID
+	prefix := append([]byte{priceFeedMessageType}, feedID...)
+	at := bytes.Index(data[:len(data)-32], prefix)
+	if at < 0 || at+priceFeedMessageLen > len(data)-32 {
+		return nil, false
+	}
+	msg := data[at+len(prefix):]
+	price := int64(binary.BigEndian.Uint64(msg[0:8]))
+	conf := binary.BigEndian.Uint64(msg[8:16])
+	exponent := int32(binary.BigEndian.Uint32(msg[16:20]))
+	publishTime := int64(binary.BigEndian.Uint64(msg[20:28]))
+	scale := math.Pow10(int(exponent))
+	return &PythPrice{
+		Price:       float64(price) * scale,
+		Conf:        float64(conf) * scale,
+		PublishTime: time.Unix(publishTime, 0).UTC(),
+	}, true
+}
+
+func containsKey(keys []solana.PublicKey, key solana.PublicKey) bool {
+	for _, k := range keys {
+		if k.Equals(key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package price
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+	"golang.org/x/time/rate"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// Free-tier request rates of the price APIs an Enricher might call
+var (
+	CoinGeckoFreeTier = rate.Every(time.Minute / 30)
+	BirdeyeFreeTier   = rate.Every(time.Minute / 100)
+)
+
+// DefaultBatchSize is how many distinct tokens go into one inner Enrich
+// call unless configured otherwise
+const DefaultBatchSize = 50
+
+// RateLimitedEnricher wraps an Enricher that makes one API call per Enrich,
+// splitting the swaps into batches covering at most BatchSize distinct
+// tokens and waiting on Limiter before each. It suits enrichers backed by a
+// bulk price endpoint; StablecoinEnricher prices from the batch itself and
+// shouldn't be split.
+type RateLimitedEnricher struct {
+	Inner     Enricher
+	Limiter   *rate.Limiter
+	BatchSize int
+
+	// If set, called after each run with the number of calls that succeeded
+	Log func(format string, args ...any)
+
+	calls atomic.Int64
+}
+
+// NewRateLimitedEnricher limits inner to limit calls per second with no
+// bursting, in batches of DefaultBatchSize tokens
+func NewRateLimitedEnricher(inner Enricher, limit rate.Limit) *RateLimitedEnricher {
+	return &RateLimitedEnricher{Inner: inner, Limiter: rate.NewLimiter(limit, 1), BatchSize: DefaultBatchSize}
+}
+
+func (r *RateLimitedEnricher) Enrich(ctx context.Context, swaps []*model.SwapData) error {
+	batches := batchByTokens(swaps, max(r.BatchSize, 1))
+	succeeded := 0
+	if r.Log != nil {
+		defer func() {
+			r.Log("price: %d of %d API calls succeeded for %d swaps", succeeded, len(batches), len(swaps))
+		}()
+	}
+	for _, batch := range batches {
+		if err := r.Limiter.Wait(ctx); err != nil {
+			return err
+		}
+		if err := r.Inner.Enrich(ctx, batch); err != nil {
+			return err
+		}
+		succeeded++
+		r.calls.Add(1)
+	}
+	return nil
+}
+
+// Calls returns how many inner Enrich calls have succeeded in total
+func (r *RateLimitedEnricher) Calls() int64 {
+	return r.calls.Load()
+}
+
+// batchByTokens groups swaps in order so that each group's legs span at
+// most size distinct mints; a swap whose legs alone exceed that gets a group
+// of its own
+func batchByTokens(swaps []*model.SwapData, size int) [][]*model.SwapData {
+	var batches [][]*model.SwapData
+	var batch []*model.SwapData
+	mints := make(map[solana.PublicKey]struct{})
+	for _, s := range swaps {
+		added := 0
+		for _, mint := range []solana.PublicKey{s.TokenInMint, s.TokenOutMint} {
+			if _, ok := mints[mint]; !ok {
+				added++
+			}
+		}
+		if s.TokenInMint.Equals(s.TokenOutMint) && added == 2 {
+			added = 1
+		}
+		if len(batch) > 0 && len(mints)+added > size {
+			batches = append(batches, batch)
+			batch = nil
+			clear(mints)
+		}
+		batch = append(batch, s)
+		mints[s.TokenInMint] = struct{}{}
+		mints[s.TokenOutMint] = struct{}{}
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
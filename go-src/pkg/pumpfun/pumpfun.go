@@ -0,0 +1,181 @@
+// Package pumpfun prices trades against Pump.fun bonding curves.
+package pumpfun
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// ProgramID is the Pump.fun bonding curve program
+var ProgramID = solana.MustPublicKeyFromBase58("6EF8rrecthR5Dkzon8Nwu78hRvfCKubJ14M5uBEwF6P")
+
+// ErrNoTrade is returned when a transaction has no bonding curve trade
+var ErrNoTrade = errors.New("no Pump.fun trade in transaction")
+
+// Every Pump.fun token has the same supply and decimals
+const (
+	TokenDecimals = 6
+	// Raw units, i.e. one billion tokens
+	TokenSupply = 1_000_000_000_000_000
+)
+
+// ComputePumpFunPrice prices a token from its bonding curve's virtual
+// reserves, in lamports and raw token units. The curve is constant product
+// (virtual SOL * virtual tokens is invariant), so the spot price is their
+// ratio; market cap values the full supply at that price, as Pump.fun
+// displays it.
+func ComputePumpFunPrice(virtualSOLReserves, virtualTokenReserves uint64) (priceSOL float64, marketCapSOL float64) {
+	if virtualTokenReserves == 0 {
+		return 0, 0
+	}
+	sol := float64(virtualSOLReserves) / float64(solana.LAMPORTS_PER_SOL)
+	tokens := float64(virtualTokenReserves) / math.Pow10(TokenDecimals)
+	priceSOL = sol / tokens
+	return priceSOL, priceSOL * TokenSupply / math.Pow10(TokenDecimals)
+}
+
+// Anchor event tags: events are emitted through a self-invocation whose
+// data starts with txutil.AnchorEventTag, or on older deployments logged as
+// "Program data: " lines
+var (
+	tradeEventTag     = eventDiscriminator("TradeEvent")
+	programDataPrefix = "Program data: "
+)
+
+func eventDiscriminator(name string) []byte {
+	sum := sha256.Sum256([]byte("event:" + name))
+	return sum[:8]
+}
+
+// tradeEventLen covers the fields up to the virtual reserves: mint,
+// sol_amount, token_amount, is_buy, user, timestamp, virtual_sol_reserves,
+// virtual_token_reserves
+const tradeEventLen = 32 + 8 + 8 + 1 + 32 + 8 + 8 + 8
+
+// Trade is a decoded TradeEvent: one buy or sell on a bonding curve
+type Trade struct {
+	Mint        solana.PublicKey
+	SOLAmount   uint64
+	TokenAmount uint64
+	IsBuy       bool
+	User        solana.PublicKey
+
+	// The curve's virtual reserves after the trade
+	VirtualSOLReserves   uint64
+	VirtualTokenReserves uint64
+}
+
+// PreReserves returns the curve's virtual reserves before the trade
+func (t *Trade) PreReserves() (lamports, tokens uint64) {
+	if t.IsBuy {
+		return t.VirtualSOLReserves - t.SOLAmount, t.VirtualTokenReserves + t.TokenAmount
+	}
+	return t.VirtualSOLReserves + t.SOLAmount, t.VirtualTokenReserves - t.TokenAmount
+}
+
+// MarketImpactBps returns how far the trade moved the price of the token
+// bought (the curve's token for a buy, SOL for a sell), in basis points of
+// the price before it
+func (t *Trade) MarketImpactBps() int {
+	preSOL, preTokens := t.PreReserves()
+	pre, _ := ComputePumpFunPrice(preSOL, preTokens)
+	post, _ := ComputePumpFunPrice(t.VirtualSOLReserves, t.VirtualTokenReserves)
+	if !t.IsBuy {
+		// Price SOL in tokens instead
+		pre, post = 1/pre, 1/post
+	}
+	if pre == 0 || math.IsInf(pre, 0) || math.IsInf(post, 0) {
+		return 0
+	}
+	return int(math.Round((post - pre) / pre * 10_000))
+}
+
+// ParseTrades returns the transaction's bonding curve trade events in
+// execution order, or ErrNoTrade
+func ParseTrades(tx *rpc.GetTransactionResult) ([]*Trade, error) {
+	ixs, err := txutil.Instructions(tx)
+	if err != nil {
+		return nil, err
+	}
+	var trades []*Trade
+	for _, ix := range ixs {
+		if ix.ProgramID.Equals(ProgramID) && bytes.HasPrefix(ix.Data, txutil.AnchorEventTag) {
+			if trade, ok := decodeTrade(ix.Data[len(txutil.AnchorEventTag):]); ok {
+				trades = append(trades, trade)
+			}
+		}
+	}
+	if len(trades) == 0 && tx.Meta != nil {
+		for _, line := range tx.Meta.LogMessages {
+			encoded, ok := strings.CutPrefix(line, programDataPrefix)
+			if !ok {
+				continue
+			}
+			data, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				continue
+			}
+			if trade, ok := decodeTrade(data); ok {
+				trades = append(trades, trade)
+			}
+		}
+	}
+	if len(trades) == 0 {
+		return nil, ErrNoTrade
+	}
+	return trades, nil
+}
+
+// ParseTrade returns the first bonding curve trade event in a transaction,
+// or ErrNoTrade
+func ParseTrade(tx *rpc.GetTransactionResult) (*Trade, error) {
+	trades, err := ParseTrades(tx)
+	if err != nil {
+		return nil, err
+	}
+	return trades[0], nil
+}
+
+// Made reports whether the trade is the one a swap records: a buy of the
+// curve's token for the amount the swap bought, or a sale of the amount
+// it sold
+func (t *Trade) Made(swap *model.SwapData) bool {
+	if t.IsBuy {
+		return t.Mint.Equals(swap.TokenOutMint) && t.TokenAmount == swap.TokenOutAmount
+	}
+	return t.Mint.Equals(swap.TokenInMint) && t.TokenAmount == swap.TokenInAmount
+}
+
+// decodeTrade decodes event data starting with the TradeEvent discriminator
+func decodeTrade(data []byte) (*Trade, bool) {
+	if !bytes.HasPrefix(data, tradeEventTag) || len(data) < len(tradeEventTag)+tradeEventLen {
+		return nil, false
+	}
+	data = data[len(tradeEventTag):]
+	trade := &Trade{
+		Mint:        solana.PublicKeyFromBytes(data[0:32]),
+		SOLAmount:   binary.LittleEndian.Uint64(data[32:40]),
+		TokenAmount: binary.LittleEndian.Uint64(data[40:48]),
+		IsBuy:       data[48] != 0,
+		User:        solana.PublicKeyFromBytes(data[49:81]),
+		// timestamp at 81:89
+		VirtualSOLReserves:   binary.LittleEndian.Uint64(data[89:97]),
+		VirtualTokenReserves: binary.LittleEndian.Uint64(data[97:105]),
+	}
+	// Reserves that can't have held before the trade mean a misread event
+	if (trade.IsBuy && trade.VirtualSOLReserves < trade.SOLAmount) || (!trade.IsBuy && trade.VirtualTokenReserves < trade.TokenAmount) {
+		return nil, false
+	}
+	return trade, true
+}
@@ -0,0 +1,94 @@
+package registry
+
+import (
+	"fmt"
+	"path/filepath"
+	"plugin"
+	"strings"
+	"sync"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// ParseSwapFunc is the ParseSwap symbol a parser plugin exports. It only
+// needs to fill in the swap legs (and Dex, if it likes); getswaps sets the
+// transaction-level fields.
+type ParseSwapFunc = func(tx *rpc.GetTransactionResult) (*model.SwapData, error)
+
+// Plugin is a custom DEX parser loaded from a Go plugin
+type Plugin struct {
+	// File name without its extension
+	Name      string
+	ProgramID solana.PublicKey
+	ParseSwap ParseSwapFunc
+}
+
+var (
+	pluginsMu sync.RWMutex
+	plugins   = make(map[solana.PublicKey]*Plugin)
+)
+
+// LoadPlugin opens a parser plugin built with -buildmode=plugin. It must
+// export a ParseSwap function of type ParseSwapFunc and a ProgramID
+// solana.PublicKey variable naming the program it parses. A later plugin
+// for the same program replaces an earlier one.
+func LoadPlugin(path string) error {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return fmt.Errorf("loading plugin %s: %w", path, err)
+	}
+	parseSym, err := p.Lookup("ParseSwap")
+	if err != nil {
+		return fmt.Errorf("plugin %s: %w", path, err)
+	}
+	parse, ok := parseSym.(ParseSwapFunc)
+	if !ok {
+		return fmt.Errorf("plugin %s: ParseSwap is %T, want %T", path, parseSym, ParseSwapFunc(nil))
+	}
+	programSym, err := p.Lookup("ProgramID")
+	if err != nil {
+		return fmt.Errorf("plugin %s: %w", path, err)
+	}
+	// Variables are looked up as pointers
+	programID, ok := programSym.(*solana.PublicKey)
+	if !ok {
+		return fmt.Errorf("plugin %s: ProgramID is %T, want solana.PublicKey", path, programSym)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	plugins[*programID] = &Plugin{Name: name, ProgramID: *programID, ParseSwap: parse}
+	return nil
+}
+
+// PluginFor returns the plugin for the first program the transaction
+// invokes that has one, checking top-level instructions before inner ones
+func PluginFor(tx *rpc.GetTransactionResult) (*Plugin, bool) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	if len(plugins) == 0 {
+		return nil, false
+	}
+
+	ixs, err := txutil.Instructions(tx)
+	if err != nil {
+		return nil, false
+	}
+	// Instructions interleaves inner instructions with their parents
+	for _, topLevel := range []bool{true, false} {
+		for _, ix := range ixs {
+			if (ix.InnerIndex < 0) != topLevel {
+				continue
+			}
+			if p, ok := plugins[ix.ProgramID]; ok {
+				return p, true
+			}
+		}
+	}
+	return nil, false
+}
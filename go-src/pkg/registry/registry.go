@@ -0,0 +1,235 @@
+// Package registry maps on-chain program addresses to the protocol and
+// deployed version they belong to, so callers can pick the right decoding
+// logic for programs with several live deployments.
+package registry
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/network"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// Type is the broad kind of program, used to choose a decoding strategy
+type Type string
+
+const (
+	TypeAMM          Type = "amm"
+	TypeCLMM         Type = "clmm"
+	TypeAggregator   Type = "aggregator"
+	TypeBondingCurve Type = "bonding-curve"
+	TypeOrderbook    Type = "orderbook"
+)
+
+// ProgramInfo describes a single deployed program
+type ProgramInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Type    Type   `json:"type"`
+}
+
+//go:embed assets/programs.json
+var bundledPrograms []byte
+
+// ProgramRegistry is a concurrency-safe program address -> ProgramInfo lookup
+// for one cluster
+type ProgramRegistry struct {
+	network  string
+	mu       sync.RWMutex
+	programs map[solana.PublicKey]ProgramInfo
+}
+
+// NewProgramRegistry returns an empty mainnet-beta registry
+func NewProgramRegistry() *ProgramRegistry {
+	return NewNetworkRegistry(network.Mainnet)
+}
+
+// NewNetworkRegistry returns an empty registry that loads the programs
+// deployed on the named cluster
+func NewNetworkRegistry(name string) *ProgramRegistry {
+	return &ProgramRegistry{network: name, programs: make(map[solana.PublicKey]ProgramInfo)}
+}
+
+var (
+	defaultMu       sync.Mutex
+	defaultNetwork  = network.Mainnet
+	networkRegistry = make(map[string]*ProgramRegistry)
+)
+
+// SetDefaultNetwork picks the cluster Default returns the registry for
+func SetDefaultNetwork(name string) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultNetwork = name
+}
+
+// Default returns the bundled registry for the cluster chosen with
+// SetDefaultNetwork, mainnet-beta unless changed
+func Default() *ProgramRegistry {
+	defaultMu.Lock()
+	name := defaultNetwork
+	defaultMu.Unlock()
+	return ForNetwork(name)
+}
+
+// ForNetwork returns the registry loaded from the bundled
+// assets/programs.json for a cluster. A bad bundled entry is a programming
+// error, so it panics rather than making every caller handle an error.
+func ForNetwork(name string) *ProgramRegistry {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if r, ok := networkRegistry[name]; ok {
+		return r
+	}
+	r := NewNetworkRegistry(name)
+	if err := r.loadJSON(bundledPrograms); err != nil {
+		panic(fmt.Sprintf("registry: bundled programs.json: %s", err))
+	}
+	networkRegistry[name] = r
+	return r
+}
+
+type programsFile struct {
+	Programs []struct {
+		Address string `json:"address"`
+		// Clusters the address is deployed on, mainnet-beta if unset
+		Networks []string `json:"networks,omitempty"`
+		ProgramInfo
+	} `json:"programs"`
+}
+
+// deployedOn reports whether an entry listing networks applies to the
+// registry's cluster. Local validators usually clone mainnet programs, so
+// localnet uses the mainnet addresses.
+func (r *ProgramRegistry) deployedOn(networks []string) bool {
+	if len(networks) == 0 {
+		networks = []string{network.Mainnet}
+	}
+	name := r.network
+	if name == network.Localnet {
+		name = network.Mainnet
+	}
+	return slices.Contains(networks, name)
+}
+
+// Load merges the programs in a programs.json formatted reader that are
+// deployed on the registry's cluster, overriding any existing entries for
+// the same address
+func (r *ProgramRegistry) Load(reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return r.loadJSON(data)
+}
+
+// LoadFile is Load for a file on disk
+func (r *ProgramRegistry) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.Load(f)
+}
+
+func (r *ProgramRegistry) loadJSON(data []byte) error {
+	var file programsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("decoding programs: %w", err)
+	}
+	for _, p := range file.Programs {
+		if !r.deployedOn(p.Networks) {
+			continue
+		}
+		address, err := solana.PublicKeyFromBase58(p.Address)
+		if err != nil {
+			return fmt.Errorf("program %q (%s %s): %w", p.Address, p.Name, p.Version, err)
+		}
+		r.Register(address, p.ProgramInfo)
+	}
+	return nil
+}
+
+// Register adds or replaces the entry for a program address
+func (r *ProgramRegistry) Register(address solana.PublicKey, info ProgramInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.programs[address] = info
+}
+
+// Lookup returns the entry for a program address
+func (r *ProgramRegistry) Lookup(address solana.PublicKey) (ProgramInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.programs[address]
+	return info, ok
+}
+
+// Find returns the addresses of every program whose name starts with name,
+// ignoring case, so "raydium" matches all the Raydium deployments. A base58
+// address is returned as-is whether or not it is registered.
+func (r *ProgramRegistry) Find(name string) []solana.PublicKey {
+	if address, err := solana.PublicKeyFromBase58(name); err == nil {
+		return []solana.PublicKey{address}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var matches []solana.PublicKey
+	for address, info := range r.programs {
+		if strings.HasPrefix(strings.ToLower(info.Name), strings.ToLower(name)) {
+			matches = append(matches, address)
+		}
+	}
+	slices.SortFunc(matches, func(a, b solana.PublicKey) int { return strings.Compare(a.String(), b.String()) })
+	return matches
+}
+
+// DetectProtocol returns the first known program invoked by the transaction.
+// Top-level instructions are checked before inner ones so an aggregator
+// wins over the DEXes it routes through.
+func (r *ProgramRegistry) DetectProtocol(tx *rpc.GetTransactionResult) (ProgramInfo, solana.PublicKey, bool) {
+	keys, err := txutil.AccountKeys(tx)
+	if err != nil {
+		return ProgramInfo{}, solana.PublicKey{}, false
+	}
+	decoded, _ := txutil.Decode(tx)
+
+	indexes := make([]uint16, 0, len(decoded.Message.Instructions))
+	for _, ix := range decoded.Message.Instructions {
+		indexes = append(indexes, ix.ProgramIDIndex)
+	}
+	if tx.Meta != nil {
+		for _, inner := range tx.Meta.InnerInstructions {
+			for _, ix := range inner.Instructions {
+				indexes = append(indexes, ix.ProgramIDIndex)
+			}
+		}
+	}
+
+	for _, idx := range indexes {
+		if int(idx) >= len(keys) {
+			continue
+		}
+		if info, ok := r.Lookup(keys[idx]); ok {
+			return info, keys[idx], true
+		}
+	}
+	return ProgramInfo{}, solana.PublicKey{}, false
+}
+
+// DetectProtocol runs ProgramRegistry.DetectProtocol against the default registry
+func DetectProtocol(tx *rpc.GetTransactionResult) (ProgramInfo, solana.PublicKey, bool) {
+	return Default().DetectProtocol(tx)
+}
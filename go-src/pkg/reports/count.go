@@ -0,0 +1,114 @@
+package reports
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+)
+
+// CountReport summarises a file of getswaps results without pricing them
+type CountReport struct {
+	Records int `json:"records"`
+
+	// Zero if no record has a block time
+	FirstBlockTime time.Time `json:"first_block_time"`
+	LastBlockTime  time.Time `json:"last_block_time"`
+
+	UniqueWallets  int `json:"unique_wallets"`
+	UniquePrograms int `json:"unique_programs"`
+
+	// Most records first. Each swap counts towards both of its tokens.
+	ByDex   []DexCount   `json:"by_dex"`
+	ByToken []TokenCount `json:"by_token"`
+}
+
+// DexCount is how many records a DEX handled; Dex is empty for unknown programs
+type DexCount struct {
+	Dex     string `json:"dex"`
+	Records int    `json:"records"`
+}
+
+// TokenCount is how many records traded a token
+type TokenCount struct {
+	Mint    solana.PublicKey `json:"mint"`
+	Symbol  string           `json:"symbol"`
+	Records int              `json:"records"`
+}
+
+// Count reads a stream of getswaps results, as written by the json or
+// ndjson output formats
+func Count(r io.Reader) (*CountReport, error) {
+	report := &CountReport{}
+	dexes := make(map[string]int)
+	tokens := make(map[solana.PublicKey]int)
+	wallets := make(map[solana.PublicKey]struct{})
+	programs := make(map[solana.PublicKey]struct{})
+
+	dec := json.NewDecoder(r)
+	for {
+		var result model.Result
+		err := dec.Decode(&result)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", report.Records+1, err)
+		}
+		report.Records++
+
+		// A transaction with several swaps counts once for each DEX and token
+		seenDex := make(map[string]bool)
+		seenToken := make(map[solana.PublicKey]bool)
+		for _, s := range result.AllSwaps() {
+			if !seenDex[s.Dex] {
+				seenDex[s.Dex] = true
+				dexes[s.Dex]++
+			}
+			wallets[s.Signer] = struct{}{}
+			if s.ProgramID != nil {
+				programs[*s.ProgramID] = struct{}{}
+			}
+			if !s.Failed {
+				for _, mint := range []solana.PublicKey{s.TokenInMint, s.TokenOutMint} {
+					if !seenToken[mint] {
+						seenToken[mint] = true
+						tokens[mint]++
+					}
+				}
+			}
+			if !s.BlockTime.IsZero() {
+				if report.FirstBlockTime.IsZero() || s.BlockTime.Before(report.FirstBlockTime) {
+					report.FirstBlockTime = s.BlockTime
+				}
+				if s.BlockTime.After(report.LastBlockTime) {
+					report.LastBlockTime = s.BlockTime
+				}
+			}
+		}
+	}
+
+	report.UniqueWallets = len(wallets)
+	report.UniquePrograms = len(programs)
+	for dex, n := range dexes {
+		report.ByDex = append(report.ByDex, DexCount{Dex: dex, Records: n})
+	}
+	slices.SortFunc(report.ByDex, func(a, b DexCount) int {
+		return cmp.Or(cmp.Compare(b.Records, a.Records), cmp.Compare(a.Dex, b.Dex))
+	})
+	for mint, n := range tokens {
+		report.ByToken = append(report.ByToken, TokenCount{Mint: mint, Symbol: tokenmetadata.Symbol(mint), Records: n})
+	}
+	slices.SortFunc(report.ByToken, func(a, b TokenCount) int {
+		return cmp.Or(cmp.Compare(b.Records, a.Records), cmp.Compare(a.Mint.String(), b.Mint.String()))
+	})
+	return report, nil
+}
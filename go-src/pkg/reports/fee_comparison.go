@@ -0,0 +1,92 @@
+package reports
+
+import (
+	"cmp"
+	"slices"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// DexFeeRow is one DEX in a FeeComparison report
+type DexFeeRow struct {
+	Rank      int     `json:"rank"`
+	Dex       string  `json:"dex"`
+	SwapCount int     `json:"swap_count"`
+	AvgFeeBps float64 `json:"avg_fee_bps"`
+	// What the average fee would cost on a swap of the requested amount of
+	// token A, in token A
+	EstimatedFee float64 `json:"estimated_fee"`
+}
+
+// FeeComparison ranks DEXes by the average effective fee of their swaps
+// between tokenA and tokenB, cheapest first. A swap's effective fee is how
+// far its rate fell short of the pair's median rate across every DEX in
+// the sample, in bps of the input, so it covers LP fees, price impact and
+// any routing markup but not network fees. Swaps in both directions count.
+// Swaps with no detected DEX are grouped under "unknown".
+func FeeComparison(swaps []*model.SwapData, tokenA, tokenB solana.PublicKey, amount float64) []*DexFeeRow {
+	// Rates are B per A whichever way the swap went
+	type pairSwap struct {
+		dex  string
+		rate float64
+		aIn  bool
+	}
+	var pair []pairSwap
+	var rates []float64
+	for _, s := range swaps {
+		if s.AmountInUI <= 0 || s.AmountOutUI <= 0 {
+			continue
+		}
+		var p pairSwap
+		switch {
+		case s.TokenInMint.Equals(tokenA) && s.TokenOutMint.Equals(tokenB):
+			p = pairSwap{rate: s.AmountOutUI / s.AmountInUI, aIn: true}
+		case s.TokenInMint.Equals(tokenB) && s.TokenOutMint.Equals(tokenA):
+			p = pairSwap{rate: s.AmountInUI / s.AmountOutUI}
+		default:
+			continue
+		}
+		p.dex = cmp.Or(s.Dex, "unknown")
+		pair = append(pair, p)
+		rates = append(rates, p.rate)
+	}
+	if len(pair) == 0 {
+		return nil
+	}
+	slices.Sort(rates)
+	reference := percentile(rates, 50)
+
+	rows := make(map[string]*DexFeeRow)
+	for _, p := range pair {
+		// Selling A should get at least the reference rate of B; buying A
+		// should cost at most it
+		fee := (reference - p.rate) / reference
+		if !p.aIn {
+			fee = (p.rate - reference) / p.rate
+		}
+		row, ok := rows[p.dex]
+		if !ok {
+			row = &DexFeeRow{Dex: p.dex}
+			rows[p.dex] = row
+		}
+		row.SwapCount++
+		row.AvgFeeBps += fee * 10_000
+	}
+
+	ranked := make([]*DexFeeRow, 0, len(rows))
+	for _, row := range rows {
+		row.AvgFeeBps /= float64(row.SwapCount)
+		row.EstimatedFee = amount * row.AvgFeeBps / 10_000
+		ranked = append(ranked, row)
+	}
+	slices.SortFunc(ranked, func(a, b *DexFeeRow) int {
+		return cmp.Or(
+			cmp.Compare(a.AvgFeeBps, b.AvgFeeBps),
+			cmp.Compare(b.SwapCount, a.SwapCount),
+			cmp.Compare(a.Dex, b.Dex),
+		)
+	})
+	return rank(ranked, 0, func(row *DexFeeRow, r int) { row.Rank = r })
+}
@@ -0,0 +1,79 @@
+package reports
+
+import (
+	"slices"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// FeePayerStats is how one fee payer's transactions behaved. Bots tend to
+// stand out: many transactions on one or two programs, steady compute
+// use, and high fees or failure rates from racing other bots.
+type FeePayerStats struct {
+	FeePayer solana.PublicKey `json:"fee_payer"`
+
+	Transactions          int    `json:"transactions"`
+	TotalFeesPaidLamports uint64 `json:"total_fees_paid_lamports"`
+	MedianComputeUnits    uint64 `json:"median_compute_units"`
+
+	// Distinct DEX programs the payer's swaps went through
+	UniquePrograms int `json:"unique_programs"`
+
+	SwapCount     int     `json:"swap_count"`
+	FailedTxCount int     `json:"failed_tx_count"`
+	SuccessRate   float64 `json:"success_rate"`
+}
+
+// FeePayerAnalysis groups transactions by fee payer. It takes whole results
+// rather than swaps since compute units are only in the transaction data.
+// Failed transactions (SwapData.Failed) count towards fees, compute and
+// the success rate but not swaps.
+func FeePayerAnalysis(results []*model.Result) map[solana.PublicKey]*FeePayerStats {
+	stats := make(map[solana.PublicKey]*FeePayerStats)
+	units := make(map[solana.PublicKey][]uint64)
+	programs := make(map[solana.PublicKey]map[solana.PublicKey]struct{})
+
+	for _, r := range results {
+		if r.SwapData == nil {
+			continue
+		}
+		payer := r.SwapData.Signer
+		if r.TransactionData != nil {
+			payer = r.TransactionData.Signer
+		}
+		s, ok := stats[payer]
+		if !ok {
+			s = &FeePayerStats{FeePayer: payer}
+			stats[payer] = s
+			programs[payer] = make(map[solana.PublicKey]struct{})
+		}
+		s.Transactions++
+		s.TotalFeesPaidLamports += r.SwapData.FeeLamports
+		if r.TransactionData != nil {
+			units[payer] = append(units[payer], r.TransactionData.ComputeUnitsConsumed)
+		}
+		if r.SwapData.Failed {
+			s.FailedTxCount++
+			continue
+		}
+		swaps := r.AllSwaps()
+		s.SwapCount += len(swaps)
+		for _, swap := range swaps {
+			if swap.ProgramID != nil {
+				programs[payer][*swap.ProgramID] = struct{}{}
+			}
+		}
+	}
+
+	for payer, s := range stats {
+		s.UniquePrograms = len(programs[payer])
+		s.SuccessRate = float64(s.Transactions-s.FailedTxCount) / float64(s.Transactions)
+		if u := units[payer]; len(u) > 0 {
+			slices.Sort(u)
+			s.MedianComputeUnits = percentile(u, 50)
+		}
+	}
+	return stats
+}
@@ -0,0 +1,133 @@
+package reports
+
+import (
+	"math"
+	"slices"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// FeeReport summarises what a batch of transactions paid in fees, and
+// whether paying more priority fee made them more likely to land
+type FeeReport struct {
+	Transactions int     `json:"transactions"`
+	Landed       int     `json:"landed"`
+	Failed       int     `json:"failed"`
+	LandingRate  float64 `json:"landing_rate"`
+
+	MinFeeLamports   uint64  `json:"min_fee_lamports"`
+	MaxFeeLamports   uint64  `json:"max_fee_lamports"`
+	MeanFeeLamports  float64 `json:"mean_fee_lamports"`
+	P50FeeLamports   uint64  `json:"p50_fee_lamports"`
+	P95FeeLamports   uint64  `json:"p95_fee_lamports"`
+	TotalFeeLamports uint64  `json:"total_fee_lamports"`
+
+	// Rent put into new token accounts, and that plus the fees
+	TotalTokenAccountRentLamports uint64 `json:"total_token_account_rent_lamports"`
+	TotalCostLamports             uint64 `json:"total_cost_lamports"`
+
+	// Priced at the median SOL rate among the batch's enriched swaps,
+	// 0 if none of them priced SOL
+	TotalFeesUSD float64 `json:"total_fees_usd"`
+
+	MeanPriorityFeeLandedLamports float64 `json:"mean_priority_fee_landed_lamports"`
+	MeanPriorityFeeFailedLamports float64 `json:"mean_priority_fee_failed_lamports"`
+
+	// Pearson correlation between priority fee and landing (1) or failing
+	// (0). Positive means higher priority fees landed more often; 0 when
+	// either side has no variance.
+	PriorityFeeLandingCorrelation float64 `json:"priority_fee_landing_correlation"`
+}
+
+// FeeAnalysis computes fee statistics over swaps, including failed
+// transactions (Failed set), which count towards the landing rate
+func FeeAnalysis(swaps []*model.SwapData) *FeeReport {
+	report := &FeeReport{Transactions: len(swaps)}
+	if len(swaps) == 0 {
+		return report
+	}
+
+	fees := make([]uint64, 0, len(swaps))
+	priority := make([]float64, 0, len(swaps))
+	landed := make([]float64, 0, len(swaps))
+	var landedPriority, failedPriority float64
+	var solRates []float64
+
+	for _, s := range swaps {
+		fees = append(fees, s.FeeLamports)
+		report.TotalFeeLamports += s.FeeLamports
+		report.TotalTokenAccountRentLamports += s.TokenAccountRentLamports
+		report.TotalCostLamports += s.TotalCostLamports()
+		priority = append(priority, float64(s.PriorityFeeLamports))
+		if s.Failed {
+			report.Failed++
+			failedPriority += float64(s.PriorityFeeLamports)
+			landed = append(landed, 0)
+		} else {
+			report.Landed++
+			landedPriority += float64(s.PriorityFeeLamports)
+			landed = append(landed, 1)
+		}
+
+		if s.TokenInMint == solana.SolMint && s.ValueInUSD > 0 && s.AmountInUI > 0 {
+			solRates = append(solRates, s.ValueInUSD/s.AmountInUI)
+		}
+		if s.TokenOutMint == solana.SolMint && s.ValueOutUSD > 0 && s.AmountOutUI > 0 {
+			solRates = append(solRates, s.ValueOutUSD/s.AmountOutUI)
+		}
+	}
+
+	slices.Sort(fees)
+	report.MinFeeLamports = fees[0]
+	report.MaxFeeLamports = fees[len(fees)-1]
+	report.MeanFeeLamports = float64(report.TotalFeeLamports) / float64(len(fees))
+	report.P50FeeLamports = percentile(fees, 50)
+	report.P95FeeLamports = percentile(fees, 95)
+	report.LandingRate = float64(report.Landed) / float64(len(swaps))
+
+	if report.Landed > 0 {
+		report.MeanPriorityFeeLandedLamports = landedPriority / float64(report.Landed)
+	}
+	if report.Failed > 0 {
+		report.MeanPriorityFeeFailedLamports = failedPriority / float64(report.Failed)
+	}
+	report.PriorityFeeLandingCorrelation = correlation(priority, landed)
+
+	if len(solRates) > 0 {
+		slices.Sort(solRates)
+		solPrice := solRates[len(solRates)/2]
+		report.TotalFeesUSD = float64(report.TotalFeeLamports) / float64(solana.LAMPORTS_PER_SOL) * solPrice
+	}
+	return report
+}
+
+// percentile returns the nearest-rank percentile p (0-100) of sorted values
+func percentile[T any](sorted []T, p float64) T {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank-1, 0), len(sorted)-1)]
+}
+
+// correlation returns the Pearson correlation of xs and ys, or 0 if either has no variance
+func correlation(xs, ys []float64) float64 {
+	n := float64(len(xs))
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
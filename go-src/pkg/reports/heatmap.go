@@ -0,0 +1,166 @@
+package reports
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// Heatmap metrics
+const (
+	HeatmapCount  = "count"
+	HeatmapVolume = "volume"
+)
+
+// Weekdays are the heatmap's rows, Monday first
+var Weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+// HeatmapReport is swap activity by weekday and time of day, in UTC
+type HeatmapReport struct {
+	// HeatmapCount or HeatmapVolume (in USD)
+	Metric string `json:"metric"`
+	// Width of each column, e.g. 1h for 24 columns a day
+	Bucket time.Duration `json:"-"`
+	// Each column's start time of day, e.g. "13:00"
+	Columns []string `json:"columns"`
+	// Cells[d][c] is the total for Weekdays[d] in Columns[c]
+	Cells [][]float64 `json:"cells"`
+	Max   float64     `json:"max"`
+	// Swaps without a block time, which can't be placed
+	Skipped int `json:"skipped"`
+}
+
+// Heatmap totals swaps by weekday and time of day. bucket must divide a day
+// evenly. For HeatmapVolume only priced swaps contribute.
+func Heatmap(swaps []*model.SwapData, bucket time.Duration, metric string) (*HeatmapReport, error) {
+	if bucket <= 0 || (24*time.Hour)%bucket != 0 {
+		return nil, fmt.Errorf("bucket %s doesn't divide a day evenly", bucket)
+	}
+	if metric != HeatmapCount && metric != HeatmapVolume {
+		return nil, fmt.Errorf("unknown metric %q (want %s or %s)", metric, HeatmapCount, HeatmapVolume)
+	}
+	columns := int(24 * time.Hour / bucket)
+	report := &HeatmapReport{Metric: metric, Bucket: bucket, Cells: make([][]float64, len(Weekdays))}
+	for d := range report.Cells {
+		report.Cells[d] = make([]float64, columns)
+	}
+	for c := range columns {
+		start := time.Duration(c) * bucket
+		report.Columns = append(report.Columns, fmt.Sprintf("%02d:%02d", int(start.Hours()), int(start.Minutes())%60))
+	}
+
+	for _, s := range swaps {
+		if s.BlockTime.IsZero() {
+			report.Skipped++
+			continue
+		}
+		t := s.BlockTime.UTC()
+		// Monday is row 0
+		row := (int(t.Weekday()) + 6) % 7
+		sinceMidnight := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+		column := int(sinceMidnight / bucket)
+		if metric == HeatmapCount {
+			report.Cells[row][column]++
+		} else {
+			report.Cells[row][column] += s.VolumeUSD
+		}
+	}
+	for _, row := range report.Cells {
+		for _, v := range row {
+			report.Max = max(report.Max, v)
+		}
+	}
+	return report, nil
+}
+
+func (h *HeatmapReport) formatValue(v float64) string {
+	if h.Metric == HeatmapCount {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// WriteCSV writes the heatmap as a matrix: a weekday per row and a column
+// per time bucket
+func (h *HeatmapReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"weekday"}, h.Columns...)); err != nil {
+		return err
+	}
+	for d, row := range h.Cells {
+		record := []string{Weekdays[d].String()[:3]}
+		for _, v := range row {
+			record = append(record, h.formatValue(v))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// SVG layout, in pixels
+const (
+	svgCell   = 22
+	svgLeft   = 44
+	svgTop    = 40
+	svgMargin = 12
+)
+
+// WriteSVG draws the heatmap as a standalone SVG image, shading each cell
+// from white (none) to dark blue (the busiest cell). Hovering a cell shows
+// its value.
+func (h *HeatmapReport) WriteSVG(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	columns := len(h.Columns)
+	cell := svgCell
+	if columns > 24 {
+		cell = svgCell * 24 / columns
+	}
+	width := svgLeft + columns*cell + svgMargin
+	height := svgTop + len(h.Cells)*svgCell + svgMargin
+
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="Helvetica, Arial, sans-serif" font-size="11">`+"\n", width, height)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="white"/>`+"\n", width, height)
+	title := "Swaps by weekday and hour (UTC)"
+	if h.Metric == HeatmapVolume {
+		title = "Swap volume in USD by weekday and hour (UTC)"
+	}
+	fmt.Fprintf(bw, `<text x="%d" y="14" font-size="13" font-weight="bold">%s</text>`+"\n", svgLeft, title)
+
+	// Label every few columns so the labels don't overlap
+	every := max(1, 3*columns/24)
+	for i, label := range h.Columns {
+		if i%every == 0 {
+			fmt.Fprintf(bw, `<text x="%d" y="%d">%s</text>`+"\n", svgLeft+i*cell, svgTop-6, label)
+		}
+	}
+	for d, row := range h.Cells {
+		y := svgTop + d*svgCell
+		fmt.Fprintf(bw, `<text x="4" y="%d">%s</text>`+"\n", y+svgCell*2/3, Weekdays[d].String()[:3])
+		for i, v := range row {
+			shade := 0.0
+			if h.Max > 0 {
+				shade = v / h.Max
+			}
+			fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="#eeeeee"><title>%s %s: %s</title></rect>`+"\n",
+				svgLeft+i*cell, y, cell, svgCell, heatColor(shade), Weekdays[d].String()[:3], h.Columns[i], h.formatValue(v))
+		}
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// heatColor blends white towards dark blue (#08306b) as shade goes 0 to 1
+func heatColor(shade float64) string {
+	blend := func(from, to int) int {
+		return from + int(float64(to-from)*shade)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", blend(0xff, 0x08), blend(0xff, 0x30), blend(0xff, 0x6b))
+}
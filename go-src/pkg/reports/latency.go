@@ -0,0 +1,47 @@
+package reports
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// LatencyReport summarises how long a batch of RPC requests took
+type LatencyReport struct {
+	Requests int
+	Min      time.Duration
+	Max      time.Duration
+	Mean     time.Duration
+	P50      time.Duration
+	P95      time.Duration
+	P99      time.Duration
+}
+
+// LatencyStats computes latency statistics, or a zero report if there are none
+func LatencyStats(latencies []time.Duration) LatencyReport {
+	if len(latencies) == 0 {
+		return LatencyReport{}
+	}
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+	return LatencyReport{
+		Requests: len(sorted),
+		Min:      sorted[0],
+		Max:      sorted[len(sorted)-1],
+		Mean:     total / time.Duration(len(sorted)),
+		P50:      percentile(sorted, 50),
+		P95:      percentile(sorted, 95),
+		P99:      percentile(sorted, 99),
+	}
+}
+
+func (r LatencyReport) String() string {
+	round := func(d time.Duration) time.Duration { return d.Round(time.Millisecond) }
+	return fmt.Sprintf("RPC latency over %d requests: min %s, max %s, mean %s, p95 %s",
+		r.Requests, round(r.Min), round(r.Max), round(r.Mean), round(r.P95))
+}
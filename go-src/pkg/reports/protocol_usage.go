@@ -0,0 +1,129 @@
+package reports
+
+import (
+	"cmp"
+	"slices"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/registry"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// ProtocolUsageRow is one program in a ProtocolUsage report
+type ProtocolUsageRow struct {
+	Rank      int              `json:"rank"`
+	ProgramID solana.PublicKey `json:"program_id"`
+	// The registry's name and version for DEXes, plus a few native and SPL
+	// programs; empty for the rest
+	ProgramName string `json:"program_name,omitempty"`
+
+	// Instructions to the program, top-level and inner, and the distinct
+	// fee payers of the transactions they were in
+	InvocationCount int `json:"invocation_count"`
+	UniqueWallets   int `json:"unique_wallets"`
+
+	// Swaps attributed to the program, and their USD volume where priced
+	SwapCount int     `json:"swap_count"`
+	VolumeUSD float64 `json:"volume_usd"`
+}
+
+// nativeProgramNames names the programs most transactions call that the
+// DEX registry doesn't list
+var nativeProgramNames = map[solana.PublicKey]string{
+	solana.SystemProgramID:                    "System Program",
+	solana.ComputeBudget:                      "Compute Budget",
+	solana.VoteProgramID:                      "Vote",
+	solana.StakeProgramID:                     "Stake",
+	solana.TokenProgramID:                     "Token Program",
+	solana.Token2022ProgramID:                 "Token-2022",
+	solana.SPLAssociatedTokenAccountProgramID: "Associated Token Account",
+	solana.MemoProgramID:                      "Memo",
+	solana.TokenMetadataProgramID:             "Token Metadata",
+	solana.MustPublicKeyFromBase58("AddressLookupTab1e1111111111111111111111111"): "Address Lookup Table",
+}
+
+// ProtocolUsage tallies which programs a run of transactions invoked. It's
+// fed a transaction at a time, rather than taking a slice like the other
+// reports, so a scan of many blocks needn't hold them all.
+type ProtocolUsage struct {
+	rows    map[solana.PublicKey]*ProtocolUsageRow
+	wallets map[solana.PublicKey]map[solana.PublicKey]struct{}
+}
+
+// NewProtocolUsage returns an empty ProtocolUsage
+func NewProtocolUsage() *ProtocolUsage {
+	return &ProtocolUsage{
+		rows:    make(map[solana.PublicKey]*ProtocolUsageRow),
+		wallets: make(map[solana.PublicKey]map[solana.PublicKey]struct{}),
+	}
+}
+
+func (u *ProtocolUsage) row(program solana.PublicKey) *ProtocolUsageRow {
+	row, ok := u.rows[program]
+	if !ok {
+		row = &ProtocolUsageRow{ProgramID: program}
+		u.rows[program] = row
+		u.wallets[program] = make(map[solana.PublicKey]struct{})
+	}
+	return row
+}
+
+// AddTransaction counts each of a transaction's instructions as an
+// invocation of its program by the fee payer
+func (u *ProtocolUsage) AddTransaction(tx *rpc.GetTransactionResult) error {
+	instructions, err := txutil.Instructions(tx)
+	if err != nil {
+		return err
+	}
+	keys, err := txutil.AccountKeys(tx)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	for _, ix := range instructions {
+		u.row(ix.ProgramID).InvocationCount++
+		u.wallets[ix.ProgramID][keys[0]] = struct{}{}
+	}
+	return nil
+}
+
+// AddSwaps credits swaps to their ProgramID, so price them first for
+// VolumeUSD. Swaps without one are left out.
+func (u *ProtocolUsage) AddSwaps(swaps []*model.SwapData) {
+	for _, s := range swaps {
+		if s.ProgramID == nil {
+			continue
+		}
+		row := u.row(*s.ProgramID)
+		row.SwapCount++
+		row.VolumeUSD += s.VolumeUSD
+	}
+}
+
+// Rows ranks the programs by invocations, then swap count, naming those it
+// knows. n <= 0 returns every program.
+func (u *ProtocolUsage) Rows(n int) []*ProtocolUsageRow {
+	ranked := make([]*ProtocolUsageRow, 0, len(u.rows))
+	for program, row := range u.rows {
+		row.UniqueWallets = len(u.wallets[program])
+		if info, ok := registry.Default().Lookup(program); ok {
+			row.ProgramName = info.Name + " " + info.Version
+		} else {
+			row.ProgramName = nativeProgramNames[program]
+		}
+		ranked = append(ranked, row)
+	}
+	slices.SortFunc(ranked, func(a, b *ProtocolUsageRow) int {
+		return cmp.Or(
+			cmp.Compare(b.InvocationCount, a.InvocationCount),
+			cmp.Compare(b.SwapCount, a.SwapCount),
+			cmp.Compare(a.ProgramID.String(), b.ProgramID.String()),
+		)
+	})
+	return rank(ranked, n, func(row *ProtocolUsageRow, r int) { row.Rank = r })
+}
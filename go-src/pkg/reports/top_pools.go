@@ -0,0 +1,66 @@
+package reports
+
+import (
+	"cmp"
+	"slices"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+)
+
+// PoolRankRow is one pool in a TopPools report
+type PoolRankRow struct {
+	Rank          int              `json:"rank"`
+	Pool          solana.PublicKey `json:"pool"`
+	Dex           string           `json:"dex"`
+	Pair          string           `json:"pair"`
+	SwapCount     int              `json:"swap_count"`
+	VolumeUSD     float64          `json:"volume_usd"`
+	UniqueWallets int              `json:"unique_wallets"`
+}
+
+// TopPools ranks the pools swaps traded against by swap count, then USD
+// volume, then unique wallets. Swaps without a PoolAddress are skipped.
+// n <= 0 returns every pool.
+func TopPools(swaps []*model.SwapData, n int) []*PoolRankRow {
+	rows := make(map[solana.PublicKey]*PoolRankRow)
+	wallets := make(map[solana.PublicKey]map[solana.PublicKey]struct{})
+
+	for _, s := range swaps {
+		if s.PoolAddress == nil {
+			continue
+		}
+		pool := *s.PoolAddress
+		row, ok := rows[pool]
+		if !ok {
+			// Name the pair in a fixed order, whichever way the first swap went
+			a, b := s.TokenInMint, s.TokenOutMint
+			if b.String() < a.String() {
+				a, b = b, a
+			}
+			row = &PoolRankRow{Pool: pool, Dex: s.Dex, Pair: tokenmetadata.Symbol(a) + "/" + tokenmetadata.Symbol(b)}
+			rows[pool] = row
+			wallets[pool] = make(map[solana.PublicKey]struct{})
+		}
+		row.SwapCount++
+		row.VolumeUSD += s.VolumeUSD
+		wallets[pool][s.Signer] = struct{}{}
+	}
+
+	ranked := make([]*PoolRankRow, 0, len(rows))
+	for pool, row := range rows {
+		row.UniqueWallets = len(wallets[pool])
+		ranked = append(ranked, row)
+	}
+	slices.SortFunc(ranked, func(a, b *PoolRankRow) int {
+		return cmp.Or(
+			cmp.Compare(b.SwapCount, a.SwapCount),
+			cmp.Compare(b.VolumeUSD, a.VolumeUSD),
+			cmp.Compare(b.UniqueWallets, a.UniqueWallets),
+			cmp.Compare(a.Pool.String(), b.Pool.String()),
+		)
+	})
+	return rank(ranked, n, func(row *PoolRankRow, r int) { row.Rank = r })
+}
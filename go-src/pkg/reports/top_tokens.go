@@ -0,0 +1,72 @@
+// Package reports aggregates parsed swaps into ranked summaries.
+package reports
+
+import (
+	"cmp"
+	"slices"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+)
+
+// TokenRankRow is one token in a TopTokens report
+type TokenRankRow struct {
+	Rank          int              `json:"rank"`
+	Mint          solana.PublicKey `json:"mint"`
+	Symbol        string           `json:"symbol"`
+	VolumeUSD     float64          `json:"volume_usd"`
+	SwapCount     int              `json:"swap_count"`
+	UniqueWallets int              `json:"unique_wallets"`
+
+	// Set with top-tokens --wash-score, from analytics.WashTradingScore
+	WashTradingScore *float64 `json:"wash_trading_score,omitempty"`
+}
+
+// TopTokens ranks the tokens traded in swaps by USD volume, then swap count.
+// Each swap counts towards both of its tokens. Swaps should already be price
+// enriched or every token will have zero volume. n <= 0 returns every token.
+func TopTokens(swaps []*model.SwapData, n int) []*TokenRankRow {
+	rows := make(map[solana.PublicKey]*TokenRankRow)
+	wallets := make(map[solana.PublicKey]map[solana.PublicKey]struct{})
+
+	for _, s := range swaps {
+		for _, mint := range []solana.PublicKey{s.TokenInMint, s.TokenOutMint} {
+			row, ok := rows[mint]
+			if !ok {
+				row = &TokenRankRow{Mint: mint, Symbol: tokenmetadata.Symbol(mint)}
+				rows[mint] = row
+				wallets[mint] = make(map[solana.PublicKey]struct{})
+			}
+			row.VolumeUSD += s.VolumeUSD
+			row.SwapCount++
+			wallets[mint][s.Signer] = struct{}{}
+		}
+	}
+
+	ranked := make([]*TokenRankRow, 0, len(rows))
+	for mint, row := range rows {
+		row.UniqueWallets = len(wallets[mint])
+		ranked = append(ranked, row)
+	}
+	slices.SortFunc(ranked, func(a, b *TokenRankRow) int {
+		return cmp.Or(
+			cmp.Compare(b.VolumeUSD, a.VolumeUSD),
+			cmp.Compare(b.SwapCount, a.SwapCount),
+			cmp.Compare(a.Mint.String(), b.Mint.String()),
+		)
+	})
+	return rank(ranked, n, func(row *TokenRankRow, r int) { row.Rank = r })
+}
+
+// rank truncates sorted rows to n (n <= 0 keeps them all) and numbers them from 1
+func rank[T any](sorted []T, n int, setRank func(row T, rank int)) []T {
+	if n > 0 && len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	for i, row := range sorted {
+		setRank(row, i+1)
+	}
+	return sorted
+}
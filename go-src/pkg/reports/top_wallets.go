@@ -0,0 +1,64 @@
+package reports
+
+import (
+	"cmp"
+	"slices"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// WalletRankRow is one wallet in a TopWallets report
+type WalletRankRow struct {
+	Rank   int              `json:"rank"`
+	Wallet solana.PublicKey `json:"wallet"`
+	// Display name such as a .sol domain; not set by TopWallets
+	Name string `json:"name,omitempty"`
+
+	SwapCount    int     `json:"swap_count"`
+	VolumeUSD    float64 `json:"volume_usd"`
+	UniqueTokens int     `json:"unique_tokens"`
+
+	// Net USD value received minus sent across the wallet's swaps where
+	// both legs were priced, i.e. how the wallet's fills compare with the
+	// prices the batch was enriched at
+	EstimatedPnL float64 `json:"estimated_pnl"`
+}
+
+// TopWallets ranks the signers of swaps by USD volume, then swap count.
+// n <= 0 returns every wallet.
+func TopWallets(swaps []*model.SwapData, n int) []*WalletRankRow {
+	rows := make(map[solana.PublicKey]*WalletRankRow)
+	tokens := make(map[solana.PublicKey]map[solana.PublicKey]struct{})
+
+	for _, s := range swaps {
+		row, ok := rows[s.Signer]
+		if !ok {
+			row = &WalletRankRow{Wallet: s.Signer}
+			rows[s.Signer] = row
+			tokens[s.Signer] = make(map[solana.PublicKey]struct{})
+		}
+		row.SwapCount++
+		row.VolumeUSD += s.VolumeUSD
+		if s.ValueInUSD > 0 && s.ValueOutUSD > 0 {
+			row.EstimatedPnL += s.ValueOutUSD - s.ValueInUSD
+		}
+		tokens[s.Signer][s.TokenInMint] = struct{}{}
+		tokens[s.Signer][s.TokenOutMint] = struct{}{}
+	}
+
+	ranked := make([]*WalletRankRow, 0, len(rows))
+	for wallet, row := range rows {
+		row.UniqueTokens = len(tokens[wallet])
+		ranked = append(ranked, row)
+	}
+	slices.SortFunc(ranked, func(a, b *WalletRankRow) int {
+		return cmp.Or(
+			cmp.Compare(b.VolumeUSD, a.VolumeUSD),
+			cmp.Compare(b.SwapCount, a.SwapCount),
+			cmp.Compare(a.Wallet.String(), b.Wallet.String()),
+		)
+	})
+	return rank(ranked, n, func(row *WalletRankRow, r int) { row.Rank = r })
+}
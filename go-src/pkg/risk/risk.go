@@ -0,0 +1,230 @@
+// Package risk scores swaps for patterns that suggest a scam, an attack or
+// a bot.
+package risk
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/mev"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+)
+
+// Points each factor adds to the score when it applies. They sum to more
+// than 100 so a few strong signals are enough to max the score out.
+const (
+	pointsLargeValue        = 15
+	pointsNewToken          = 25
+	pointsUnverifiedProgram = 20
+	pointsHighSlippage      = 15
+	pointsSandwichVictim    = 25
+	pointsFailedRatio       = 15
+)
+
+// MaxScore is the highest score a swap can get
+const MaxScore = 100
+
+// RiskFactor is one detector that fired
+type RiskFactor struct {
+	Name   string `json:"name"`
+	Points int    `json:"points"`
+	Detail string `json:"detail"`
+}
+
+// RiskReport is the outcome of scoring a swap
+type RiskReport struct {
+	Signature solana.Signature `json:"signature"`
+	// Sum of the factors' points, capped at MaxScore
+	Score   int          `json:"score"`
+	Factors []RiskFactor `json:"factors"`
+}
+
+// Scorer runs the detectors. The thresholds can be changed after NewScorer.
+type Scorer struct {
+	rpc *rpc.Client
+
+	// Swaps worth at least this much are flagged
+	LargeValueUSD float64
+
+	// Tokens whose mint has no history older than this at swap time are flagged
+	NewTokenAge time.Duration
+
+	// Fills this much worse than the block's median price for the pair are
+	// flagged, e.g. 0.05 for 5%
+	HighSlippage float64
+
+	// Signers whose recent transactions failed at least this often are
+	// flagged; bots spamming speculative transactions fail constantly
+	FailedRatio float64
+	// How many of the signer's recent transactions to sample
+	FailedSample int
+}
+
+// NewScorer returns a Scorer with the default thresholds
+func NewScorer(rpcClient *rpc.Client) *Scorer {
+	return &Scorer{
+		rpc:           rpcClient,
+		LargeValueUSD: 100_000,
+		NewTokenAge:   24 * time.Hour,
+		HighSlippage:  0.05,
+		FailedRatio:   0.5,
+		FailedSample:  100,
+	}
+}
+
+// maxMintHistoryPages bounds how far back the new-token check pages through
+// a mint's history before giving up
+const maxMintHistoryPages = 10
+
+// Score scores block[victim]. block must be the swaps of its slot in block
+// order, used for the slippage and sandwich detectors, and should be price
+// enriched for the large-value detector.
+func (sc *Scorer) Score(ctx context.Context, block []*model.SwapData, victim int) (*RiskReport, error) {
+	s := block[victim]
+	report := &RiskReport{Signature: s.Signature, Factors: []RiskFactor{}}
+	add := func(name string, points int, detail string, args ...any) {
+		report.Factors = append(report.Factors, RiskFactor{Name: name, Points: points, Detail: fmt.Sprintf(detail, args...)})
+	}
+
+	if s.VolumeUSD >= sc.LargeValueUSD {
+		add("large_value", pointsLargeValue, "swap worth $%.0f", s.VolumeUSD)
+	}
+
+	for _, mint := range []solana.PublicKey{s.TokenInMint, s.TokenOutMint} {
+		if _, known := tokenmetadata.Lookup(mint); known || mint.Equals(solana.SolMint) {
+			continue
+		}
+		age, ok, err := sc.mintAge(ctx, mint, s)
+		if err != nil {
+			return nil, err
+		}
+		if ok && age < sc.NewTokenAge {
+			add("new_token", pointsNewToken, "mint %s was first used %s before the swap", mint, age.Round(time.Minute))
+			break
+		}
+	}
+
+	if s.Dex == "" {
+		add("unverified_program", pointsUnverifiedProgram, "swap did not go through a registered program")
+	}
+
+	if slippage, ok := blockSlippage(block, victim); ok && slippage >= sc.HighSlippage {
+		add("high_slippage", pointsHighSlippage, "filled %.1f%% worse than the block's median price", slippage*100)
+	}
+
+	if sandwich, ok := mev.FindSandwich(block, victim); ok {
+		add("sandwich_victim", pointsSandwichVictim, "sandwiched by %s (%s, %s)", sandwich.Frontrun.Signer, sandwich.Frontrun.Signature, sandwich.Backrun.Signature)
+	}
+
+	failed, total, err := sc.failures(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	if total > 0 && float64(failed)/float64(total) >= sc.FailedRatio {
+		add("failed_simulation_ratio", pointsFailedRatio, "%d of the signer's last %d transactions failed", failed, total)
+	}
+
+	for _, f := range report.Factors {
+		report.Score += f.Points
+	}
+	report.Score = min(report.Score, MaxScore)
+	return report, nil
+}
+
+// mintAge returns how long before s the mint's oldest transaction was. ok
+// is false if that couldn't be established within maxMintHistoryPages.
+func (sc *Scorer) mintAge(ctx context.Context, mint solana.PublicKey, s *model.SwapData) (time.Duration, bool, error) {
+	limit := 1000
+	before := s.Signature
+	cutoff := s.BlockTime.Add(-sc.NewTokenAge)
+	var oldest time.Time
+
+	for range maxMintHistoryPages {
+		page, err := sc.rpc.GetSignaturesForAddressWithOpts(ctx, mint, &rpc.GetSignaturesForAddressOpts{
+			Limit:      &limit,
+			Before:     before,
+			Commitment: rpc.CommitmentConfirmed,
+		})
+		if err != nil {
+			return 0, false, fmt.Errorf("fetching signatures for %s: %w", mint, err)
+		}
+		if len(page) == 0 {
+			if oldest.IsZero() {
+				// The swap is the mint's first transaction
+				return 0, true, nil
+			}
+			return s.BlockTime.Sub(oldest), true, nil
+		}
+		for _, sig := range page {
+			if sig.BlockTime == nil {
+				continue
+			}
+			oldest = sig.BlockTime.Time()
+			if oldest.Before(cutoff) {
+				return s.BlockTime.Sub(oldest), true, nil
+			}
+		}
+		before = page[len(page)-1].Signature
+	}
+	return 0, false, nil
+}
+
+// failures counts the failed transactions among the signer's FailedSample
+// most recent up to and including s
+func (sc *Scorer) failures(ctx context.Context, s *model.SwapData) (failed, total int, err error) {
+	limit := sc.FailedSample
+	page, err := sc.rpc.GetSignaturesForAddressWithOpts(ctx, s.Signer, &rpc.GetSignaturesForAddressOpts{
+		Limit:      &limit,
+		Before:     s.Signature,
+		Commitment: rpc.CommitmentConfirmed,
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("fetching signatures for %s: %w", s.Signer, err)
+	}
+	for _, sig := range page {
+		if sig.Err != nil {
+			failed++
+		}
+	}
+	// The swap itself landed
+	return failed, len(page) + 1, nil
+}
+
+// blockSlippage returns how much worse block[victim]'s price was than the
+// median price of the other swaps of its pair in the block. ok is false if
+// there were fewer than two to compare with.
+func blockSlippage(block []*model.SwapData, victim int) (float64, bool) {
+	v := block[victim]
+	if v.AmountInUI <= 0 || v.AmountOutUI <= 0 {
+		return 0, false
+	}
+
+	// Prices are in units of the victim's input token per output token
+	var prices []float64
+	for i, s := range block {
+		if i == victim || s.AmountInUI <= 0 || s.AmountOutUI <= 0 {
+			continue
+		}
+		switch {
+		case s.TokenInMint.Equals(v.TokenInMint) && s.TokenOutMint.Equals(v.TokenOutMint):
+			prices = append(prices, s.AmountInUI/s.AmountOutUI)
+		case s.TokenInMint.Equals(v.TokenOutMint) && s.TokenOutMint.Equals(v.TokenInMint):
+			prices = append(prices, s.AmountOutUI/s.AmountInUI)
+		}
+	}
+	if len(prices) < 2 {
+		return 0, false
+	}
+	slices.Sort(prices)
+	median := prices[len(prices)/2]
+	if len(prices)%2 == 0 {
+		median = (prices[len(prices)/2-1] + median) / 2
+	}
+	return v.AmountInUI/v.AmountOutUI/median - 1, true
+}
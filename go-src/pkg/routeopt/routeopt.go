@@ -0,0 +1,79 @@
+// Package routeopt suggests which DEX has historically given the best
+// output for a swap. It only looks back at fills; it doesn't quote or route.
+package routeopt
+
+import (
+	"cmp"
+	"slices"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/price"
+)
+
+// MinSwaps is how many fills in the direction asked a DEX needs for its
+// median rate to be trusted
+var MinSwaps = 3
+
+// DexRate is one DEX's historical rate for the swap
+type DexRate struct {
+	Dex       string `json:"dex"`
+	SwapCount int    `json:"swap_count"`
+	// Median units of the output token received per unit of input
+	MedianRate float64 `json:"median_rate"`
+	// MedianRate applied to the requested amount
+	ExpectedOut float64 `json:"expected_out"`
+}
+
+// RouteSuggestion is the DEX with the best historical rate, and how the
+// others compared
+type RouteSuggestion struct {
+	InMint  solana.PublicKey `json:"in_mint"`
+	OutMint solana.PublicKey `json:"out_mint"`
+	Amount  float64          `json:"amount"`
+
+	Best *DexRate `json:"best"`
+	// Every DEX with enough history, best first, including Best
+	Ranked []*DexRate `json:"ranked"`
+}
+
+// Suggest ranks the DEXes in history by their median effective rate for
+// swapping inMint to outMint, and returns nil if none has MinSwaps fills in
+// that direction. Rates are taken from the fills' UI amounts, so they
+// include LP fees and price impact at whatever size each fill was.
+func Suggest(inMint, outMint solana.PublicKey, amount float64, history []*model.SwapData) *RouteSuggestion {
+	rates := make(map[string][]float64)
+	for _, s := range history {
+		if !s.TokenInMint.Equals(inMint) || !s.TokenOutMint.Equals(outMint) || s.AmountInUI <= 0 || s.AmountOutUI <= 0 || s.Dex == "" {
+			continue
+		}
+		rates[s.Dex] = append(rates[s.Dex], s.AmountOutUI/s.AmountInUI)
+	}
+
+	suggestion := &RouteSuggestion{InMint: inMint, OutMint: outMint, Amount: amount}
+	for dex, r := range rates {
+		if len(r) < MinSwaps {
+			continue
+		}
+		rate := price.Median(r)
+		suggestion.Ranked = append(suggestion.Ranked, &DexRate{
+			Dex:         dex,
+			SwapCount:   len(r),
+			MedianRate:  rate,
+			ExpectedOut: amount * rate,
+		})
+	}
+	if len(suggestion.Ranked) == 0 {
+		return nil
+	}
+	slices.SortFunc(suggestion.Ranked, func(a, b *DexRate) int {
+		return cmp.Or(
+			cmp.Compare(b.MedianRate, a.MedianRate),
+			cmp.Compare(b.SwapCount, a.SwapCount),
+			cmp.Compare(a.Dex, b.Dex),
+		)
+	})
+	suggestion.Best = suggestion.Ranked[0]
+	return suggestion
+}
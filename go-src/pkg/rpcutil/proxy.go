@@ -0,0 +1,55 @@
+package rpcutil
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/proxy"
+)
+
+// NewProxyClient returns a copy of base that reaches the endpoint through
+// httpProxy (an http:// or https:// URL) or socks5Proxy (socks5://, with
+// optional user:pass). With neither set base is returned unchanged, and
+// uses HTTP_PROXY/HTTPS_PROXY/NO_PROXY as http.DefaultTransport does.
+func NewProxyClient(base *http.Transport, httpProxy, socks5Proxy string) (*http.Transport, error) {
+	switch {
+	case httpProxy != "" && socks5Proxy != "":
+		return nil, errors.New("--http-proxy and --socks5-proxy can't both be set")
+	case httpProxy != "":
+		u, err := url.Parse(httpProxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --http-proxy: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("invalid --http-proxy: want an http:// or https:// URL, got %q", httpProxy)
+		}
+		transport := base.Clone()
+		transport.Proxy = http.ProxyURL(u)
+		return transport, nil
+	case socks5Proxy != "":
+		u, err := url.Parse(socks5Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --socks5-proxy: %w", err)
+		}
+		if u.Scheme != "socks5" && u.Scheme != "socks5h" {
+			return nil, fmt.Errorf("invalid --socks5-proxy: want a socks5:// URL, got %q", socks5Proxy)
+		}
+		dialer, err := proxy.FromURL(u, proxy.Direct)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --socks5-proxy: %w", err)
+		}
+		contextDialer, ok := dialer.(proxy.ContextDialer)
+		if !ok {
+			return nil, errors.New("invalid --socks5-proxy: dialer doesn't support contexts")
+		}
+		transport := base.Clone()
+		// Every connection goes through the SOCKS dialer, so an HTTP proxy
+		// from the environment would only be tunnelled through it
+		transport.Proxy = nil
+		transport.DialContext = contextDialer.DialContext
+		return transport, nil
+	}
+	return base, nil
+}
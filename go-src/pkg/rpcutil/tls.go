@@ -0,0 +1,48 @@
+// Package rpcutil configures the HTTP transport under the RPC client.
+package rpcutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// NewTLSClient returns a copy of base set up for endpoints requiring mutual
+// TLS: it presents the certificate in certFile/keyFile, and if caFile is set
+// trusts only the CAs in it instead of the system pool. certFile and keyFile
+// must be given together; caFile alone only changes the trusted CAs.
+func NewTLSClient(base *http.Transport, certFile, keyFile, caFile string) (*http.Transport, error) {
+	if (certFile == "") != (keyFile == "") {
+		return nil, errors.New("--tls-cert and --tls-key must be given together")
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if base.TLSClientConfig != nil {
+		config = base.TLSClientConfig.Clone()
+	}
+
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates in %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+
+	transport := base.Clone()
+	transport.TLSClientConfig = config
+	return transport, nil
+}
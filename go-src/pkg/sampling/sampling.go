@@ -0,0 +1,25 @@
+// Package sampling picks random subsets of batch inputs.
+package sampling
+
+import "math/rand/v2"
+
+// Reservoir returns a uniformly random sample of n items using Vitter's
+// Algorithm R, in no particular order. If there are no more than n items
+// they are all returned.
+func Reservoir[T any](items []T, n int) []T {
+	if n <= 0 {
+		return nil
+	}
+	if len(items) <= n {
+		return append([]T(nil), items...)
+	}
+
+	sample := append([]T(nil), items[:n]...)
+	for i := n; i < len(items); i++ {
+		// Item i replaces a random reservoir slot with probability n/(i+1)
+		if j := rand.IntN(i + 1); j < n {
+			sample[j] = items[i]
+		}
+	}
+	return sample
+}
@@ -0,0 +1,148 @@
+// Package sanctum parses swaps between liquid staking tokens routed
+// through Sanctum.
+package sanctum
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/lstake"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// RouterProgramID is the Sanctum Router, which swaps one LST for another by
+// withdrawing from one stake pool and depositing into the other
+var RouterProgramID = solana.MustPublicKeyFromBase58("stkitrT1Uoy18Dk1fTrgPw8W6MVzoCfYoAFT4MLsmhq")
+
+// ErrNoSanctumSwap is returned when a transaction doesn't use the router
+var ErrNoSanctumSwap = errors.New("no Sanctum router instruction in transaction")
+
+// stakePools names the stake pool programs the router delegates to
+var stakePools = map[solana.PublicKey]string{
+	solana.MustPublicKeyFromBase58("SPoo1Ku8WFXoNDMHPsrGSTSG1Y47rzgn41SLUNakuHy"): "SPL Stake Pool",
+	solana.MustPublicKeyFromBase58("SP12tWFxD9oJsVWNavTTBZvMbA6gkAmxtVgxdqvyvhY"): "Sanctum SPL Stake Pool",
+	solana.MustPublicKeyFromBase58("SPMBzsVUuoHA4Jm6KunbsotaahvVikZs1JyTW6iJvbn"): "Sanctum SPL Multi Stake Pool",
+	lstake.MarinadeProgramID: "Marinade",
+	solana.MustPublicKeyFromBase58("CrX7kMhLC3cSsXJdT7JDgqrRVWGnUpX3gfEfxxU2NVLi"): "Lido",
+}
+
+// routerProtocol is LSTProtocol when no known stake pool was invoked
+const routerProtocol = "Sanctum"
+
+// ParseSanctumSwap returns the LST swap a transaction made through the
+// Sanctum Router, or ErrNoSanctumSwap. The router hands off to each LST's
+// stake pool program, so rather than decoding those the legs are taken from
+// the signer's token balance changes: the mint it lost most of is the input
+// and the mint it gained most of the output. SOL sent to a stake pool
+// without being wrapped is the input when no token balance fell. Only the
+// legs and LST fields are set.
+func ParseSanctumSwap(tx *rpc.GetTransactionResult) (*model.SwapData, error) {
+	instructions, err := txutil.Instructions(tx)
+	if err != nil {
+		return nil, err
+	}
+	routed := false
+	var protocols []string
+	for _, ix := range instructions {
+		if ix.ProgramID.Equals(RouterProgramID) {
+			routed = true
+		}
+		if name, ok := stakePools[ix.ProgramID]; ok && !slices.Contains(protocols, name) {
+			protocols = append(protocols, name)
+		}
+	}
+	if !routed {
+		return nil, ErrNoSanctumSwap
+	}
+	keys, err := txutil.AccountKeys(tx)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Meta == nil || len(keys) == 0 {
+		return nil, errors.New("transaction has no balance changes")
+	}
+
+	in, out := legs(tx.Meta, keys[0])
+	if in == nil {
+		in = nativeSOLSpent(tx.Meta)
+	}
+	if in == nil || out == nil {
+		return nil, errors.New("signer's token balances don't show a swap")
+	}
+	if len(protocols) == 0 {
+		protocols = []string{routerProtocol}
+	}
+	return &model.SwapData{
+		TokenInMint:      in.mint,
+		TokenInAmount:    uint64(-in.change),
+		TokenInDecimals:  in.decimals,
+		AmountInUI:       model.UIAmount(uint64(-in.change), in.decimals),
+		TokenOutMint:     out.mint,
+		TokenOutAmount:   uint64(out.change),
+		TokenOutDecimals: out.decimals,
+		AmountOutUI:      model.UIAmount(uint64(out.change), out.decimals),
+		IsLSTSwap:        true,
+		LSTProtocol:      strings.Join(protocols, ", "),
+	}, nil
+}
+
+// balanceChange is the net change in an owner's balance of one mint
+type balanceChange struct {
+	mint     solana.PublicKey
+	decimals uint8
+	change   int64
+}
+
+// legs returns the owner's largest token decrease and increase, nil where
+// there is none
+func legs(meta *rpc.TransactionMeta, owner solana.PublicKey) (in, out *balanceChange) {
+	changes := make(map[solana.PublicKey]*balanceChange)
+	add := func(balances []rpc.TokenBalance, sign int64) {
+		for _, b := range balances {
+			if b.Owner == nil || !b.Owner.Equals(owner) || b.UiTokenAmount == nil {
+				continue
+			}
+			amount, err := strconv.ParseInt(b.UiTokenAmount.Amount, 10, 64)
+			if err != nil {
+				continue
+			}
+			c, ok := changes[b.Mint]
+			if !ok {
+				c = &balanceChange{mint: b.Mint, decimals: b.UiTokenAmount.Decimals}
+				changes[b.Mint] = c
+			}
+			c.change += sign * amount
+		}
+	}
+	add(meta.PreTokenBalances, -1)
+	add(meta.PostTokenBalances, 1)
+
+	for _, c := range changes {
+		switch {
+		case c.change < 0 && (in == nil || c.change < in.change):
+			in = c
+		case c.change > 0 && (out == nil || c.change > out.change):
+			out = c
+		}
+	}
+	return in, out
+}
+
+// nativeSOLSpent returns the fee payer's lamports spent beyond the fee as a
+// wrapped SOL leg, or nil
+func nativeSOLSpent(meta *rpc.TransactionMeta) *balanceChange {
+	if len(meta.PreBalances) == 0 || len(meta.PostBalances) == 0 {
+		return nil
+	}
+	spent := int64(meta.PreBalances[0]) - int64(meta.PostBalances[0]) - int64(meta.Fee)
+	if spent <= 0 {
+		return nil
+	}
+	return &balanceChange{mint: solana.WrappedSol, decimals: 9, change: -spent}
+}
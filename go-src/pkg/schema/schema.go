@@ -0,0 +1,138 @@
+// Package schema describes getswaps' output records as JSON Schema.
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Draft is the JSON Schema version generated
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+)
+
+// Generate returns the indented JSON Schema for T as encoding/json would
+// marshal it. Fields without omitempty are required. Types that marshal
+// themselves as text (keys, signatures) are strings, and other custom
+// marshalers and interface fields accept any value.
+func Generate[T any]() ([]byte, error) {
+	t := reflect.TypeFor[T]()
+	s := schemaFor(t, map[reflect.Type]bool{})
+	s["$schema"] = Draft
+	s["title"] = t.Name()
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// schemaFor builds the schema for t. seen holds the structs being expanded,
+// so recursive types end in an open schema rather than looping.
+func schemaFor(t reflect.Type, seen map[reflect.Type]bool) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		s := schemaFor(t.Elem(), seen)
+		if typ, ok := s["type"].(string); ok {
+			s["type"] = []string{typ, "null"}
+		}
+		return s
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case implements(t, textMarshalerType):
+		return map[string]any{"type": "string"}
+	case implements(t, jsonMarshalerType):
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		// encoding/json writes byte slices as base64
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": []string{"string", "null"}, "contentEncoding": "base64"}
+		}
+		s := map[string]any{"type": "array", "items": schemaFor(t.Elem(), seen)}
+		if t.Kind() == reflect.Array {
+			s["minItems"], s["maxItems"] = t.Len(), t.Len()
+		} else {
+			// A nil slice marshals as null
+			s["type"] = []string{"array", "null"}
+		}
+		return s
+	case reflect.Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": schemaFor(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]any{}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		properties := map[string]any{}
+		required := []string{}
+		addFields(t, seen, properties, &required)
+		return map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	default:
+		// Interfaces can hold anything
+		return map[string]any{}
+	}
+}
+
+// addFields adds t's fields to properties, inlining untagged embedded
+// structs as encoding/json does
+func addFields(t reflect.Type, seen map[reflect.Type]bool, properties map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addFields(embedded, seen, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = schemaFor(field.Type, seen)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
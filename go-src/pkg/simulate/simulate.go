@@ -0,0 +1,164 @@
+// Package simulate previews the swap a transaction would make by simulating
+// it and diffing the signer's balances, without broadcasting it.
+package simulate
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// Snapshot is the state of the accounts requested from a simulation, taken
+// just before simulating. simulateTransaction only returns post-state, so
+// this is what ParseSimulatedSwap diffs against.
+type Snapshot struct {
+	// Fee payer, whose balances define the swap. Always Addresses[0].
+	Signer    solana.PublicKey
+	Addresses []solana.PublicKey
+	Pre       []*rpc.Account
+}
+
+// tokenAccountLen is the size of an SPL token account; Token-2022 accounts
+// with extensions are longer but share the same prefix
+const tokenAccountLen = 165
+
+// Prepare snapshots the fee payer and every writable static account of tx.
+// Accounts loaded from address lookup tables aren't included, which is
+// fine for the signer's own token accounts as wallets don't put them in
+// lookup tables.
+func Prepare(ctx context.Context, rpcClient *rpc.Client, tx *solana.Transaction) (*Snapshot, error) {
+	if len(tx.Message.AccountKeys) == 0 {
+		return nil, errors.New("transaction has no accounts")
+	}
+	signer := tx.Message.AccountKeys[0]
+	addresses := []solana.PublicKey{signer}
+	for _, key := range tx.Message.AccountKeys[1:] {
+		if writable, _ := tx.Message.IsWritable(key); writable {
+			addresses = append(addresses, key)
+		}
+	}
+
+	pre, err := rpcClient.GetMultipleAccountsWithOpts(ctx, addresses, &rpc.GetMultipleAccountsOpts{Encoding: solana.EncodingBase64})
+	if err != nil {
+		return nil, fmt.Errorf("fetching accounts: %w", err)
+	}
+	return &Snapshot{Signer: signer, Addresses: addresses, Pre: pre.Value}, nil
+}
+
+// Simulate runs tx against the current bank, returning the post-state of
+// the snapshot's accounts. Signatures aren't verified and the blockhash is
+// replaced, so unsigned or stale transactions can still be previewed.
+func (s *Snapshot) Simulate(ctx context.Context, rpcClient *rpc.Client, tx *solana.Transaction) (*rpc.SimulateTransactionResult, error) {
+	resp, err := rpcClient.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+		ReplaceRecentBlockhash: true,
+		Commitment:             rpc.CommitmentConfirmed,
+		Accounts: &rpc.SimulateTransactionAccountsOpts{
+			Encoding:  solana.EncodingBase64,
+			Addresses: s.Addresses,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Value == nil {
+		return nil, errors.New("empty simulation result")
+	}
+	return resp.Value, nil
+}
+
+// ParseSimulatedSwap works out the swap from the change in the signer's
+// token balances between the snapshot and the simulation. The mint that
+// decreased most is the input and the one that increased most the output;
+// if only one side moved a token, the other is taken to be native SOL.
+// Decimals are only set for SOL, since mint accounts aren't part of the
+// simulation.
+func ParseSimulatedSwap(simResult *rpc.SimulateTransactionResult, snapshot *Snapshot) (*model.SwapData, error) {
+	if simResult.Err != nil {
+		return nil, fmt.Errorf("simulation failed: %v", simResult.Err)
+	}
+	if len(simResult.Accounts) != len(snapshot.Addresses) {
+		return nil, fmt.Errorf("simulation returned %d accounts, expected %d", len(simResult.Accounts), len(snapshot.Addresses))
+	}
+
+	deltas := make(map[solana.PublicKey]int64)
+	for i := range snapshot.Addresses {
+		if mint, owner, amount, ok := tokenBalance(snapshot.Pre[i]); ok && owner == snapshot.Signer {
+			deltas[mint] -= int64(amount)
+		}
+		if mint, owner, amount, ok := tokenBalance(simResult.Accounts[i]); ok && owner == snapshot.Signer {
+			deltas[mint] += int64(amount)
+		}
+	}
+
+	// Wrapped SOL is usually opened and closed within the swap, so count
+	// the signer's native balance towards the SOL mint
+	var nativeDelta int64
+	if snapshot.Pre[0] != nil && simResult.Accounts[0] != nil {
+		nativeDelta = int64(simResult.Accounts[0].Lamports) - int64(snapshot.Pre[0].Lamports)
+	}
+
+	var in, out solana.PublicKey
+	var inDelta, outDelta int64
+	for mint, delta := range deltas {
+		if mint == solana.SolMint {
+			continue
+		}
+		if delta < inDelta {
+			in, inDelta = mint, delta
+		}
+		if delta > outDelta {
+			out, outDelta = mint, delta
+		}
+	}
+	solDelta := nativeDelta + deltas[solana.SolMint]
+	if inDelta == 0 && solDelta < 0 {
+		in, inDelta = solana.SolMint, solDelta
+	}
+	if outDelta == 0 && solDelta > 0 {
+		out, outDelta = solana.SolMint, solDelta
+	}
+	if inDelta == 0 || outDelta == 0 {
+		return nil, errors.New("simulation did not change the signer's balances like a swap")
+	}
+
+	swap := &model.SwapData{
+		Signer:         snapshot.Signer,
+		TokenInMint:    in,
+		TokenInAmount:  uint64(-inDelta),
+		TokenOutMint:   out,
+		TokenOutAmount: uint64(outDelta),
+	}
+	if in == solana.SolMint {
+		swap.TokenInDecimals = 9
+	}
+	if out == solana.SolMint {
+		swap.TokenOutDecimals = 9
+	}
+	swap.AmountInUI = model.UIAmount(swap.TokenInAmount, swap.TokenInDecimals)
+	swap.AmountOutUI = model.UIAmount(swap.TokenOutAmount, swap.TokenOutDecimals)
+	return swap, nil
+}
+
+// tokenBalance decodes an SPL token account into its mint, owner and raw amount
+func tokenBalance(account *rpc.Account) (mint, owner solana.PublicKey, amount uint64, ok bool) {
+	if account == nil || account.Data == nil {
+		return
+	}
+	if account.Owner != solana.TokenProgramID && account.Owner != solana.Token2022ProgramID {
+		return
+	}
+	data := account.Data.GetBinary()
+	if len(data) < tokenAccountLen {
+		return
+	}
+	mint = solana.PublicKeyFromBytes(data[0:32])
+	owner = solana.PublicKeyFromBytes(data[32:64])
+	amount = binary.LittleEndian.Uint64(data[64:72])
+	return mint, owner, amount, true
+}
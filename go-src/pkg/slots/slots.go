@@ -0,0 +1,115 @@
+// Package slots converts wall-clock times into slot numbers.
+package slots
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// slotDuration is the target slot time, used to estimate where to start searching
+const slotDuration = 400 * time.Millisecond
+
+// maxSkippedProbe bounds how many consecutive slots are probed past a
+// skipped slot before giving up on finding a produced block
+const maxSkippedProbe = 64
+
+// FindSlotForTime returns the first slot whose block time is at or after t.
+// It estimates the slot from the current tip assuming ~400ms slots, widens
+// the estimate until it is before t, then binary searches over GetBlockTime.
+// Times after the current finalized tip return the tip.
+func FindSlotForTime(ctx context.Context, rpcClient *rpc.Client, t time.Time) (uint64, error) {
+	tip, err := rpcClient.GetSlot(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return 0, fmt.Errorf("getting current slot: %w", err)
+	}
+	tipTime, err := blockTime(ctx, rpcClient, tip)
+	if err != nil {
+		return 0, fmt.Errorf("getting block time for slot %d: %w", tip, err)
+	}
+	if !t.Before(tipTime) {
+		return tip, nil
+	}
+
+	first, err := rpcClient.GetFirstAvailableBlock(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("getting first available block: %w", err)
+	}
+
+	// Find a lower bound whose block time is before t, starting from the
+	// estimate and doubling the distance from the tip each time it misses
+	gap := uint64(tipTime.Sub(t)/slotDuration) + 1
+	hi := tip
+	var lo uint64
+	for {
+		if gap >= tip-first {
+			lo = first
+		} else {
+			lo = tip - gap
+		}
+
+		loSlot, loTime, err := blockTimeAtOrAfter(ctx, rpcClient, lo, tip)
+		if err != nil {
+			return 0, err
+		}
+		if loTime.Before(t) {
+			// Block times aren't strictly increasing, so the produced slot
+			// found may lie past hi; keep lo below it
+			lo = min(loSlot, hi-1)
+			break
+		}
+		if lo == first {
+			// t is before the oldest block this node still has
+			return loSlot, nil
+		}
+		hi = lo
+		gap *= 2
+	}
+
+	// Invariant: block time at lo < t, and every block at or after hi has a
+	// block time >= t (hi itself may be a skipped slot)
+	for lo < hi && hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		slot, slotTime, err := blockTimeAtOrAfter(ctx, rpcClient, mid, tip)
+		if err != nil {
+			return 0, err
+		}
+		// A produced slot at or past hi means [mid, hi) was all skipped
+		if slotTime.Before(t) && slot < hi {
+			lo = slot
+		} else {
+			hi = mid
+		}
+	}
+	return hi, nil
+}
+
+// blockTimeAtOrAfter returns the first produced slot in [slot, limit] and its
+// block time, stepping over skipped slots which have no block time
+func blockTimeAtOrAfter(ctx context.Context, rpcClient *rpc.Client, slot, limit uint64) (uint64, time.Time, error) {
+	var lastErr error
+	for s := slot; s <= limit && s < slot+maxSkippedProbe; s++ {
+		bt, err := blockTime(ctx, rpcClient, s)
+		if err == nil {
+			return s, bt, nil
+		}
+		if ctx.Err() != nil {
+			return 0, time.Time{}, ctx.Err()
+		}
+		lastErr = err
+	}
+	return 0, time.Time{}, fmt.Errorf("no block time found for slots %d-%d: %w", slot, min(limit, slot+maxSkippedProbe-1), lastErr)
+}
+
+func blockTime(ctx context.Context, rpcClient *rpc.Client, slot uint64) (time.Time, error) {
+	bt, err := rpcClient.GetBlockTime(ctx, slot)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if bt == nil {
+		return time.Time{}, fmt.Errorf("block time not available for slot %d", slot)
+	}
+	return bt.Time(), nil
+}
@@ -0,0 +1,146 @@
+// Package sns resolves wallet addresses to their .sol domains using the
+// Solana Name Service.
+package sns
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+var (
+	// NameProgramID owns every SNS name and reverse lookup account
+	NameProgramID = solana.MustPublicKeyFromBase58("namesLPneVptA9Z5rqUDD9tMTWEJwofgaYwp8cawRkX")
+	// NameOffersProgramID stores each wallet's chosen (favourite) domain
+	NameOffersProgramID = solana.MustPublicKeyFromBase58("85iDfUvr3HJyLM2zcq5BXSiDvUWfw6cSE1FfNBo8Ap29")
+	// ReverseLookupClass is the class of the accounts mapping a domain key back to its name
+	ReverseLookupClass = solana.MustPublicKeyFromBase58("33m47vH6Eav6jr5Ry86XjhRft2jRBLDnDgPSHoquXi2Z")
+	// RootDomain is the parent of every top-level .sol domain
+	RootDomain = solana.MustPublicKeyFromBase58("58PwtjSDuFHuUkYjH9BYnnQKHfwo9reZhC2zMJv9JPkx")
+)
+
+// ErrNoDomain is returned for wallets without a usable .sol domain
+var ErrNoDomain = errors.New("wallet has no .sol domain")
+
+const (
+	hashPrefix = "SPL Name Service"
+	// Name registry accounts start with parent, owner and class keys
+	registryHeaderLen = 96
+)
+
+// Resolver looks up a wallet's favourite .sol domain, caching every result
+// (including misses) for the life of the resolver
+type Resolver struct {
+	mu    sync.Mutex
+	cache map[solana.PublicKey]result
+}
+
+type result struct {
+	name string
+	err  error
+}
+
+// NewResolver returns a resolver with an empty cache
+func NewResolver() *Resolver {
+	return &Resolver{cache: make(map[solana.PublicKey]result)}
+}
+
+// Resolve returns the wallet's favourite domain, e.g. "bonfida.sol". It
+// returns ErrNoDomain if the wallet hasn't set one, or no longer owns it.
+func (r *Resolver) Resolve(ctx context.Context, rpcClient *rpc.Client, pubkey solana.PublicKey) (string, error) {
+	r.mu.Lock()
+	cached, ok := r.cache[pubkey]
+	r.mu.Unlock()
+	if ok {
+		return cached.name, cached.err
+	}
+
+	name, err := resolve(ctx, rpcClient, pubkey)
+	// Don't cache failures that were only the caller giving up
+	if ctx.Err() == nil {
+		r.mu.Lock()
+		r.cache[pubkey] = result{name: name, err: err}
+		r.mu.Unlock()
+	}
+	return name, err
+}
+
+func resolve(ctx context.Context, rpcClient *rpc.Client, owner solana.PublicKey) (string, error) {
+	favourite, _, err := solana.FindProgramAddress([][]byte{[]byte("favourite_domain"), owner.Bytes()}, NameOffersProgramID)
+	if err != nil {
+		return "", err
+	}
+	// Favourite domain accounts are a tag byte followed by the domain's name account
+	data, err := accountData(ctx, rpcClient, favourite)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < 33 {
+		return "", fmt.Errorf("favourite domain account %s is too short", favourite)
+	}
+	domain := solana.PublicKeyFromBytes(data[1:33])
+
+	// The favourite is only meaningful while the wallet still owns the domain
+	registry, err := accountData(ctx, rpcClient, domain)
+	if err != nil {
+		return "", err
+	}
+	if len(registry) < registryHeaderLen {
+		return "", fmt.Errorf("name account %s is too short", domain)
+	}
+	parent := solana.PublicKeyFromBytes(registry[0:32])
+	if solana.PublicKeyFromBytes(registry[32:64]) != owner {
+		return "", ErrNoDomain
+	}
+	// Subdomains need their parent's name too; only top-level domains are supported
+	if parent != RootDomain {
+		return "", ErrNoDomain
+	}
+
+	name, err := reverseLookup(ctx, rpcClient, domain)
+	if err != nil {
+		return "", err
+	}
+	return name + ".sol", nil
+}
+
+// reverseLookup returns the name stored in a domain's reverse lookup account
+func reverseLookup(ctx context.Context, rpcClient *rpc.Client, domain solana.PublicKey) (string, error) {
+	hashed := sha256.Sum256([]byte(hashPrefix + domain.String()))
+	reverse, _, err := solana.FindProgramAddress([][]byte{hashed[:], ReverseLookupClass.Bytes(), make([]byte, 32)}, NameProgramID)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := accountData(ctx, rpcClient, reverse)
+	if err != nil {
+		return "", err
+	}
+	// After the header the name is a borsh string: u32 length then bytes
+	if len(data) < registryHeaderLen+4 {
+		return "", fmt.Errorf("reverse lookup account %s is too short", reverse)
+	}
+	body := data[registryHeaderLen:]
+	n := binary.LittleEndian.Uint32(body[:4])
+	if int(n) > len(body)-4 {
+		return "", fmt.Errorf("reverse lookup account %s has a bad name length", reverse)
+	}
+	return string(body[4 : 4+n]), nil
+}
+
+func accountData(ctx context.Context, rpcClient *rpc.Client, account solana.PublicKey) ([]byte, error) {
+	info, err := rpcClient.GetAccountInfo(ctx, account)
+	if errors.Is(err, rpc.ErrNotFound) {
+		return nil, ErrNoDomain
+	}
+	if err != nil {
+		return nil, err
+	}
+	return info.GetBinary(), nil
+}
@@ -0,0 +1,174 @@
+// Package stake decodes native stake program instructions.
+package stake
+
+import (
+	"encoding/binary"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/txutil"
+)
+
+// EventType is the stake instruction an event came from
+type EventType string
+
+const (
+	Delegate   EventType = "delegate"
+	Split      EventType = "split"
+	Withdraw   EventType = "withdraw"
+	Deactivate EventType = "deactivate"
+	Merge      EventType = "merge"
+)
+
+// Stake program instruction discriminators (a little-endian u32)
+const (
+	ixDelegateStake = 2
+	ixSplit         = 3
+	ixWithdraw      = 4
+	ixDeactivate    = 5
+	ixMerge         = 7
+)
+
+// StakeEvent is one decoded stake instruction
+type StakeEvent struct {
+	Type             EventType `json:"type"`
+	InstructionIndex int       `json:"instruction_index"`
+
+	StakeAccount solana.PublicKey `json:"stake_account"`
+	// The stake authority, or the withdraw authority for withdrawals
+	Authority solana.PublicKey `json:"authority"`
+
+	// Lamports moved: the amount split off or withdrawn, or for the other
+	// types the stake account's balance (for Merge, the source's balance
+	// before it was merged)
+	Amount uint64 `json:"amount"`
+
+	// Only set for Delegate
+	ValidatorVoteAccount *solana.PublicKey `json:"validator_vote_account,omitempty"`
+
+	// The new account for Split, recipient for Withdraw, or the merged-away
+	// source account for Merge
+	Counterparty *solana.PublicKey `json:"counterparty,omitempty"`
+}
+
+// ParseStakeInstruction decodes the Delegate, Withdraw, Split, Merge and
+// Deactivate instructions in a transaction, including ones invoked by other
+// programs such as stake pools. Other stake instructions are skipped.
+func ParseStakeInstruction(tx *rpc.GetTransactionResult) ([]StakeEvent, error) {
+	instructions, err := txutil.Instructions(tx)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := txutil.AccountKeys(tx)
+	if err != nil {
+		return nil, err
+	}
+	balances := balanceLookup(tx, keys)
+
+	var events []StakeEvent
+	for _, ix := range instructions {
+		if !ix.ProgramID.Equals(solana.StakeProgramID) || len(ix.Data) < 4 {
+			continue
+		}
+		if event, ok := decode(ix, balances); ok {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// decode returns the event for one stake instruction, if it is a type we track
+func decode(ix txutil.Instruction, balances func(solana.PublicKey, bool) uint64) (StakeEvent, bool) {
+	event := StakeEvent{InstructionIndex: ix.Index}
+	account := func(i int) (solana.PublicKey, bool) {
+		if i >= len(ix.Accounts) {
+			return solana.PublicKey{}, false
+		}
+		return ix.Accounts[i], true
+	}
+	amount := func() (uint64, bool) {
+		if len(ix.Data) < 12 {
+			return 0, false
+		}
+		return binary.LittleEndian.Uint64(ix.Data[4:12]), true
+	}
+
+	var stakeIdx, authorityIdx, counterpartyIdx int
+	switch binary.LittleEndian.Uint32(ix.Data[:4]) {
+	case ixDelegateStake:
+		// stake, vote, clock, stake history, config, authority
+		event.Type, stakeIdx, authorityIdx, counterpartyIdx = Delegate, 0, 5, -1
+		vote, ok := account(1)
+		if !ok {
+			return StakeEvent{}, false
+		}
+		event.ValidatorVoteAccount = &vote
+	case ixSplit:
+		// stake, new stake, authority
+		event.Type, stakeIdx, authorityIdx, counterpartyIdx = Split, 0, 2, 1
+	case ixWithdraw:
+		// stake, recipient, clock, stake history, withdraw authority
+		event.Type, stakeIdx, authorityIdx, counterpartyIdx = Withdraw, 0, 4, 1
+	case ixDeactivate:
+		// stake, clock, authority
+		event.Type, stakeIdx, authorityIdx, counterpartyIdx = Deactivate, 0, 2, -1
+	case ixMerge:
+		// destination, source, clock, stake history, authority
+		event.Type, stakeIdx, authorityIdx, counterpartyIdx = Merge, 0, 4, 1
+	default:
+		return StakeEvent{}, false
+	}
+
+	var ok bool
+	if event.StakeAccount, ok = account(stakeIdx); !ok {
+		return StakeEvent{}, false
+	}
+	if event.Authority, ok = account(authorityIdx); !ok {
+		return StakeEvent{}, false
+	}
+	if counterpartyIdx >= 0 {
+		counterparty, ok := account(counterpartyIdx)
+		if !ok {
+			return StakeEvent{}, false
+		}
+		event.Counterparty = &counterparty
+	}
+
+	switch event.Type {
+	case Split, Withdraw:
+		if event.Amount, ok = amount(); !ok {
+			return StakeEvent{}, false
+		}
+	case Merge:
+		event.Amount = balances(*event.Counterparty, true)
+	default:
+		event.Amount = balances(event.StakeAccount, false)
+	}
+	return event, true
+}
+
+// balanceLookup returns a function giving an account's lamports before
+// (pre) or after the transaction, or 0 if unknown
+func balanceLookup(tx *rpc.GetTransactionResult, keys solana.PublicKeySlice) func(solana.PublicKey, bool) uint64 {
+	index := make(map[solana.PublicKey]int, len(keys))
+	for i, key := range keys {
+		if _, dup := index[key]; !dup {
+			index[key] = i
+		}
+	}
+	return func(account solana.PublicKey, pre bool) uint64 {
+		if tx.Meta == nil {
+			return 0
+		}
+		balances := tx.Meta.PostBalances
+		if pre {
+			balances = tx.Meta.PreBalances
+		}
+		i, ok := index[account]
+		if !ok || i >= len(balances) {
+			return 0
+		}
+		return balances[i]
+	}
+}
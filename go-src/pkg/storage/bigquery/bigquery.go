@@ -0,0 +1,330 @@
+// Package bigquery writes swaps to a Google BigQuery table over the Storage
+// Write API.
+package bigquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"time"
+
+	cloudbigquery "cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/managedwriter"
+	"cloud.google.com/go/bigquery/storage/managedwriter/adapt"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage"
+)
+
+// BatchSize is how many rows are sent per append
+const BatchSize = 500
+
+// Config names the table to write to
+type Config struct {
+	Project string
+	Dataset string
+	Table   string
+
+	// Also store each result's getTransaction response in storage.RawTable
+	StoreRaw bool
+}
+
+// Writer appends swaps to BigQuery through the table's default stream.
+// Each batch lands atomically, but the default stream doesn't deduplicate,
+// so unlike the other backends re-running over the same signatures stores
+// them again.
+type Writer struct {
+	ctx    context.Context
+	client *managedwriter.Client
+	swaps  *stream
+	raw    *stream
+}
+
+// stream is an open default stream to one table and the rows waiting to be
+// appended to it
+type stream struct {
+	ms      *managedwriter.ManagedStream
+	message protoreflect.MessageDescriptor
+	pending [][]byte
+}
+
+// NewWriter creates the dataset and table if they don't exist and opens a
+// write stream to the table
+func NewWriter(ctx context.Context, cfg Config) (*Writer, error) {
+	if cfg.Table == "" {
+		cfg.Table = storage.DefaultTable
+	}
+	if err := createTables(ctx, cfg); err != nil {
+		return nil, err
+	}
+	// The client and its streams keep the context they're opened with, and
+	// must outlive a cancelled run so Close can append the last rows
+	streamCtx := context.WithoutCancel(ctx)
+	client, err := managedwriter.NewClient(streamCtx, cfg.Project)
+	if err != nil {
+		return nil, err
+	}
+	w := &Writer{ctx: ctx, client: client}
+	if w.swaps, err = openStream(streamCtx, client, cfg, cfg.Table, Schema()); err != nil {
+		client.Close()
+		return nil, err
+	}
+	if cfg.StoreRaw {
+		if w.raw, err = openStream(streamCtx, client, cfg, storage.RawTable, rawSchema); err != nil {
+			w.swaps.ms.Close()
+			client.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Schema returns the swaps table's schema
+func Schema() cloudbigquery.Schema {
+	schema := make(cloudbigquery.Schema, len(storage.Columns))
+	for i, c := range storage.Columns {
+		schema[i] = &cloudbigquery.FieldSchema{Name: c.Name, Type: columnType(c.Type), Required: !c.Nullable}
+	}
+	return schema
+}
+
+// rawSchema is storage.RawTable's schema
+var rawSchema = cloudbigquery.Schema{
+	{Name: "signature", Type: cloudbigquery.StringFieldType, Required: true},
+	{Name: "transaction", Type: cloudbigquery.JSONFieldType, Required: true},
+}
+
+func columnType(t storage.ColumnType) cloudbigquery.FieldType {
+	switch t {
+	case storage.Int:
+		return cloudbigquery.IntegerFieldType
+	case storage.Uint64:
+		return cloudbigquery.NumericFieldType
+	case storage.Float:
+		return cloudbigquery.FloatFieldType
+	case storage.Bool:
+		return cloudbigquery.BooleanFieldType
+	case storage.Timestamp:
+		return cloudbigquery.TimestampFieldType
+	default:
+		return cloudbigquery.StringFieldType
+	}
+}
+
+// createTables creates the dataset, the swaps table partitioned by day of
+// block_time and clustered by dex and token_in_mint, and the raw table if
+// it's wanted. Each is left alone if it already exists, except that an
+// older swaps table gets the hop_index column.
+func createTables(ctx context.Context, cfg Config) error {
+	client, err := cloudbigquery.NewClient(ctx, cfg.Project)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	dataset := client.Dataset(cfg.Dataset)
+	if err := dataset.Create(ctx, nil); err != nil && !isConflict(err) {
+		return fmt.Errorf("creating dataset %s: %w", cfg.Dataset, err)
+	}
+	swaps := &cloudbigquery.TableMetadata{
+		Schema:           Schema(),
+		TimePartitioning: &cloudbigquery.TimePartitioning{Type: cloudbigquery.DayPartitioningType, Field: "block_time"},
+		Clustering:       &cloudbigquery.Clustering{Fields: []string{"dex", "token_in_mint"}},
+	}
+	table := dataset.Table(cfg.Table)
+	if err := table.Create(ctx, swaps); err != nil {
+		if !isConflict(err) {
+			return fmt.Errorf("creating table %s: %w", cfg.Table, err)
+		}
+		if err := addHopIndex(ctx, table); err != nil {
+			return fmt.Errorf("migrating table %s: %w", cfg.Table, err)
+		}
+	}
+	if cfg.StoreRaw {
+		raw := &cloudbigquery.TableMetadata{Schema: rawSchema}
+		if err := dataset.Table(storage.RawTable).Create(ctx, raw); err != nil && !isConflict(err) {
+			return fmt.Errorf("creating table %s: %w", storage.RawTable, err)
+		}
+	}
+	return nil
+}
+
+// addHopIndex adds the hop_index column to a table created before it
+// existed. BigQuery only adds NULLABLE columns, so older rows read as NULL.
+func addHopIndex(ctx context.Context, table *cloudbigquery.Table) error {
+	md, err := table.Metadata(ctx)
+	if err != nil {
+		return err
+	}
+	for _, f := range md.Schema {
+		if f.Name == "hop_index" {
+			return nil
+		}
+	}
+	schema := append(md.Schema, &cloudbigquery.FieldSchema{Name: "hop_index", Type: cloudbigquery.IntegerFieldType})
+	_, err = table.Update(ctx, cloudbigquery.TableMetadataToUpdate{Schema: schema}, md.ETag)
+	return err
+}
+
+// isConflict reports whether a create failed because the resource exists
+func isConflict(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict
+}
+
+// openStream opens the default stream of a table, with rows described by a
+// message type built from its schema
+func openStream(ctx context.Context, client *managedwriter.Client, cfg Config, table string, schema cloudbigquery.Schema) (*stream, error) {
+	tableSchema, err := adapt.BQSchemaToStorageTableSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	descriptor, err := adapt.StorageSchemaToProto2Descriptor(tableSchema, "row")
+	if err != nil {
+		return nil, err
+	}
+	message, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("schema of %s didn't convert to a message", table)
+	}
+	normalized, err := adapt.NormalizeDescriptor(message)
+	if err != nil {
+		return nil, err
+	}
+	ms, err := client.NewManagedStream(ctx,
+		managedwriter.WithDestinationTable(managedwriter.TableParentFromParts(cfg.Project, cfg.Dataset, table)),
+		managedwriter.WithType(managedwriter.DefaultStream),
+		managedwriter.WithSchemaDescriptor(normalized),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("opening stream to %s: %w", table, err)
+	}
+	return &stream{ms: ms, message: message}, nil
+}
+
+func (w *Writer) Write(r *model.Result) error {
+	for _, swap := range r.AllSwaps() {
+		row, err := w.swaps.encode(storage.ColumnNames(), storage.Row(swap))
+		if err != nil {
+			return err
+		}
+		w.swaps.pending = append(w.swaps.pending, row)
+	}
+	if w.raw != nil {
+		values, err := storage.RawRow(r)
+		if err != nil {
+			return err
+		}
+		if values != nil {
+			row, err := w.raw.encode([]string{"signature", "transaction"}, values)
+			if err != nil {
+				return err
+			}
+			w.raw.pending = append(w.raw.pending, row)
+		}
+	}
+	if len(w.swaps.pending) >= BatchSize {
+		return w.flush(w.ctx)
+	}
+	return nil
+}
+
+// encode serialises a row of values, in the same order as columns, as the
+// stream's message type. Uint64 values go to NUMERIC columns and a nil is
+// a NULL.
+func (s *stream) encode(columns []string, values []any) ([]byte, error) {
+	msg := dynamicpb.NewMessage(s.message)
+	for i, v := range values {
+		field := s.message.Fields().ByName(protoreflect.Name(columns[i]))
+		if field == nil {
+			return nil, fmt.Errorf("no column %s in the table schema", columns[i])
+		}
+		var value protoreflect.Value
+		switch v := v.(type) {
+		case nil:
+			continue
+		case string:
+			value = protoreflect.ValueOfString(v)
+		case int64:
+			value = protoreflect.ValueOfInt64(v)
+		case uint64:
+			value = protoreflect.ValueOfBytes(numericBytes(v))
+		case float64:
+			value = protoreflect.ValueOfFloat64(v)
+		case bool:
+			value = protoreflect.ValueOfBool(v)
+		case time.Time:
+			value = protoreflect.ValueOfInt64(v.UnixMicro())
+		default:
+			return nil, fmt.Errorf("column %s: unsupported type %T", columns[i], v)
+		}
+		msg.Set(field, value)
+	}
+	return proto.Marshal(msg)
+}
+
+// numericScale is NUMERIC's fixed scale of 9 decimal places
+var numericScale = big.NewInt(1_000_000_000)
+
+// numericBytes encodes v as the Storage Write API takes NUMERIC values: the
+// value times 10^9 as a little-endian two's complement integer
+func numericBytes(v uint64) []byte {
+	scaled := new(big.Int).Mul(new(big.Int).SetUint64(v), numericScale)
+	b := scaled.Bytes()
+	slices.Reverse(b)
+	// A set top bit would make a positive value read as negative
+	if len(b) == 0 || b[len(b)-1]&0x80 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// flush appends the pending rows and waits for BigQuery to accept them
+func (w *Writer) flush(ctx context.Context) error {
+	err := w.swaps.flush(ctx)
+	if w.raw != nil {
+		if rerr := w.raw.flush(ctx); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
+
+func (s *stream) flush(ctx context.Context) error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	result, err := s.ms.AppendRows(ctx, s.pending)
+	s.pending = nil
+	if err != nil {
+		return err
+	}
+	_, err = result.GetResult(ctx)
+	return err
+}
+
+// Close appends any remaining rows and closes the streams
+func (w *Writer) Close() error {
+	ctx, cancel := storage.CloseContext(w.ctx)
+	defer cancel()
+	err := w.flush(ctx)
+	for _, s := range []*stream{w.swaps, w.raw} {
+		if s == nil {
+			continue
+		}
+		if cerr := s.ms.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if cerr := w.client.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,77 @@
+package bigquery
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cloudbigquery "cloud.google.com/go/bigquery"
+	"google.golang.org/api/iterator"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage"
+)
+
+// Purger deletes old swaps from a BigQuery table
+type Purger struct {
+	client *cloudbigquery.Client
+	table  string
+}
+
+// NewPurger connects to the project named by cfg
+func NewPurger(ctx context.Context, cfg Config) (*Purger, error) {
+	if cfg.Table == "" {
+		cfg.Table = storage.DefaultTable
+	}
+	client, err := cloudbigquery.NewClient(ctx, cfg.Project)
+	if err != nil {
+		return nil, err
+	}
+	return &Purger{client: client, table: fmt.Sprintf("`%s.%s.%s`", cfg.Project, cfg.Dataset, cfg.Table)}, nil
+}
+
+// Purge deletes with DML. Since the table is partitioned by block_time, a
+// cutoff on a day boundary drops whole partitions.
+func (p *Purger) Purge(ctx context.Context, before time.Time) (int64, error) {
+	job, err := p.query("DELETE FROM ", before).Run(ctx)
+	if err != nil {
+		return 0, err
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := status.Err(); err != nil {
+		return 0, err
+	}
+	query, ok := status.Statistics.Details.(*cloudbigquery.QueryStatistics)
+	if !ok {
+		return 0, nil
+	}
+	return query.NumDMLAffectedRows, nil
+}
+
+func (p *Purger) CountBefore(ctx context.Context, before time.Time) (int64, error) {
+	rows, err := p.query("SELECT COUNT(*) FROM ", before).Read(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var row []cloudbigquery.Value
+	if err := rows.Next(&row); err != nil {
+		if err == iterator.Done {
+			return 0, fmt.Errorf("count returned no rows")
+		}
+		return 0, err
+	}
+	n, _ := row[0].(int64)
+	return n, nil
+}
+
+func (p *Purger) query(prefix string, before time.Time) *cloudbigquery.Query {
+	q := p.client.Query(prefix + p.table + " WHERE block_time < @before")
+	q.Parameters = []cloudbigquery.QueryParameter{{Name: "before", Value: before.UTC()}}
+	return q
+}
+
+func (p *Purger) Close() error {
+	return p.client.Close()
+}
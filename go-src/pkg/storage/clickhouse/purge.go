@@ -0,0 +1,56 @@
+package clickhouse
+
+import (
+	"context"
+	"time"
+
+	ch "github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage"
+)
+
+// Purger deletes old swaps from a ClickHouse table
+type Purger struct {
+	conn  driver.Conn
+	table string
+}
+
+// NewPurger connects to the server named by cfg
+func NewPurger(cfg Config) (*Purger, error) {
+	if cfg.Table == "" {
+		cfg.Table = storage.DefaultTable
+	}
+	opts, err := ch.ParseDSN(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := ch.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Purger{conn: conn, table: cfg.Table}, nil
+}
+
+// Purge runs a lightweight DELETE, which doesn't report how many rows it
+// removed, so the count is taken first
+func (p *Purger) Purge(ctx context.Context, before time.Time) (int64, error) {
+	n, err := p.CountBefore(ctx, before)
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	if err := p.conn.Exec(ctx, "DELETE FROM "+p.table+" WHERE block_time < ?", before.UTC()); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (p *Purger) CountBefore(ctx context.Context, before time.Time) (int64, error) {
+	var n uint64
+	err := p.conn.QueryRow(ctx, "SELECT count() FROM "+p.table+" WHERE block_time < ?", before.UTC()).Scan(&n)
+	return int64(n), err
+}
+
+func (p *Purger) Close() error {
+	return p.conn.Close()
+}
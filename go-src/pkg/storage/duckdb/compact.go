@@ -0,0 +1,74 @@
+package duckdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Compact rewrites the file at path without the space left by deleted
+// rows, returning its size before and after. DuckDB's VACUUM doesn't
+// shrink the file, so the database is checkpointed, copied into a fresh
+// file and the copy moved over the original. The file must not be open
+// elsewhere.
+func Compact(ctx context.Context, path string) (before, after int64, err error) {
+	if before, err = fileSize(path); err != nil {
+		return 0, 0, err
+	}
+	tmp := path + ".compact"
+	os.Remove(tmp)
+	if err := copyDatabase(ctx, path, tmp); err != nil {
+		os.Remove(tmp)
+		return 0, 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return 0, 0, err
+	}
+	after, err = fileSize(path)
+	return before, after, err
+}
+
+func copyDatabase(ctx context.Context, path, tmp string) error {
+	db, err := sql.Open("duckdb", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	// Fold the WAL into the file so the copy sees every change, and refresh
+	// the optimizer's statistics on the way
+	if _, err := db.ExecContext(ctx, "CHECKPOINT; VACUUM ANALYZE"); err != nil {
+		return err
+	}
+	var name string
+	if err := db.QueryRowContext(ctx, "SELECT current_database()").Scan(&name); err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, fmt.Sprintf("ATTACH %s AS compacted; COPY FROM DATABASE %s TO compacted; DETACH compacted", quoteString(tmp), quoteIdentifier(name)))
+	if err != nil {
+		return fmt.Errorf("copying %s: %w", path, err)
+	}
+	return nil
+}
+
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// quoteString makes s a SQL string literal, for statements like ATTACH
+// that take no parameters
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// quoteIdentifier makes s a quoted SQL identifier. The database name comes
+// from the file name, so it can hold anything a path can.
+func quoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
@@ -0,0 +1,161 @@
+// Package duckdb writes swaps to a local DuckDB file and runs queries
+// against it.
+package duckdb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/marcboeker/go-duckdb"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage"
+)
+
+// DefaultPath is the database file used unless configured otherwise
+const DefaultPath = "swaps.duckdb"
+
+// Config names the file and table to write to
+type Config struct {
+	Path  string
+	Table string
+
+	// Also store each result's getTransaction response in
+	// storage.RawTable, so reindex can re-parse it later
+	StoreRaw bool
+}
+
+// Writer appends swaps through DuckDB's appender API, a row for each swap
+// in a result. The appender can't upsert, so the table has no primary key
+// and writing a signature twice stores its swaps twice.
+type Writer struct {
+	connector *duckdb.Connector
+	db        *sql.DB
+	conn      driver.Conn
+	appender  *duckdb.Appender
+
+	// Set with Config.StoreRaw
+	raw *sql.Stmt
+}
+
+// NewWriter opens the file, creating it if it doesn't exist, and applies
+// any pending migrations. A table other than the default is created with
+// the default table's columns, copied without their NOT NULL constraints.
+func NewWriter(ctx context.Context, cfg Config) (*Writer, error) {
+	if cfg.Path == "" {
+		cfg.Path = DefaultPath
+	}
+	if cfg.Table == "" {
+		cfg.Table = storage.DefaultTable
+	}
+	connector, err := duckdb.NewConnector(cfg.Path, nil)
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(connector)
+	if err := migrate(ctx, db, cfg.Table); err != nil {
+		db.Close()
+		connector.Close()
+		return nil, err
+	}
+	var raw *sql.Stmt
+	if cfg.StoreRaw {
+		if raw, err = prepareRaw(ctx, db); err != nil {
+			db.Close()
+			connector.Close()
+			return nil, err
+		}
+	}
+
+	conn, err := connector.Connect(ctx)
+	if err != nil {
+		closeRaw(raw)
+		db.Close()
+		connector.Close()
+		return nil, err
+	}
+	appender, err := duckdb.NewAppenderFromConn(conn, "", cfg.Table)
+	if err != nil {
+		closeRaw(raw)
+		conn.Close()
+		db.Close()
+		connector.Close()
+		return nil, err
+	}
+	return &Writer{connector: connector, db: db, conn: conn, appender: appender, raw: raw}, nil
+}
+
+func (w *Writer) Write(r *model.Result) error {
+	if w.raw != nil {
+		if err := storeRaw(w.raw, r); err != nil {
+			return err
+		}
+	}
+	for _, swap := range r.AllSwaps() {
+		row := storage.Row(swap)
+		values := make([]driver.Value, len(row))
+		for i, v := range row {
+			values[i] = v
+		}
+		if err := w.appender.AppendRow(values...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close flushes the appender and closes the file
+func (w *Writer) Close() error {
+	err := w.appender.Close()
+	closeRaw(w.raw)
+	w.conn.Close()
+	w.db.Close()
+	w.connector.Close()
+	return err
+}
+
+// closeRaw closes the Config.StoreRaw statement, if there is one
+func closeRaw(raw *sql.Stmt) {
+	if raw != nil {
+		raw.Close()
+	}
+}
+
+// Query runs query against the file at path and returns each result row as
+// a map of column name to value
+func Query(ctx context.Context, path, query string) ([]map[string]any, error) {
+	db, err := sql.Open("duckdb", path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	results := []map[string]any{}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]any, len(columns))
+		for i, c := range columns {
+			row[c] = values[i]
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,67 @@
+package duckdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage"
+)
+
+// Exporter reads swaps back out of a DuckDB file
+type Exporter struct {
+	db    *sql.DB
+	table string
+}
+
+// NewExporter opens the file named by cfg
+func NewExporter(cfg Config) (*Exporter, error) {
+	if cfg.Path == "" {
+		cfg.Path = DefaultPath
+	}
+	if cfg.Table == "" {
+		cfg.Table = storage.DefaultTable
+	}
+	db, err := sql.Open("duckdb", cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &Exporter{db: db, table: cfg.Table}, nil
+}
+
+func (e *Exporter) Export(ctx context.Context, where string, fn func(s *model.SwapData) error) error {
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(storage.ColumnNames(), ", "), e.table)
+	if where != "" {
+		query += " WHERE " + where
+	}
+	rows, err := e.db.QueryContext(ctx, query+" ORDER BY block_time, signature, hop_index")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	values := make([]any, len(storage.Columns))
+	ptrs := make([]any, len(values))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		s, err := storage.SwapFromRow(values)
+		if err != nil {
+			return err
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (e *Exporter) Close() error {
+	return e.db.Close()
+}
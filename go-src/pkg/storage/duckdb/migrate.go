@@ -0,0 +1,54 @@
+package duckdb
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage"
+)
+
+// migrations holds the schema of the default tables, one numbered change
+// per file. The first creates the tables only if they don't exist, so files
+// written before migrations were tracked are adopted as they are.
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// NewMigrator returns a Migrator for the DuckDB schema. Migrations apply to
+// the default table names; tables chosen with Config.Table are created by
+// NewWriter from the default one.
+func NewMigrator() (*storage.Migrator, error) {
+	sub, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewMigrator(sub)
+}
+
+// migrate applies the pending migrations and creates table, if it isn't
+// the default, with the default table's columns
+func migrate(ctx context.Context, db *sql.DB, table string) error {
+	migrator, err := NewMigrator()
+	if err != nil {
+		return err
+	}
+	if err := migrator.Migrate(ctx, db); err != nil {
+		return err
+	}
+	if table == storage.DefaultTable {
+		return nil
+	}
+	_, err = db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+table+" AS SELECT * FROM "+storage.DefaultTable+" LIMIT 0")
+	if err != nil {
+		return fmt.Errorf("creating table %s: %w", table, err)
+	}
+	// Tables copied before hop_index existed don't have it
+	_, err = db.ExecContext(ctx, "ALTER TABLE "+table+" ADD COLUMN IF NOT EXISTS hop_index BIGINT DEFAULT 0")
+	if err != nil {
+		return fmt.Errorf("migrating table %s: %w", table, err)
+	}
+	return nil
+}
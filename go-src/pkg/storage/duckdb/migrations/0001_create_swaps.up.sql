@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS swaps (
+  signature VARCHAR NOT NULL,
+  slot BIGINT NOT NULL,
+  block_time TIMESTAMP NOT NULL,
+  dex VARCHAR NOT NULL,
+  dex_version VARCHAR NOT NULL,
+  dex_type VARCHAR NOT NULL,
+  program_id VARCHAR,
+  signer VARCHAR NOT NULL,
+  token_in_mint VARCHAR NOT NULL,
+  token_in_amount UBIGINT NOT NULL,
+  token_in_decimals BIGINT NOT NULL,
+  amount_in_ui DOUBLE NOT NULL,
+  token_out_mint VARCHAR NOT NULL,
+  token_out_amount UBIGINT NOT NULL,
+  token_out_decimals BIGINT NOT NULL,
+  amount_out_ui DOUBLE NOT NULL,
+  fee_lamports UBIGINT NOT NULL,
+  priority_fee_lamports UBIGINT NOT NULL,
+  failed BOOLEAN NOT NULL,
+  value_in_usd DOUBLE NOT NULL,
+  value_out_usd DOUBLE NOT NULL,
+  volume_usd DOUBLE NOT NULL
+);
@@ -0,0 +1 @@
+CREATE TABLE IF NOT EXISTS raw_transactions (signature VARCHAR PRIMARY KEY, transaction JSON NOT NULL);
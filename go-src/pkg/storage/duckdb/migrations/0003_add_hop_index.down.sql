@@ -0,0 +1 @@
+ALTER TABLE swaps DROP COLUMN IF EXISTS hop_index;
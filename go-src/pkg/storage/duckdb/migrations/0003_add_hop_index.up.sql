@@ -0,0 +1 @@
+ALTER TABLE swaps ADD COLUMN IF NOT EXISTS hop_index BIGINT DEFAULT 0;
@@ -0,0 +1,48 @@
+package duckdb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage"
+)
+
+// Purger deletes old swaps from a DuckDB file
+type Purger struct {
+	db    *sql.DB
+	table string
+}
+
+// NewPurger opens the file named by cfg
+func NewPurger(cfg Config) (*Purger, error) {
+	if cfg.Path == "" {
+		cfg.Path = DefaultPath
+	}
+	if cfg.Table == "" {
+		cfg.Table = storage.DefaultTable
+	}
+	db, err := sql.Open("duckdb", cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &Purger{db: db, table: cfg.Table}, nil
+}
+
+func (p *Purger) Purge(ctx context.Context, before time.Time) (int64, error) {
+	result, err := p.db.ExecContext(ctx, "DELETE FROM "+p.table+" WHERE block_time < ?", before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (p *Purger) CountBefore(ctx context.Context, before time.Time) (int64, error) {
+	var n int64
+	err := p.db.QueryRowContext(ctx, "SELECT count(*) FROM "+p.table+" WHERE block_time < ?", before.UTC()).Scan(&n)
+	return n, err
+}
+
+func (p *Purger) Close() error {
+	return p.db.Close()
+}
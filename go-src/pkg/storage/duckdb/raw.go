@@ -0,0 +1,117 @@
+package duckdb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage"
+)
+
+// prepareRaw prepares the statement storeRaw runs; the migrations create
+// storage.RawTable. Unlike the swaps table it upserts, so a transaction is
+// only kept once.
+func prepareRaw(ctx context.Context, db *sql.DB) (*sql.Stmt, error) {
+	return db.PrepareContext(ctx, "INSERT OR REPLACE INTO "+storage.RawTable+" VALUES (?, ?)")
+}
+
+func storeRaw(stmt *sql.Stmt, r *model.Result) error {
+	row, err := storage.RawRow(r)
+	if err != nil || row == nil {
+		return err
+	}
+	_, err = stmt.Exec(row...)
+	return err
+}
+
+// Reindexer re-parses the transactions in storage.RawTable and replaces their rows
+// in the swaps table
+type Reindexer struct {
+	db    *sql.DB
+	table string
+}
+
+// NewReindexer opens the file named by cfg
+func NewReindexer(cfg Config) (*Reindexer, error) {
+	if cfg.Path == "" {
+		cfg.Path = DefaultPath
+	}
+	if cfg.Table == "" {
+		cfg.Table = storage.DefaultTable
+	}
+	db, err := sql.Open("duckdb", cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &Reindexer{db: db, table: cfg.Table}, nil
+}
+
+// RawTransactions calls fn with each stored transaction and its signature.
+// A row that doesn't decode is passed to fn as an error.
+func (r *Reindexer) RawTransactions(ctx context.Context, fn func(signature string, tx *rpc.GetTransactionResult, err error)) error {
+	rows, err := r.db.QueryContext(ctx, "SELECT signature, transaction FROM "+storage.RawTable+" ORDER BY signature")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var signature, data string
+		if err := rows.Scan(&signature, &data); err != nil {
+			return err
+		}
+		var tx rpc.GetTransactionResult
+		if err := json.Unmarshal([]byte(data), &tx); err != nil {
+			fn(signature, nil, fmt.Errorf("decoding stored transaction: %w", err))
+			continue
+		}
+		fn(signature, &tx, nil)
+	}
+	return rows.Err()
+}
+
+// Replace swaps the stored rows for these swaps' signatures for the swaps
+// themselves, in one transaction. Every row of a signature goes, so a
+// transaction re-parsed into fewer swaps leaves none behind.
+func (r *Reindexer) Replace(ctx context.Context, swaps []*model.SwapData) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	remove, err := tx.PrepareContext(ctx, "DELETE FROM "+r.table+" WHERE signature = ?")
+	if err != nil {
+		return err
+	}
+	defer remove.Close()
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(storage.Columns)), ", ")
+	insert, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", r.table, strings.Join(storage.ColumnNames(), ", "), placeholders))
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	removed := make(map[solana.Signature]bool)
+	for _, s := range swaps {
+		if !removed[s.Signature] {
+			if _, err := remove.ExecContext(ctx, s.Signature.String()); err != nil {
+				return err
+			}
+			removed[s.Signature] = true
+		}
+		if _, err := insert.ExecContext(ctx, storage.Row(s)...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *Reindexer) Close() error {
+	return r.db.Close()
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// MigrationsTable records which migrations have been applied
+const MigrationsTable = "schema_migrations"
+
+// Migration is one numbered schema change, read from NNNN_name.up.sql and
+// NNNN_name.down.sql
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration and whether it has been applied
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migrator applies a backend's migrations to a database/sql database,
+// tracking them in MigrationsTable so each runs once
+type Migrator struct {
+	migrations []Migration
+}
+
+// NewMigrator reads the migrations in the root of fsys. Every migration
+// needs an up file; a missing down file makes it irreversible.
+func NewMigrator(fsys fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		m := migrationFile.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(m[1])
+		sqlText, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+		} else if migration.Name != m[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, m[2])
+		}
+		if m[3] == "up" {
+			migration.Up = string(sqlText)
+		} else {
+			migration.Down = string(sqlText)
+		}
+	}
+
+	var migrations []Migration
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	return &Migrator{migrations: migrations}, nil
+}
+
+// Migrate applies every pending migration
+func (m *Migrator) Migrate(ctx context.Context, db *sql.DB) error {
+	_, err := m.Up(ctx, db, 0)
+	return err
+}
+
+// Up applies the next n pending migrations, or all of them if n is 0, and
+// returns those it applied
+func (m *Migrator) Up(ctx context.Context, db *sql.DB, n int) ([]Migration, error) {
+	applied, err := m.applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if n > 0 && len(done) == n {
+			break
+		}
+		err := m.run(ctx, db, migration.Up,
+			"INSERT INTO "+MigrationsTable+" (version, name, applied_at) VALUES (?, ?, ?)",
+			migration.Version, migration.Name, time.Now().UTC())
+		if err != nil {
+			return done, fmt.Errorf("applying migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down rolls back the last n applied migrations, newest first, and returns
+// those it rolled back
+func (m *Migrator) Down(ctx context.Context, db *sql.DB, n int) ([]Migration, error) {
+	applied, err := m.applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, migration := range slices.Backward(m.migrations) {
+		if len(done) == n {
+			break
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return done, fmt.Errorf("migration %d_%s can't be rolled back", migration.Version, migration.Name)
+		}
+		err := m.run(ctx, db, migration.Down,
+			"DELETE FROM "+MigrationsTable+" WHERE version = ?", migration.Version)
+		if err != nil {
+			return done, fmt.Errorf("rolling back migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Status lists every migration in order and whether it has been applied
+func (m *Migrator) Status(ctx context.Context, db *sql.DB) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if at, ok := applied[migration.Version]; ok {
+			statuses[i].Applied = true
+			statuses[i].AppliedAt = &at
+		}
+	}
+	return statuses, nil
+}
+
+// run executes a migration's SQL and the bookkeeping statement in one
+// transaction, so a failed migration leaves nothing behind
+func (m *Migrator) run(ctx context.Context, db *sql.DB, migrationSQL, record string, args ...any) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, migrationSQL); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applied creates MigrationsTable if needed and returns when each applied
+// migration ran
+func (m *Migrator) applied(ctx context.Context, db *sql.DB) (map[int]time.Time, error) {
+	_, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+MigrationsTable+" (version INTEGER PRIMARY KEY, name VARCHAR NOT NULL, applied_at TIMESTAMP NOT NULL)")
+	if err != nil {
+		return nil, fmt.Errorf("creating %s: %w", MigrationsTable, err)
+	}
+	rows, err := db.QueryContext(ctx, "SELECT version, applied_at FROM "+MigrationsTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
@@ -0,0 +1,275 @@
+// Package opensearch indexes swaps into monthly OpenSearch indices.
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	opensearchgo "github.com/opensearch-project/opensearch-go/v4"
+	"github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage"
+)
+
+// BatchSize is how many documents are sent per bulk request
+const BatchSize = 500
+
+// IndexPrefix starts the name of every index swaps are written to, which
+// is followed by the year and month of their block time
+const IndexPrefix = "solana-swaps-"
+
+// indexPattern matches every swaps index
+const indexPattern = IndexPrefix + "*"
+
+// templateName names both the index template and the ISM policy
+const templateName = "solana-swaps"
+
+// IndexTemplate maps the swaps indices' fields: strings as keywords, since
+// they are keys, signatures and names rather than text, block_time as a
+// date and raw amounts as unsigned longs
+//
+//go:embed index_template.json
+var IndexTemplate []byte
+
+// ISMPolicy is the Index State Management policy attached to new swaps
+// indices: force-merged after 60 days and deleted after a year. Edit it in
+// the cluster to keep swaps for longer; it isn't overwritten once created.
+//
+//go:embed ism_policy.json
+var ISMPolicy []byte
+
+// rawMapping maps the storage.RawTable index, which keeps each document's
+// source for reindexing without indexing any of its fields
+const rawMapping = `{"mappings": {"enabled": false}}`
+
+// Config names the cluster to write to
+type Config struct {
+	URL      string
+	User     string
+	Password string
+
+	// Also index each result's getTransaction response in storage.RawTable
+	StoreRaw bool
+}
+
+func (c Config) client() (*opensearchapi.Client, error) {
+	return opensearchapi.NewClient(opensearchapi.Config{Client: opensearchgo.Config{
+		Addresses: []string{c.URL},
+		Username:  c.User,
+		Password:  c.Password,
+	}})
+}
+
+// IndexName is the index a swap is written to, from its block time's month
+func IndexName(s *model.SwapData) string {
+	return IndexPrefix + s.BlockTime.UTC().Format("2006-01")
+}
+
+// DocumentID is a swap's document ID, from its signature and hop index.
+// The first swap of a transaction is keyed by the signature alone, as every
+// swap was before transactions could hold several.
+func DocumentID(s *model.SwapData) string {
+	if s.HopIndex == 0 {
+		return s.Signature.String()
+	}
+	return s.Signature.String() + ":" + strconv.Itoa(s.HopIndex)
+}
+
+// Writer indexes swaps in bulk, a document for each swap in a result, using
+// DocumentID so re-running over the same signatures replaces them
+type Writer struct {
+	ctx      context.Context
+	client   *opensearchapi.Client
+	storeRaw bool
+	pending  bytes.Buffer
+	docs     int
+}
+
+// NewWriter connects to the cluster and installs the index template and
+// ISM policy, and with Config.StoreRaw creates the raw index
+func NewWriter(ctx context.Context, cfg Config) (*Writer, error) {
+	client, err := cfg.client()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := client.IndexTemplate.Create(ctx, opensearchapi.IndexTemplateCreateReq{
+		IndexTemplate: templateName,
+		Body:          bytes.NewReader(IndexTemplate),
+	}); err != nil {
+		return nil, fmt.Errorf("creating index template %s: %w", templateName, err)
+	}
+	if err := putPolicy(ctx, client); err != nil {
+		return nil, err
+	}
+	if cfg.StoreRaw {
+		if err := createRawIndex(ctx, client); err != nil {
+			return nil, err
+		}
+	}
+	return &Writer{ctx: ctx, client: client, storeRaw: cfg.StoreRaw}, nil
+}
+
+// rawRequest is a request the client has no typed call for
+type rawRequest struct {
+	method string
+	path   string
+	body   []byte
+}
+
+func (r rawRequest) GetRequest() (*http.Request, error) {
+	return opensearchgo.BuildRequest(r.method, r.path, bytes.NewReader(r.body), nil, http.Header{"Content-Type": {"application/json"}})
+}
+
+// putPolicy creates the ISM policy unless one with its name exists.
+// Elasticsearch has no ISM plugin, so there the policy is skipped and
+// indices are kept until deleted by hand or with purge.
+func putPolicy(ctx context.Context, client *opensearchapi.Client) error {
+	resp, err := client.Client.Do(ctx, rawRequest{method: http.MethodPut, path: "/_plugins/_ism/policies/" + templateName, body: ISMPolicy}, nil)
+	if err != nil {
+		return fmt.Errorf("creating ISM policy %s: %w", templateName, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return nil
+	case http.StatusConflict, http.StatusBadRequest, http.StatusNotFound, http.StatusMethodNotAllowed:
+		if policyExists(body) || noISM(resp.StatusCode, body) {
+			return nil
+		}
+	}
+	return fmt.Errorf("creating ISM policy %s: %s: %s", templateName, resp.Status(), body)
+}
+
+// createRawIndex creates the storage.RawTable index unless it exists
+func createRawIndex(ctx context.Context, client *opensearchapi.Client) error {
+	resp, err := client.Client.Do(ctx, rawRequest{method: http.MethodPut, path: "/" + storage.RawTable, body: []byte(rawMapping)}, nil)
+	if err != nil {
+		return fmt.Errorf("creating index %s: %w", storage.RawTable, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusBadRequest && policyExists(body) {
+		return nil
+	}
+	return fmt.Errorf("creating index %s: %s: %s", storage.RawTable, resp.Status(), body)
+}
+
+// policyExists reports whether a failed policy or index PUT was refused
+// only because the policy or index is already there
+func policyExists(body []byte) bool {
+	var resp struct {
+		Error struct {
+			Type string `json:"type"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return false
+	}
+	switch resp.Error.Type {
+	case "version_conflict_engine_exception", "resource_already_exists_exception":
+		return true
+	}
+	return false
+}
+
+// noISM reports whether a failed policy PUT came from a cluster without
+// the ISM plugin, which has no handler for its path
+func noISM(status int, body []byte) bool {
+	if status == http.StatusMethodNotAllowed {
+		return true
+	}
+	return bytes.Contains(body, []byte("no handler found for uri"))
+}
+
+func (w *Writer) Write(r *model.Result) error {
+	for _, swap := range r.AllSwaps() {
+		doc, err := json.Marshal(swap)
+		if err != nil {
+			return err
+		}
+		if err := w.add(IndexName(swap), DocumentID(swap), doc); err != nil {
+			return err
+		}
+	}
+	if w.storeRaw {
+		raw, err := storage.RawRow(r)
+		if err != nil {
+			return err
+		}
+		if raw != nil {
+			doc, err := json.Marshal(map[string]json.RawMessage{"transaction": json.RawMessage(raw[1].(string))})
+			if err != nil {
+				return err
+			}
+			if err := w.add(storage.RawTable, raw[0].(string), doc); err != nil {
+				return err
+			}
+		}
+	}
+	if w.docs >= BatchSize {
+		return w.flush(w.ctx)
+	}
+	return nil
+}
+
+// add queues doc to be indexed in index with id
+func (w *Writer) add(index, id string, doc []byte) error {
+	action, err := json.Marshal(map[string]any{"index": map[string]string{"_index": index, "_id": id}})
+	if err != nil {
+		return err
+	}
+	w.pending.Write(action)
+	w.pending.WriteByte('\n')
+	w.pending.Write(doc)
+	w.pending.WriteByte('\n')
+	w.docs++
+	return nil
+}
+
+// flush sends the pending documents as one bulk request. A bulk request
+// succeeds even if some documents in it fail, so those are counted and the
+// first one's reason reported.
+func (w *Writer) flush(ctx context.Context) error {
+	if w.docs == 0 {
+		return nil
+	}
+	sent := w.docs
+	resp, err := w.client.Bulk(ctx, opensearchapi.BulkReq{Body: bytes.NewReader(w.pending.Bytes())})
+	w.pending.Reset()
+	w.docs = 0
+	if err != nil {
+		return err
+	}
+	if !resp.Errors {
+		return nil
+	}
+	failed := 0
+	var reason string
+	for _, item := range resp.Items {
+		for _, result := range item {
+			if result.Error == nil {
+				continue
+			}
+			if failed == 0 {
+				reason = result.Error.Type + ": " + result.Error.Reason
+			}
+			failed++
+		}
+	}
+	return fmt.Errorf("%d of %d documents failed to index, first: %s", failed, sent, reason)
+}
+
+// Close sends any remaining documents
+func (w *Writer) Close() error {
+	ctx, cancel := storage.CloseContext(w.ctx)
+	defer cancel()
+	return w.flush(ctx)
+}
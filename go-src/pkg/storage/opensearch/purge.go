@@ -0,0 +1,67 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+)
+
+// Purger deletes old swaps from every swaps index
+type Purger struct {
+	client *opensearchapi.Client
+}
+
+// NewPurger connects to the cluster named by cfg
+func NewPurger(cfg Config) (*Purger, error) {
+	client, err := cfg.client()
+	if err != nil {
+		return nil, err
+	}
+	return &Purger{client: client}, nil
+}
+
+// Purge deletes by query. Indices left empty by it aren't removed, though
+// the ISM policy deletes them once they're a year old.
+func (p *Purger) Purge(ctx context.Context, before time.Time) (int64, error) {
+	body, err := beforeQuery(before)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := p.client.Document.DeleteByQuery(ctx, opensearchapi.DocumentDeleteByQueryReq{
+		Indices: []string{indexPattern},
+		Body:    bytes.NewReader(body),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(resp.Deleted), nil
+}
+
+func (p *Purger) CountBefore(ctx context.Context, before time.Time) (int64, error) {
+	body, err := beforeQuery(before)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := p.client.Indices.Count(ctx, &opensearchapi.IndicesCountReq{
+		Indices: []string{indexPattern},
+		Body:    bytes.NewReader(body),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(resp.Count), nil
+}
+
+// beforeQuery matches swaps with block_time before the cutoff
+func beforeQuery(before time.Time) ([]byte, error) {
+	return json.Marshal(map[string]any{"query": map[string]any{
+		"range": map[string]any{"block_time": map[string]string{"lt": before.UTC().Format(time.RFC3339Nano)}},
+	}})
+}
+
+func (p *Purger) Close() error {
+	return nil
+}
@@ -0,0 +1,59 @@
+package spanner
+
+import (
+	"context"
+	"time"
+
+	cloudspanner "cloud.google.com/go/spanner"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage"
+)
+
+// Purger deletes old swaps from a Spanner table
+type Purger struct {
+	client *cloudspanner.Client
+	table  string
+}
+
+// NewPurger connects to the database named by cfg
+func NewPurger(ctx context.Context, cfg Config) (*Purger, error) {
+	if cfg.Table == "" {
+		cfg.Table = storage.DefaultTable
+	}
+	client, err := cloudspanner.NewClient(ctx, cfg.database())
+	if err != nil {
+		return nil, err
+	}
+	return &Purger{client: client, table: cfg.Table}, nil
+}
+
+// Purge deletes with partitioned DML, which isn't bound by the mutation
+// limit of a single transaction. Spanner reports a lower bound on the rows
+// it deleted.
+func (p *Purger) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return p.client.PartitionedUpdate(ctx, p.statement("DELETE FROM ", before))
+}
+
+func (p *Purger) CountBefore(ctx context.Context, before time.Time) (int64, error) {
+	var n int64
+	iter := p.client.Single().Query(ctx, p.statement("SELECT COUNT(*) FROM ", before))
+	defer iter.Stop()
+	row, err := iter.Next()
+	if err != nil {
+		return 0, err
+	}
+	err = row.Columns(&n)
+	return n, err
+}
+
+func (p *Purger) statement(prefix string, before time.Time) cloudspanner.Statement {
+	return cloudspanner.Statement{
+		SQL:    prefix + p.table + " WHERE block_time < @before",
+		Params: map[string]any{"before": before.UTC()},
+	}
+}
+
+func (p *Purger) Close() error {
+	p.client.Close()
+	return nil
+}
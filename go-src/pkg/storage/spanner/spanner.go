@@ -0,0 +1,180 @@
+// Package spanner writes swaps to a Google Cloud Spanner table.
+package spanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	cloudspanner "cloud.google.com/go/spanner"
+	database "cloud.google.com/go/spanner/admin/database/apiv1"
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/storage"
+)
+
+// BatchSize is how many swaps are committed together, along with their raw
+// transactions with Config.StoreRaw
+const BatchSize = 100
+
+var rawColumns = []string{"signature", "transaction"}
+
+// Config names the table to write to
+type Config struct {
+	Project  string
+	Instance string
+	Database string
+	Table    string
+
+	// Also store each result's getTransaction response in storage.RawTable
+	StoreRaw bool
+}
+
+func (c Config) database() string {
+	return fmt.Sprintf("projects/%s/instances/%s/databases/%s", c.Project, c.Instance, c.Database)
+}
+
+// Writer upserts swaps into Spanner, keyed by signature and hop_index so
+// re-running over the same signatures is harmless
+type Writer struct {
+	ctx      context.Context
+	client   *cloudspanner.Client
+	table    string
+	columns  []string
+	storeRaw bool
+	pending  []*cloudspanner.Mutation
+
+	// Swaps in pending, not counting their raw transactions
+	swaps int
+}
+
+// NewWriter connects to the database and creates the table if it doesn't exist
+func NewWriter(ctx context.Context, cfg Config) (*Writer, error) {
+	if cfg.Table == "" {
+		cfg.Table = storage.DefaultTable
+	}
+	if err := createTable(ctx, cfg); err != nil {
+		return nil, err
+	}
+	client, err := cloudspanner.NewClient(ctx, cfg.database())
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{
+		ctx:      ctx,
+		client:   client,
+		table:    cfg.Table,
+		columns:  storage.ColumnNames(),
+		storeRaw: cfg.StoreRaw,
+	}, nil
+}
+
+// DDL returns the CREATE TABLE statement for the swaps table
+func DDL(table string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s (\n", table)
+	for _, c := range storage.Columns {
+		fmt.Fprintf(&b, "  %s %s", c.Name, columnType(c.Type))
+		if !c.Nullable {
+			b.WriteString(" NOT NULL")
+		}
+		b.WriteString(",\n")
+	}
+	b.WriteString(") PRIMARY KEY (signature, hop_index)")
+	return b.String()
+}
+
+// rawDDL creates storage.RawTable
+const rawDDL = "CREATE TABLE IF NOT EXISTS " + storage.RawTable + " (signature STRING(MAX) NOT NULL, transaction JSON NOT NULL) PRIMARY KEY (signature)"
+
+func columnType(t storage.ColumnType) string {
+	switch t {
+	case storage.Int:
+		return "INT64"
+	case storage.Uint64:
+		return "NUMERIC"
+	case storage.Float:
+		return "FLOAT64"
+	case storage.Bool:
+		return "BOOL"
+	case storage.Timestamp:
+		return "TIMESTAMP"
+	default:
+		return "STRING(MAX)"
+	}
+}
+
+func createTable(ctx context.Context, cfg Config) error {
+	admin, err := database.NewDatabaseAdminClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	statements := []string{DDL(cfg.Table)}
+	if cfg.StoreRaw {
+		statements = append(statements, rawDDL)
+	}
+	op, err := admin.UpdateDatabaseDdl(ctx, &databasepb.UpdateDatabaseDdlRequest{
+		Database:   cfg.database(),
+		Statements: statements,
+	})
+	if err != nil {
+		return fmt.Errorf("creating table %s: %w", cfg.Table, err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return fmt.Errorf("creating table %s: %w", cfg.Table, err)
+	}
+	return nil
+}
+
+func (w *Writer) Write(r *model.Result) error {
+	for _, swap := range r.AllSwaps() {
+		row := storage.Row(swap)
+		for i, v := range row {
+			switch v := v.(type) {
+			case uint64:
+				row[i] = new(big.Rat).SetUint64(v)
+			case nil:
+				row[i] = cloudspanner.NullString{}
+			}
+		}
+		w.pending = append(w.pending, cloudspanner.InsertOrUpdate(w.table, w.columns, row))
+		w.swaps++
+	}
+	if w.storeRaw {
+		raw, err := storage.RawRow(r)
+		if err != nil {
+			return err
+		}
+		if raw != nil {
+			// Spanner takes JSON column values as NullJSON, not strings
+			w.pending = append(w.pending, cloudspanner.InsertOrUpdate(storage.RawTable, rawColumns, []any{raw[0], cloudspanner.NullJSON{Value: json.RawMessage(raw[1].(string)), Valid: true}}))
+		}
+	}
+	if w.swaps >= BatchSize {
+		return w.flush(w.ctx)
+	}
+	return nil
+}
+
+func (w *Writer) flush(ctx context.Context) error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+	_, err := w.client.Apply(ctx, w.pending)
+	w.pending, w.swaps = w.pending[:0], 0
+	return err
+}
+
+// Close commits any remaining mutations and closes the client
+func (w *Writer) Close() error {
+	ctx, cancel := storage.CloseContext(w.ctx)
+	defer cancel()
+	err := w.flush(ctx)
+	w.client.Close()
+	return err
+}
@@ -0,0 +1,224 @@
+// Package storage defines the swaps table that the database output modes
+// write, so the same queries work against whichever backend a team runs.
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+)
+
+// DefaultTable is the table name backends write to unless configured otherwise
+const DefaultTable = "swaps"
+
+// RawTable holds the getTransaction response behind each stored swap, keyed
+// by signature, when raw storage is enabled, so swaps can be re-parsed
+// without fetching them again
+const RawTable = "raw_transactions"
+
+// ColumnType is a backend-neutral column type; each backend maps it to its
+// own DDL
+type ColumnType int
+
+const (
+	String ColumnType = iota
+	Int
+	// Raw token amounts and lamports, which can exceed the range of int64.
+	// Backends without an unsigned 64-bit integer store them as NUMERIC.
+	Uint64
+	Float
+	Bool
+	Timestamp
+)
+
+// Column is one column of the swaps table
+type Column struct {
+	Name     string
+	Type     ColumnType
+	Nullable bool
+}
+
+// Columns is the swaps table in insert order, mirroring model.SwapData.
+// signature and hop_index are the primary key; hop_index comes last since
+// it was added after the tables were first created.
+var Columns = []Column{
+	{Name: "signature", Type: String},
+	{Name: "slot", Type: Int},
+	{Name: "block_time", Type: Timestamp},
+	{Name: "dex", Type: String},
+	{Name: "dex_version", Type: String},
+	{Name: "dex_type", Type: String},
+	{Name: "program_id", Type: String, Nullable: true},
+	{Name: "signer", Type: String},
+	{Name: "token_in_mint", Type: String},
+	{Name: "token_in_amount", Type: Uint64},
+	{Name: "token_in_decimals", Type: Int},
+	{Name: "amount_in_ui", Type: Float},
+	{Name: "token_out_mint", Type: String},
+	{Name: "token_out_amount", Type: Uint64},
+	{Name: "token_out_decimals", Type: Int},
+	{Name: "amount_out_ui", Type: Float},
+	{Name: "fee_lamports", Type: Uint64},
+	{Name: "priority_fee_lamports", Type: Uint64},
+	{Name: "failed", Type: Bool},
+	{Name: "value_in_usd", Type: Float},
+	{Name: "value_out_usd", Type: Float},
+	{Name: "volume_usd", Type: Float},
+	{Name: "hop_index", Type: Int},
+}
+
+// Purger deletes old swaps from a backend's table, by block_time
+type Purger interface {
+	// Purge deletes rows with block_time before the cutoff and returns how
+	// many were deleted
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	// CountBefore returns how many rows Purge would delete
+	CountBefore(ctx context.Context, before time.Time) (int64, error)
+	Close() error
+}
+
+// Exporter reads stored swaps back out of a backend's table
+type Exporter interface {
+	// Export calls fn with each stored swap in block time order. where is
+	// a SQL condition on the table's columns, or "" for every row.
+	Export(ctx context.Context, where string, fn func(s *model.SwapData) error) error
+	Close() error
+}
+
+// CloseTimeout bounds the final flush a writer makes on Close
+const CloseTimeout = 30 * time.Second
+
+// CloseContext returns the context for a writer's final flush: ctx without
+// its cancellation, since Close usually runs after an interrupt or the
+// --deadline has cancelled ctx, and limited to CloseTimeout instead
+func CloseContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), CloseTimeout)
+}
+
+// ColumnNames returns the names of Columns, in order
+func ColumnNames() []string {
+	names := make([]string, len(Columns))
+	for i, c := range Columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// RawRow returns the signature and the getTransaction response as JSON,
+// the two columns of RawTable, or nil if r doesn't carry the response
+func RawRow(r *model.Result) ([]any, error) {
+	if r.RawTransaction == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(r.RawTransaction)
+	if err != nil {
+		return nil, err
+	}
+	return []any{r.SwapData.Signature.String(), string(data)}, nil
+}
+
+// Row returns the values of s in Columns order. Keys and the signature are
+// base58 strings, Int columns are int64, Uint64 columns are uint64, and a
+// NULL is nil.
+func Row(s *model.SwapData) []any {
+	var programID any
+	if s.ProgramID != nil {
+		programID = s.ProgramID.String()
+	}
+	return []any{
+		s.Signature.String(),
+		int64(s.Slot),
+		s.BlockTime,
+		s.Dex,
+		s.DexVersion,
+		s.DexType,
+		programID,
+		s.Signer.String(),
+		s.TokenInMint.String(),
+		s.TokenInAmount,
+		int64(s.TokenInDecimals),
+		s.AmountInUI,
+		s.TokenOutMint.String(),
+		s.TokenOutAmount,
+		int64(s.TokenOutDecimals),
+		s.AmountOutUI,
+		s.FeeLamports,
+		s.PriorityFeeLamports,
+		s.Failed,
+		s.ValueInUSD,
+		s.ValueOutUSD,
+		s.VolumeUSD,
+		int64(s.HopIndex),
+	}
+}
+
+// SwapFromRow is the inverse of Row, rebuilding a swap from values read in
+// Columns order. Fields that aren't stored are left zero.
+func SwapFromRow(values []any) (*model.SwapData, error) {
+	if len(values) != len(Columns) {
+		return nil, fmt.Errorf("got %d columns, want %d", len(values), len(Columns))
+	}
+	s := &model.SwapData{}
+	var err error
+	str := func(i int) string {
+		v, _ := values[i].(string)
+		return v
+	}
+	key := func(i int) solana.PublicKey {
+		k, kerr := solana.PublicKeyFromBase58(str(i))
+		if kerr != nil && err == nil {
+			err = fmt.Errorf("column %s: %w", Columns[i].Name, kerr)
+		}
+		return k
+	}
+	integer := func(i int) int64 {
+		switch v := values[i].(type) {
+		case int64:
+			return v
+		case int32:
+			return int64(v)
+		}
+		return 0
+	}
+	unsigned := func(i int) uint64 {
+		v, _ := values[i].(uint64)
+		return v
+	}
+	float := func(i int) float64 {
+		v, _ := values[i].(float64)
+		return v
+	}
+
+	if s.Signature, err = solana.SignatureFromBase58(str(0)); err != nil {
+		return nil, fmt.Errorf("column signature: %w", err)
+	}
+	s.Slot = uint64(integer(1))
+	if t, ok := values[2].(time.Time); ok {
+		s.BlockTime = t.UTC()
+	}
+	s.Dex, s.DexVersion, s.DexType = str(3), str(4), str(5)
+	if values[6] != nil {
+		programID := key(6)
+		s.ProgramID = &programID
+	}
+	s.Signer = key(7)
+	s.TokenInMint = key(8)
+	s.TokenInAmount = unsigned(9)
+	s.TokenInDecimals = uint8(integer(10))
+	s.AmountInUI = float(11)
+	s.TokenOutMint = key(12)
+	s.TokenOutAmount = unsigned(13)
+	s.TokenOutDecimals = uint8(integer(14))
+	s.AmountOutUI = float(15)
+	s.FeeLamports = unsigned(16)
+	s.PriorityFeeLamports = unsigned(17)
+	s.Failed, _ = values[18].(bool)
+	s.ValueInUSD, s.ValueOutUSD, s.VolumeUSD = float(19), float(20), float(21)
+	s.HopIndex = int(integer(22))
+	return s, err
+}
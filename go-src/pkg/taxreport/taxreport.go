@@ -0,0 +1,139 @@
+// Package taxreport exports a wallet's swaps as trades in the CSV layout
+// crypto tax software (Koinly, TaxBit) imports.
+package taxreport
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	solana "github.com/gagliardetto/solana-go"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/portfolio"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/tokenmetadata"
+)
+
+// Header is the CSV header row
+var Header = []string{
+	"date", "type", "base_currency", "base_amount", "quote_currency",
+	"quote_amount", "fee_currency", "fee_amount", "exchange",
+}
+
+// dateLayout is the UTC timestamp format the tax tools accept
+const dateLayout = "2006-01-02 15:04:05 UTC"
+
+// TaxRow is one trade line: base_amount of base_currency sold or bought
+// for quote_amount of the fiat currency
+type TaxRow struct {
+	Date          string
+	Type          string
+	BaseCurrency  string
+	BaseAmount    float64
+	QuoteCurrency string
+	QuoteAmount   float64
+	FeeCurrency   string
+	FeeAmount     float64
+	Exchange      string
+}
+
+// Record returns the row's CSV fields in Header order
+func (r TaxRow) Record() []string {
+	fee := ""
+	if r.FeeCurrency != "" {
+		fee = formatAmount(r.FeeAmount)
+	}
+	return []string{
+		r.Date, r.Type, r.BaseCurrency, formatAmount(r.BaseAmount), r.QuoteCurrency,
+		formatAmount(r.QuoteAmount), r.FeeCurrency, fee, r.Exchange,
+	}
+}
+
+// Generate returns two rows for each of the ledger's swaps in year (UTC): a
+// sell of the token sent, then a buy of the token received, both at the
+// swap's fiat value. The network fee is charged on the sell row. Swaps are
+// only priced in USD, so that is the only fiatCurrency supported.
+func Generate(ledger *portfolio.Ledger, year int, fiatCurrency string) ([]TaxRow, error) {
+	if err := ValidateCurrency(fiatCurrency); err != nil {
+		return nil, err
+	}
+	fiat := strings.ToUpper(fiatCurrency)
+
+	var rows []TaxRow
+	for _, s := range ledger.Swaps() {
+		t := s.BlockTime.UTC()
+		if t.Year() != year {
+			continue
+		}
+		date := t.Format(dateLayout)
+		value := portfolio.SwapValue(s)
+		exchange := exchangeName(s)
+		rows = append(rows,
+			TaxRow{
+				Date:          date,
+				Type:          "sell",
+				BaseCurrency:  currency(s.TokenInMint),
+				BaseAmount:    s.AmountInUI,
+				QuoteCurrency: fiat,
+				QuoteAmount:   value,
+				FeeCurrency:   "SOL",
+				FeeAmount:     s.FeeSOL(),
+				Exchange:      exchange,
+			},
+			TaxRow{
+				Date:          date,
+				Type:          "buy",
+				BaseCurrency:  currency(s.TokenOutMint),
+				BaseAmount:    s.AmountOutUI,
+				QuoteCurrency: fiat,
+				QuoteAmount:   value,
+				Exchange:      exchange,
+			},
+		)
+	}
+	return rows, nil
+}
+
+// ValidateCurrency reports whether Generate supports fiatCurrency
+func ValidateCurrency(fiatCurrency string) error {
+	if !strings.EqualFold(fiatCurrency, "USD") {
+		return fmt.Errorf("unsupported currency %q: swaps are only priced in USD", fiatCurrency)
+	}
+	return nil
+}
+
+// WriteCSV writes Header followed by rows
+func WriteCSV(w io.Writer, rows []TaxRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Header); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := cw.Write(r.Record()); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// currency names a token by its symbol, or its mint address if unknown
+func currency(mint solana.PublicKey) string {
+	if symbol := tokenmetadata.Symbol(mint); symbol != "" {
+		return symbol
+	}
+	return mint.String()
+}
+
+func exchangeName(s *model.SwapData) string {
+	if s.Dex == "" {
+		return "Solana"
+	}
+	return strings.TrimSpace(s.Dex + " " + s.DexVersion)
+}
+
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,204 @@
+// Package testutil builds fake but structurally valid chain data for
+// exercising the parser without an RPC endpoint.
+package testutil
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+
+	"github.com/MaybeItsAdam/solana-multitool/pkg/model"
+	"github.com/MaybeItsAdam/solana-multitool/pkg/registry"
+)
+
+// splTransfer is the SPL Token program's Transfer instruction index
+const splTransfer = 3
+
+// computeBudgetSetPrice is the Compute Budget program's
+// SetComputeUnitPrice instruction index
+const computeBudgetSetPrice = 3
+
+// syntheticDecimals is the decimals of the token a synthetic swap buys
+const syntheticDecimals = 6
+
+// GenerateSyntheticTransaction returns a getTransaction result for a swap
+// of wSOL for a random token on the first registry program whose name
+// starts with dex, as --program matches, or nil if none does. It has the
+// shape of a real swap: a priority fee instruction and the DEX instruction,
+// whose inner instructions are the two token transfers between the
+// signer's token accounts and the pool's vaults, with the balances and
+// logs to match. Keys, signature and amounts are random, and nothing is
+// signed, so it only stands up to parsing.
+func GenerateSyntheticTransaction(dex string) *rpc.GetTransactionResult {
+	programs := registry.Default().Find(dex)
+	if len(programs) == 0 {
+		return nil
+	}
+	program := programs[0]
+
+	signer, outMint := randomKey(), randomKey()
+	userIn, userOut := randomKey(), randomKey()
+	vaultIn, vaultOut := randomKey(), randomKey()
+	pool, authority := randomKey(), randomKey()
+	amountIn := rand.Uint64N(100*solana.LAMPORTS_PER_SOL) + 1
+	amountOut := rand.Uint64N(1_000_000*1_000_000) + 1
+
+	swapData := binary.LittleEndian.AppendUint64([]byte{9}, amountIn)
+	swapData = binary.LittleEndian.AppendUint64(swapData, 0)
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			solana.NewInstruction(solana.ComputeBudget,
+				solana.AccountMetaSlice{},
+				binary.LittleEndian.AppendUint64([]byte{computeBudgetSetPrice}, rand.Uint64N(100_000)),
+			),
+			solana.NewInstruction(program,
+				solana.AccountMetaSlice{
+					solana.Meta(solana.TokenProgramID),
+					solana.Meta(pool).WRITE(),
+					solana.Meta(authority),
+					solana.Meta(vaultIn).WRITE(),
+					solana.Meta(vaultOut).WRITE(),
+					solana.Meta(userIn).WRITE(),
+					solana.Meta(userOut).WRITE(),
+					solana.Meta(signer).WRITE().SIGNER(),
+					solana.Meta(solana.SolMint),
+					solana.Meta(outMint),
+				},
+				swapData,
+			),
+		},
+		solana.Hash(randomKey()),
+		solana.TransactionPayer(signer),
+	)
+	if err != nil {
+		// Only a malformed instruction list fails, and this one is fixed
+		panic(fmt.Sprintf("building synthetic transaction: %s", err))
+	}
+	var sig solana.Signature
+	fillRandom(sig[:])
+	tx.Signatures = []solana.Signature{sig}
+
+	keys := tx.Message.AccountKeys
+	index := func(key solana.PublicKey) uint16 {
+		for i, k := range keys {
+			if k.Equals(key) {
+				return uint16(i)
+			}
+		}
+		panic(fmt.Sprintf("synthetic transaction is missing account %s", key))
+	}
+	transfer := func(from, to, owner solana.PublicKey, amount uint64) solana.CompiledInstruction {
+		return solana.CompiledInstruction{
+			ProgramIDIndex: index(solana.TokenProgramID),
+			Accounts:       []uint16{index(from), index(to), index(owner)},
+			Data:           binary.LittleEndian.AppendUint64([]byte{splTransfer}, amount),
+		}
+	}
+
+	fee := model.BaseFeeLamportsPerSignature + rand.Uint64N(100_000)
+	balances := make([]uint64, len(keys))
+	for i := range balances {
+		balances[i] = solana.LAMPORTS_PER_SOL
+	}
+	postBalances := append([]uint64(nil), balances...)
+	postBalances[index(signer)] -= fee
+
+	vaultInBefore := rand.Uint64N(10_000*solana.LAMPORTS_PER_SOL) + amountIn
+	vaultOutBefore := rand.Uint64N(1_000_000_000*1_000_000) + amountOut
+	userInBefore := amountIn + rand.Uint64N(solana.LAMPORTS_PER_SOL)
+	tokenBalance := func(account, owner, mint solana.PublicKey, decimals uint8, amount uint64) rpc.TokenBalance {
+		ui := model.UIAmount(amount, decimals)
+		return rpc.TokenBalance{
+			AccountIndex: index(account),
+			Owner:        &owner,
+			ProgramId:    &solana.TokenProgramID,
+			Mint:         mint,
+			UiTokenAmount: &rpc.UiTokenAmount{
+				Amount:         strconv.FormatUint(amount, 10),
+				Decimals:       decimals,
+				UiAmount:       &ui,
+				UiAmountString: strconv.FormatFloat(ui, 'f', -1, 64),
+			},
+		}
+	}
+	units := 50_000 + rand.Uint64N(150_000)
+	blockTime := solana.UnixTimeSeconds(time.Now().Unix())
+
+	encoded, err := tx.MarshalBinary()
+	if err != nil {
+		panic(fmt.Sprintf("encoding synthetic transaction: %s", err))
+	}
+	envelope, err := json.Marshal([]string{base64.StdEncoding.EncodeToString(encoded), "base64"})
+	if err != nil {
+		panic(err)
+	}
+	result := &rpc.GetTransactionResult{
+		Slot:        300_000_000 + rand.Uint64N(10_000_000),
+		BlockTime:   &blockTime,
+		Transaction: &rpc.TransactionResultEnvelope{},
+		Meta: &rpc.TransactionMeta{
+			Fee:          fee,
+			PreBalances:  balances,
+			PostBalances: postBalances,
+			InnerInstructions: []rpc.InnerInstruction{{
+				Index: 1,
+				Instructions: []solana.CompiledInstruction{
+					transfer(userIn, vaultIn, signer, amountIn),
+					transfer(vaultOut, userOut, authority, amountOut),
+				},
+			}},
+			PreTokenBalances: []rpc.TokenBalance{
+				tokenBalance(userIn, signer, solana.SolMint, 9, userInBefore),
+				tokenBalance(userOut, signer, outMint, syntheticDecimals, 0),
+				tokenBalance(vaultIn, authority, solana.SolMint, 9, vaultInBefore),
+				tokenBalance(vaultOut, authority, outMint, syntheticDecimals, vaultOutBefore),
+			},
+			PostTokenBalances: []rpc.TokenBalance{
+				tokenBalance(userIn, signer, solana.SolMint, 9, userInBefore-amountIn),
+				tokenBalance(userOut, signer, outMint, syntheticDecimals, amountOut),
+				tokenBalance(vaultIn, authority, solana.SolMint, 9, vaultInBefore+amountIn),
+				tokenBalance(vaultOut, authority, outMint, syntheticDecimals, vaultOutBefore-amountOut),
+			},
+			LogMessages: []string{
+				"Program " + solana.ComputeBudget.String() + " invoke [1]",
+				"Program " + solana.ComputeBudget.String() + " success",
+				"Program " + program.String() + " invoke [1]",
+				"Program log: Instruction: Swap",
+				"Program " + solana.TokenProgramID.String() + " invoke [2]",
+				"Program log: Instruction: Transfer",
+				"Program " + solana.TokenProgramID.String() + " success",
+				"Program " + solana.TokenProgramID.String() + " invoke [2]",
+				"Program log: Instruction: Transfer",
+				"Program " + solana.TokenProgramID.String() + " success",
+				fmt.Sprintf("Program %s consumed %d of 200000 compute units", program, units),
+				"Program " + program.String() + " success",
+			},
+			ComputeUnitsConsumed: &units,
+		},
+	}
+	if err := result.Transaction.UnmarshalJSON(envelope); err != nil {
+		panic(fmt.Sprintf("wrapping synthetic transaction: %s", err))
+	}
+	return result
+}
+
+func randomKey() solana.PublicKey {
+	var key solana.PublicKey
+	fillRandom(key[:])
+	return key
+}
+
+func fillRandom(b []byte) {
+	for i := 0; i < len(b); i += 8 {
+		var word [8]byte
+		binary.LittleEndian.PutUint64(word[:], rand.Uint64())
+		copy(b[i:], word[:])
+	}
+}
@@ -0,0 +1,99 @@
+package tokenmetadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// mintHistoryPageSize is the largest page getSignaturesForAddress will return
+const mintHistoryPageSize = 1000
+
+// maxMintHistoryPages bounds how far back FetchMintAge pages. A new token
+// reaches its first transaction in a page or two; an established one like
+// USDC has millions of signatures and isn't worth paging through.
+const maxMintHistoryPages = 20
+
+// ErrEstablishedMint is returned for mints too old for FetchMintAge to find
+// their creation: the built-in list's tokens, and mints with more than
+// maxMintHistoryPages pages of history
+var ErrEstablishedMint = errors.New("mint is too established to date")
+
+// FetchMintAge returns when a mint was created: the block time of the
+// oldest transaction in its signature history, normally its
+// InitializeMint. The signature listing carries block times, so the
+// transaction itself isn't fetched. Nodes that prune history date a mint
+// by the oldest transaction they still have.
+func FetchMintAge(ctx context.Context, rpcClient *rpc.Client, mint solana.PublicKey) (time.Time, error) {
+	if _, ok := known[mint]; ok {
+		return time.Time{}, ErrEstablishedMint
+	}
+	if cached, ok := lookup(mint, entry.hasAge); ok {
+		if cached.Established {
+			return time.Time{}, ErrEstablishedMint
+		}
+		return cached.Created, cached.ageErr
+	}
+
+	created, err := oldestBlockTime(ctx, rpcClient, mint)
+	// Don't remember a lookup cut short by the run ending
+	if ctx.Err() != nil {
+		return time.Time{}, err
+	}
+	// Failures are cached too, so a busy mint is only paged once
+	update(mint, func(e *entry) {
+		switch {
+		case errors.Is(err, ErrEstablishedMint):
+			e.Established = true
+		case err != nil:
+			e.ageErr = err
+		default:
+			e.Created = created
+		}
+	})
+	return created, err
+}
+
+// oldestBlockTime pages back through an address's signatures to the first
+func oldestBlockTime(ctx context.Context, rpcClient *rpc.Client, address solana.PublicKey) (time.Time, error) {
+	limit := mintHistoryPageSize
+	opts := &rpc.GetSignaturesForAddressOpts{Limit: &limit, Commitment: rpc.CommitmentConfirmed}
+	var oldest *rpc.TransactionSignature
+	for range maxMintHistoryPages {
+		page, err := rpcClient.GetSignaturesForAddressWithOpts(ctx, address, opts)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("fetching signatures for mint %s: %w", address, err)
+		}
+		if len(page) > 0 {
+			oldest = page[len(page)-1]
+			opts.Before = oldest.Signature
+		}
+		if len(page) < limit {
+			if oldest == nil {
+				return time.Time{}, fmt.Errorf("mint %s has no transactions", address)
+			}
+			return signatureTime(ctx, rpcClient, oldest)
+		}
+	}
+	return time.Time{}, ErrEstablishedMint
+}
+
+// signatureTime is when a listed signature landed, from its block if the
+// listing didn't say
+func signatureTime(ctx context.Context, rpcClient *rpc.Client, sig *rpc.TransactionSignature) (time.Time, error) {
+	if sig.BlockTime != nil {
+		return sig.BlockTime.Time().UTC(), nil
+	}
+	bt, err := rpcClient.GetBlockTime(ctx, sig.Slot)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("getting block time for slot %d: %w", sig.Slot, err)
+	}
+	if bt == nil {
+		return time.Time{}, fmt.Errorf("block time not available for slot %d", sig.Slot)
+	}
+	return bt.Time().UTC(), nil
+}
@@ -0,0 +1,163 @@
+package tokenmetadata
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	solana "github.com/gagliardetto/solana-go"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// DefaultCacheSize is how many mints the metadata cache holds unless
+// SetCacheSize changes it
+const DefaultCacheSize = 50000
+
+// entry is what's cached about a mint. Neither decimals nor creation time
+// change once a mint exists, so entries never go stale, only get evicted.
+type entry struct {
+	Decimals    uint8
+	HasDecimals bool
+
+	// Created is when FetchMintAge dated the mint, and Established is set
+	// if it returned ErrEstablishedMint instead
+	Created     time.Time
+	Established bool
+
+	// ageErr is any other FetchMintAge failure, so a mint that can't be
+	// dated is only tried once a run. It isn't saved.
+	ageErr error
+}
+
+func (e entry) hasAge() bool {
+	return !e.Created.IsZero() || e.Established || e.ageErr != nil
+}
+
+// saved reports whether the entry holds anything worth writing to disk
+func (e entry) saved() bool {
+	return e.HasDecimals || !e.Created.IsZero() || e.Established
+}
+
+// metadata caches what FetchDecimals and FetchMintAge read over RPC,
+// keeping the most recently used mints once it's full
+var metadata = struct {
+	mu     sync.Mutex
+	lru    *lru.Cache[solana.PublicKey, entry]
+	hits   uint64
+	misses uint64
+}{lru: newLRU(DefaultCacheSize)}
+
+func newLRU(size int) *lru.Cache[solana.PublicKey, entry] {
+	c, err := lru.New[solana.PublicKey, entry](size)
+	if err != nil {
+		// Only a size below 1 fails, which SetCacheSize rules out
+		panic(fmt.Sprintf("tokenmetadata: %s", err))
+	}
+	return c
+}
+
+// SetCacheSize changes how many mints the metadata cache holds, evicting
+// the least recently used if it shrinks
+func SetCacheSize(size int) error {
+	if size < 1 {
+		return fmt.Errorf("cache size must be at least 1, got %d", size)
+	}
+	metadata.mu.Lock()
+	defer metadata.mu.Unlock()
+	metadata.lru.Resize(size)
+	return nil
+}
+
+// CacheStats returns how many lookups of mints outside the built-in list
+// the metadata cache answered, and how many went to RPC
+func CacheStats() (hits, misses uint64) {
+	metadata.mu.Lock()
+	defer metadata.mu.Unlock()
+	return metadata.hits, metadata.misses
+}
+
+// lookup returns a mint's entry if has says it holds the wanted field,
+// counting the hit or miss
+func lookup(mint solana.PublicKey, has func(entry) bool) (entry, bool) {
+	metadata.mu.Lock()
+	defer metadata.mu.Unlock()
+	e, ok := metadata.lru.Get(mint)
+	if ok && has(e) {
+		metadata.hits++
+		return e, true
+	}
+	metadata.misses++
+	return entry{}, false
+}
+
+// update applies set to a mint's entry, creating it if needed
+func update(mint solana.PublicKey, set func(*entry)) {
+	metadata.mu.Lock()
+	defer metadata.mu.Unlock()
+	e, _ := metadata.lru.Peek(mint)
+	set(&e)
+	metadata.lru.Add(mint, e)
+}
+
+// savedEntry is an entry as written by SaveCache
+type savedEntry struct {
+	Mint  solana.PublicKey
+	Entry entry
+}
+
+// LoadCache fills the metadata cache from a file written by SaveCache. A
+// missing file is an empty cache, as on the first run.
+func LoadCache(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var entries []savedEntry
+	if err := gob.NewDecoder(f).Decode(&entries); err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	metadata.mu.Lock()
+	defer metadata.mu.Unlock()
+	// Entries are saved least recently used first, so adding them in order
+	// restores which are evicted first
+	for _, e := range entries {
+		metadata.lru.Add(e.Mint, e.Entry)
+	}
+	return nil
+}
+
+// SaveCache writes the metadata cache to path with encoding/gob, replacing
+// the file only once the new one is complete
+func SaveCache(path string) error {
+	metadata.mu.Lock()
+	var entries []savedEntry
+	for _, mint := range metadata.lru.Keys() {
+		if e, ok := metadata.lru.Peek(mint); ok && e.saved() {
+			entries = append(entries, savedEntry{Mint: mint, Entry: e})
+		}
+	}
+	metadata.mu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := gob.NewEncoder(tmp).Encode(entries); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
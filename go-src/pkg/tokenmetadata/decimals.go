@@ -0,0 +1,55 @@
+package tokenmetadata
+
+import (
+	"context"
+	"fmt"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// MintDecimalsOffset is where the decimals byte sits in an SPL Token (and
+// Token-2022) mint account, after the mint authority option and the supply
+const MintDecimalsOffset = 44
+
+// FetchDecimals returns the mint's decimals, from the built-in list, the
+// metadata cache or else the mint account
+func FetchDecimals(ctx context.Context, rpcClient *rpc.Client, mint solana.PublicKey) (uint8, error) {
+	if token, ok := known[mint]; ok {
+		return token.Decimals, nil
+	}
+	if cached, ok := lookup(mint, func(e entry) bool { return e.HasDecimals }); ok {
+		return cached.Decimals, nil
+	}
+
+	account, err := rpcClient.GetAccountInfo(ctx, mint)
+	if err != nil {
+		return 0, fmt.Errorf("fetching mint %s: %w", mint, err)
+	}
+	if account.Value == nil || account.Value.Data == nil {
+		return 0, fmt.Errorf("mint %s is empty", mint)
+	}
+	data := account.Value.Data.GetBinary()
+	if len(data) <= MintDecimalsOffset {
+		return 0, fmt.Errorf("mint %s: account is %d bytes, too short for a mint", mint, len(data))
+	}
+	decimals := data[MintDecimalsOffset]
+	update(mint, func(e *entry) { e.Decimals, e.HasDecimals = decimals, true })
+	return decimals, nil
+}
+
+// BalanceDecimals returns the mint's decimals as recorded in a transaction's
+// token balances, for when the mint account can't be fetched
+func BalanceDecimals(meta *rpc.TransactionMeta, mint solana.PublicKey) (uint8, bool) {
+	if meta == nil {
+		return 0, false
+	}
+	for _, balances := range [][]rpc.TokenBalance{meta.PreTokenBalances, meta.PostTokenBalances} {
+		for _, b := range balances {
+			if b.Mint.Equals(mint) && b.UiTokenAmount != nil {
+				return b.UiTokenAmount.Decimals, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,63 @@
+// Package tokenmetadata looks up display details for SPL token mints.
+package tokenmetadata
+
+import (
+	"strings"
+
+	solana "github.com/gagliardetto/solana-go"
+)
+
+// Token is the metadata we know about a mint
+type Token struct {
+	Symbol     string
+	Decimals   uint8
+	Stablecoin bool
+}
+
+var (
+	USDC = solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	USDT = solana.MustPublicKeyFromBase58("Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB")
+)
+
+// known is a small built-in list of widely traded mints, enough to label
+// reports without fetching on-chain metadata
+var known = map[solana.PublicKey]Token{
+	solana.SolMint: {Symbol: "SOL", Decimals: 9},
+	USDC:           {Symbol: "USDC", Decimals: 6, Stablecoin: true},
+	USDT:           {Symbol: "USDT", Decimals: 6, Stablecoin: true},
+	solana.MustPublicKeyFromBase58("mSoLzYCxHdYgdzU16g5QSh3i5K3z3KZK7ytfqcJm7So"):  {Symbol: "mSOL", Decimals: 9},
+	solana.MustPublicKeyFromBase58("J1toso1uCk3RLmjorhTtrVwY9HJ7X8V9yYac6Y7kGCPn"): {Symbol: "JitoSOL", Decimals: 9},
+	solana.MustPublicKeyFromBase58("bSo13r4TkiE4KumL71LsHTPpL2euBYLFx6h9HP3piy1"):  {Symbol: "bSOL", Decimals: 9},
+	solana.MustPublicKeyFromBase58("JUPyiwrYJFskUPiHa7hkeR8VUtAeFoSYbKedZNsDvCN"):  {Symbol: "JUP", Decimals: 6},
+	solana.MustPublicKeyFromBase58("jtojtomepa8beP8AuQc6eXt5FriJwfFMwQx2v2f9mCL"):  {Symbol: "JTO", Decimals: 9},
+	solana.MustPublicKeyFromBase58("4k3Dyjzvzp8eMZWUXbBCjEvwSkkk59S5iCNLY3QrkX6R"): {Symbol: "RAY", Decimals: 6},
+	solana.MustPublicKeyFromBase58("DezXAZ8z7PnrnRJjz3wXBoRgixCa6xjnB7YaB1pPB263"): {Symbol: "BONK", Decimals: 5},
+	solana.MustPublicKeyFromBase58("EKpQGSJtjMFqKZ9KQanSqYXRcF8fBopzLHYxdM65zcjm"): {Symbol: "WIF", Decimals: 6},
+	solana.MustPublicKeyFromBase58("HZ1JovNiVvGrGNiiYvEozEVgZ58xaU3RKwX8eACQBCt3"): {Symbol: "PYTH", Decimals: 6},
+}
+
+// Lookup returns the built-in metadata for a mint
+func Lookup(mint solana.PublicKey) (Token, bool) {
+	token, ok := known[mint]
+	return token, ok
+}
+
+// Symbol returns the mint's symbol, or "" if it isn't a known token
+func Symbol(mint solana.PublicKey) string {
+	return known[mint].Symbol
+}
+
+// IsStablecoin reports whether the mint is a USD stablecoin
+func IsStablecoin(mint solana.PublicKey) bool {
+	return known[mint].Stablecoin
+}
+
+// FromSymbol returns the known mint with the symbol, ignoring case
+func FromSymbol(symbol string) (solana.PublicKey, bool) {
+	for mint, token := range known {
+		if strings.EqualFold(token.Symbol, symbol) {
+			return mint, true
+		}
+	}
+	return solana.PublicKey{}, false
+}
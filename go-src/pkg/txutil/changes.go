@@ -0,0 +1,166 @@
+package txutil
+
+import (
+	"math"
+	"strconv"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// AccountChange is how a writable account's balances moved in a transaction
+type AccountChange struct {
+	Pubkey       solana.PublicKey `json:"pubkey"`
+	PreLamports  uint64           `json:"pre_lamports"`
+	PostLamports uint64           `json:"post_lamports"`
+	LamportDelta int64            `json:"lamport_delta"`
+	// Set for token accounts
+	TokenChanges []TokenBalanceChange `json:"token_changes,omitempty"`
+}
+
+// TokenBalanceChange is a token account's balance before and after, in raw
+// units, and the difference in whole tokens
+type TokenBalanceChange struct {
+	Mint       solana.PublicKey  `json:"mint"`
+	Owner      *solana.PublicKey `json:"owner,omitempty"`
+	Decimals   uint8             `json:"decimals"`
+	PreAmount  uint64            `json:"pre_amount"`
+	PostAmount uint64            `json:"post_amount"`
+	UIDelta    float64           `json:"ui_delta"`
+}
+
+// AccountChanges returns every writable account's SOL and token balance
+// before and after the transaction, in account key order. Accounts a
+// transaction can write but didn't change are included with a zero delta.
+func AccountChanges(tx *rpc.GetTransactionResult) ([]AccountChange, error) {
+	accounts, err := Accounts(tx)
+	if err != nil {
+		return nil, err
+	}
+	meta := tx.Meta
+	if meta == nil {
+		meta = &rpc.TransactionMeta{}
+	}
+
+	tokens := make(map[int]*TokenBalanceChange)
+	token := func(b rpc.TokenBalance) *TokenBalanceChange {
+		c, ok := tokens[int(b.AccountIndex)]
+		if !ok {
+			c = &TokenBalanceChange{Mint: b.Mint, Owner: b.Owner}
+			if b.UiTokenAmount != nil {
+				c.Decimals = b.UiTokenAmount.Decimals
+			}
+			tokens[int(b.AccountIndex)] = c
+		}
+		return c
+	}
+	amount := func(b rpc.TokenBalance) uint64 {
+		if b.UiTokenAmount == nil {
+			return 0
+		}
+		v, _ := strconv.ParseUint(b.UiTokenAmount.Amount, 10, 64)
+		return v
+	}
+	for _, b := range meta.PreTokenBalances {
+		token(b).PreAmount = amount(b)
+	}
+	for _, b := range meta.PostTokenBalances {
+		token(b).PostAmount = amount(b)
+	}
+
+	var changes []AccountChange
+	for _, a := range accounts {
+		if !a.IsWritable {
+			continue
+		}
+		c := AccountChange{Pubkey: a.PubKey}
+		if a.Index < len(meta.PreBalances) {
+			c.PreLamports = meta.PreBalances[a.Index]
+		}
+		if a.Index < len(meta.PostBalances) {
+			c.PostLamports = meta.PostBalances[a.Index]
+		}
+		c.LamportDelta = int64(c.PostLamports) - int64(c.PreLamports)
+		if t, ok := tokens[a.Index]; ok {
+			t.UIDelta = (float64(t.PostAmount) - float64(t.PreAmount)) / math.Pow10(int(t.Decimals))
+			c.TokenChanges = []TokenBalanceChange{*t}
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// KeyIndex returns the position of account in keys, or -1
+func KeyIndex(keys solana.PublicKeySlice, account solana.PublicKey) int {
+	for i, key := range keys {
+		if key.Equals(account) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Lamports returns an account's balance before or after the transaction
+func Lamports(tx *rpc.GetTransactionResult, keys solana.PublicKeySlice, account solana.PublicKey, pre bool) uint64 {
+	if tx.Meta == nil {
+		return 0
+	}
+	balances := tx.Meta.PostBalances
+	if pre {
+		balances = tx.Meta.PreBalances
+	}
+	i := KeyIndex(keys, account)
+	if i < 0 || i >= len(balances) {
+		return 0
+	}
+	return balances[i]
+}
+
+// LamportChange returns how much an account's balance rose, not counting
+// the fee if it paid it
+func LamportChange(tx *rpc.GetTransactionResult, keys solana.PublicKeySlice, account solana.PublicKey) int64 {
+	change := int64(Lamports(tx, keys, account, false)) - int64(Lamports(tx, keys, account, true))
+	if tx.Meta != nil && len(keys) > 0 && keys[0].Equals(account) {
+		change += int64(tx.Meta.Fee)
+	}
+	return change
+}
+
+// TokenDelta is the net change in a token account's raw balance
+type TokenDelta struct {
+	Mint     solana.PublicKey
+	Decimals uint8
+	Change   int64
+}
+
+// TokenChange returns how much a token account's raw balance rose, and
+// false if it has neither a pre nor a post balance. An account opened by
+// the transaction starts from zero.
+func TokenChange(tx *rpc.GetTransactionResult, keys solana.PublicKeySlice, account solana.PublicKey) (TokenDelta, bool) {
+	var d TokenDelta
+	if tx.Meta == nil {
+		return d, false
+	}
+	i := KeyIndex(keys, account)
+	if i < 0 {
+		return d, false
+	}
+	found := false
+	add := func(balances []rpc.TokenBalance, sign int64) {
+		for _, b := range balances {
+			if int(b.AccountIndex) != i || b.UiTokenAmount == nil {
+				continue
+			}
+			amount, err := strconv.ParseInt(b.UiTokenAmount.Amount, 10, 64)
+			if err != nil {
+				continue
+			}
+			d.Mint, d.Decimals = b.Mint, b.UiTokenAmount.Decimals
+			d.Change += sign * amount
+			found = true
+		}
+	}
+	add(tx.Meta.PreTokenBalances, -1)
+	add(tx.Meta.PostTokenBalances, 1)
+	return d, found
+}
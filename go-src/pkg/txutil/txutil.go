@@ -0,0 +1,237 @@
+// Package txutil holds small helpers for working with transactions fetched over RPC.
+package txutil
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// AnchorDiscriminator is the 8-byte prefix Anchor gives an instruction's
+// data
+func AnchorDiscriminator(name string) []byte {
+	sum := sha256.Sum256([]byte("global:" + name))
+	return sum[:8]
+}
+
+// AnchorEventTag starts the data of the self-invocation an Anchor program
+// emits an event through
+var AnchorEventTag = []byte{0xe4, 0x45, 0xa5, 0x2e, 0x51, 0xcb, 0x9a, 0x1d}
+
+// Decode returns the decoded transaction from an RPC result
+func Decode(tx *rpc.GetTransactionResult) (*solana.Transaction, error) {
+	if tx == nil || tx.Transaction == nil {
+		return nil, errors.New("transaction result is empty")
+	}
+	decoded, err := tx.Transaction.GetTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("decoding transaction: %w", err)
+	}
+	if decoded == nil {
+		return nil, errors.New("transaction result is empty")
+	}
+	return decoded, nil
+}
+
+// FromBlock wraps a transaction from a getBlock response as a
+// getTransaction result, so it can go through the same parsing without
+// being fetched again. The block's transactions omit their slot and time,
+// so the block's are passed in.
+func FromBlock(slot uint64, blockTime *solana.UnixTimeSeconds, tx rpc.TransactionWithMeta) (*rpc.GetTransactionResult, error) {
+	if tx.Transaction == nil {
+		return nil, errors.New("block transaction is empty")
+	}
+	// The getTransaction envelope can only be built by decoding the same JSON
+	raw, err := json.Marshal(tx.Transaction)
+	if err != nil {
+		return nil, fmt.Errorf("encoding block transaction: %w", err)
+	}
+	envelope := new(rpc.TransactionResultEnvelope)
+	if err := json.Unmarshal(raw, envelope); err != nil {
+		return nil, fmt.Errorf("decoding block transaction: %w", err)
+	}
+	return &rpc.GetTransactionResult{
+		Slot:        slot,
+		BlockTime:   blockTime,
+		Transaction: envelope,
+		Meta:        tx.Meta,
+		Version:     tx.Version,
+	}, nil
+}
+
+// IsVote reports whether every instruction in a transaction is to the vote
+// program, as in the vote transactions validators send each slot and which
+// make up most of a block
+func IsVote(tx *solana.Transaction) bool {
+	return InvokesOnly(tx, solana.VoteProgramID)
+}
+
+// InvokesOnly reports whether every top-level instruction in a transaction
+// is to one of programs. Native programs such as System and ComputeBudget
+// make no cross-program calls, so a transaction made only of those invokes
+// nothing else.
+func InvokesOnly(tx *solana.Transaction, programs ...solana.PublicKey) bool {
+	if len(tx.Message.Instructions) == 0 {
+		return false
+	}
+	for _, ix := range tx.Message.Instructions {
+		program, err := tx.Message.Program(ix.ProgramIDIndex)
+		if err != nil || !slices.ContainsFunc(programs, program.Equals) {
+			return false
+		}
+	}
+	return true
+}
+
+// AccountKeys returns every account key the transaction can index into:
+// the static message keys followed by the writable and then readonly keys
+// loaded from address lookup tables, which is the order instructions use.
+func AccountKeys(tx *rpc.GetTransactionResult) (solana.PublicKeySlice, error) {
+	decoded, err := Decode(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(solana.PublicKeySlice, 0, len(decoded.Message.AccountKeys))
+	keys = append(keys, decoded.Message.AccountKeys...)
+	if tx.Meta != nil {
+		keys = append(keys, tx.Meta.LoadedAddresses.Writable...)
+		keys = append(keys, tx.Meta.LoadedAddresses.ReadOnly...)
+	}
+	return keys, nil
+}
+
+// Account is one of a transaction's account keys with its role
+type Account struct {
+	Index      int              `json:"index"`
+	PubKey     solana.PublicKey `json:"pubkey"`
+	IsSigner   bool             `json:"isSigner"`
+	IsWritable bool             `json:"isWritable"`
+	// Whether any instruction, top-level or inner, is for this account's
+	// program
+	IsProgram bool `json:"isProgram"`
+}
+
+// Accounts returns AccountKeys with each key's role, as the indexes
+// instructions refer to them by. Signer and writable flags for the static
+// keys come from the message header; keys loaded from lookup tables are
+// never signers.
+func Accounts(tx *rpc.GetTransactionResult) ([]Account, error) {
+	decoded, err := Decode(tx)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := AccountKeys(tx)
+	if err != nil {
+		return nil, err
+	}
+	instructions, err := Instructions(tx)
+	if err != nil {
+		return nil, err
+	}
+	programs := make(map[solana.PublicKey]bool)
+	for _, ix := range instructions {
+		programs[ix.ProgramID] = true
+	}
+
+	header := decoded.Message.Header
+	static := len(decoded.Message.AccountKeys)
+	signers := int(header.NumRequiredSignatures)
+	loadedWritable := 0
+	if tx.Meta != nil {
+		loadedWritable = len(tx.Meta.LoadedAddresses.Writable)
+	}
+
+	accounts := make([]Account, len(keys))
+	for i, key := range keys {
+		a := Account{Index: i, PubKey: key, IsSigner: i < signers, IsProgram: programs[key]}
+		switch {
+		case i < signers:
+			a.IsWritable = i < signers-int(header.NumReadonlySignedAccounts)
+		case i < static:
+			a.IsWritable = i < static-int(header.NumReadonlyUnsignedAccounts)
+		default:
+			a.IsWritable = i < static+loadedWritable
+		}
+		accounts[i] = a
+	}
+	return accounts, nil
+}
+
+// Instruction is a top-level or inner instruction with its program and
+// accounts resolved against the transaction's account keys
+type Instruction struct {
+	ProgramID solana.PublicKey
+	Accounts  []solana.PublicKey
+	Data      []byte
+
+	// Position of the top-level instruction this is, or was invoked by
+	Index int
+	// Position among the top-level instruction's inner instructions, or -1
+	// for the top-level instruction itself
+	InnerIndex int
+}
+
+// Instructions returns every instruction in execution order: each top-level
+// instruction followed by its inner instructions. The order is always
+// (Index, InnerIndex), however meta.InnerInstructions orders its groups, so
+// repeated parses of a transaction agree.
+func Instructions(tx *rpc.GetTransactionResult) ([]Instruction, error) {
+	decoded, err := Decode(tx)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := AccountKeys(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	inner := make(map[int][]solana.CompiledInstruction)
+	if tx.Meta != nil {
+		for _, set := range tx.Meta.InnerInstructions {
+			inner[int(set.Index)] = append(inner[int(set.Index)], set.Instructions...)
+		}
+	}
+
+	var out []Instruction
+	for i, ix := range decoded.Message.Instructions {
+		resolved, err := resolve(keys, ix, i, -1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, resolved)
+		for j, innerIx := range inner[i] {
+			resolved, err := resolve(keys, innerIx, i, j)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, resolved)
+		}
+	}
+	return out, nil
+}
+
+func resolve(keys solana.PublicKeySlice, ix solana.CompiledInstruction, index, innerIndex int) (Instruction, error) {
+	if int(ix.ProgramIDIndex) >= len(keys) {
+		return Instruction{}, fmt.Errorf("instruction %d: program index %d out of range", index, ix.ProgramIDIndex)
+	}
+	accounts := make([]solana.PublicKey, len(ix.Accounts))
+	for k, idx := range ix.Accounts {
+		if int(idx) >= len(keys) {
+			return Instruction{}, fmt.Errorf("instruction %d: account index %d out of range", index, idx)
+		}
+		accounts[k] = keys[idx]
+	}
+	return Instruction{
+		ProgramID:  keys[ix.ProgramIDIndex],
+		Accounts:   accounts,
+		Data:       ix.Data,
+		Index:      index,
+		InnerIndex: innerIndex,
+	}, nil
+}
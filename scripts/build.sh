@@ -1,2 +1,2 @@
 cd ../go-src
-go build -o ../bin/getswaps ./cmd/getswaps/main.go
+go build -o ../bin/getswaps ./cmd/getswaps
@@ -25,10 +25,10 @@ def format_goswap_and_tx_to_coindesk(swap: dict, tx: dict) -> dict:
         "TYPE": None,
         "MARKET": None,
         "CHAIN_ASSET": None,
-        "INSTRUMENT": swap["transaction_data"][1]["Data"]["info"]["authority"],
-        "MAPPED_INSTRUMENT": swap["transaction_data"][1]["Data"]["info"]["authority"],
-        "BASE": address_to_TLA(swap["swap_data"]["TokenInMint"]),
-        "QUOTE": address_to_TLA(swap["swap_data"]["TokenOutMint"]),
+        "INSTRUMENT": swap["transaction_data"]["instructions"][1]["Data"]["info"]["authority"],
+        "MAPPED_INSTRUMENT": swap["transaction_data"]["instructions"][1]["Data"]["info"]["authority"],
+        "BASE": address_to_TLA(swap["swap_data"]["token_in_mint"]),
+        "QUOTE": address_to_TLA(swap["swap_data"]["token_out_mint"]),
         "SIDE": None,  # BUY OR SELL
         "ID": None,
         "TIMESTAMP": None,
@@ -42,7 +42,7 @@ def format_goswap_and_tx_to_coindesk(swap: dict, tx: dict) -> dict:
         "CCSEQ": None,
         "tx_HASH": None,
         "BLOCK_NUMBER": None,
-        "FROM": swap["transaction_data"][0]["Data"]["info"]["authority"],
+        "FROM": swap["transaction_data"]["instructions"][0]["Data"]["info"]["authority"],
         "MARKET_FEE_PERCENTAGE": None,
         "MARKET_FEE_VALUE": str(tx["meta"]["fee"]),
         "PROVIDER_KEY": get_provider_key(),